@@ -0,0 +1,134 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+func (s *Server) handleGetAlertRules(c *gin.Context) {
+	rules, err := s.db.GetAlertRules(false)
+	if err != nil {
+		logging.Error("api", "Failed to get alert rules: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alert rules"})
+		return
+	}
+	c.JSON(http.StatusOK, rules)
+}
+
+// handleSaveAlertRule creates (ID 0) or updates a rule. Updating starts the
+// rule's per-target states over, since they were reached under the old
+// condition or scope.
+func (s *Server) handleSaveAlertRule(c *gin.Context) {
+	var r storage.AlertRule
+	if err := c.ShouldBindJSON(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if err := validateAlertRule(&r); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if r.ID != 0 {
+		existing, err := s.db.GetAlertRuleByID(r.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Alert rule not found"})
+			return
+		}
+		r.CreatedAt = existing.CreatedAt
+		if err := s.db.ResetAlertStates(r.ID); err != nil {
+			logging.Error("api", "Failed to reset states of alert rule %d: %v", r.ID, err)
+		}
+	}
+	if err := s.db.SaveAlertRule(&r); err != nil {
+		logging.Error("api", "Failed to save alert rule %q: %v", r.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save alert rule"})
+		return
+	}
+	c.JSON(http.StatusOK, r)
+}
+
+func (s *Server) handleDeleteAlertRule(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := s.db.DeleteAlertRule(uint(id)); err != nil {
+		logging.Error("api", "Failed to delete alert rule %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete alert rule"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Alert rule deleted"})
+}
+
+// handleAlerts returns pending/firing alerts and the firing/resolved events
+// in the time range. Query: target (empty = all targets), start, end.
+func (s *Server) handleAlerts(c *gin.Context) {
+	target := c.Query("target")
+	start, end := parseTimeRange(c)
+	active, err := s.db.GetActiveAlerts()
+	if err != nil {
+		logging.Error("api", "Failed to get active alerts: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		return
+	}
+	if target != "" {
+		filtered := active[:0]
+		for _, st := range active {
+			if st.Target == target {
+				filtered = append(filtered, st)
+			}
+		}
+		active = filtered
+	}
+	events, err := s.db.GetAlertEvents(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get alert events for %q: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch alerts"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"active": active, "events": events})
+}
+
+func validateAlertRule(r *storage.AlertRule) error {
+	if r.Name == "" || len(r.Name) > 64 {
+		return fmt.Errorf("name is required (at most 64 characters)")
+	}
+	switch r.Metric {
+	case storage.AlertMetricLatency, storage.AlertMetricLoss, storage.AlertMetricSpeedDown, storage.AlertMetricSpeedUp:
+	default:
+		return fmt.Errorf("unknown metric %q", r.Metric)
+	}
+	above := r.Operator == ">" || r.Operator == ">="
+	if !above && r.Operator != "<" && r.Operator != "<=" {
+		return fmt.Errorf("operator must be one of >, >=, <, <=")
+	}
+	if r.Cycles < 0 || r.Cycles > 1000 || r.RecoverCycles < 0 || r.RecoverCycles > 1000 {
+		return fmt.Errorf("cycles must be between 0 and 1000")
+	}
+	if r.ForSec < 0 || r.ForSec > 7*24*3600 {
+		return fmt.Errorf("duration must be between 0 and 7 days")
+	}
+	if r.BaselineDays < 0 || r.BaselineDays > 90 {
+		return fmt.Errorf("baseline must be between 0 and 90 days")
+	}
+	if r.BaselineDays > 0 && r.Threshold <= 0 {
+		return fmt.Errorf("a baseline threshold is a multiple and must be positive")
+	}
+	// The resolve threshold must sit on the healthy side of the fire threshold
+	if rt := r.ResolveThreshold; rt != nil && ((above && *rt > r.Threshold) || (!above && *rt < r.Threshold)) {
+		return fmt.Errorf("resolve threshold must not be past the alert threshold")
+	}
+	if len(r.Target) > 128 || len(r.Group) > 64 {
+		return fmt.Errorf("scope is too long")
+	}
+	return nil
+}
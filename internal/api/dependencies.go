@@ -0,0 +1,50 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// validateParent checks that a target's parent exists and that following
+// parents from it never leads back to the target
+func (s *Server) validateParent(id uint, address, parent string) error {
+	if parent == address {
+		return fmt.Errorf("a target cannot be its own parent")
+	}
+	targets, err := s.db.GetTargets(false)
+	if err != nil {
+		return fmt.Errorf("failed to load targets: %w", err)
+	}
+	parentOf := make(map[string]string, len(targets))
+	for _, t := range targets {
+		// The target being saved may be changing its address
+		if t.ID == id && id != 0 {
+			continue
+		}
+		parentOf[t.Address] = t.Parent
+	}
+	if _, ok := parentOf[parent]; !ok {
+		return fmt.Errorf("parent %q is not a target", parent)
+	}
+	for p, hops := parent, 0; p != ""; p, hops = parentOf[p], hops+1 {
+		if p == address || hops > len(parentOf) {
+			return fmt.Errorf("parent %q would create a dependency loop", parent)
+		}
+	}
+	return nil
+}
+
+// handleSuggestParents proposes parents from the common prefixes of the
+// targets' latest paths
+func (s *Server) handleSuggestParents(c *gin.Context) {
+	suggestions, err := s.monitor.SuggestParents()
+	if err != nil {
+		logging.Error("api", "Failed to suggest parents: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to suggest parents"})
+		return
+	}
+	c.JSON(http.StatusOK, suggestions)
+}
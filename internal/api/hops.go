@@ -0,0 +1,105 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// routerTarget sums up one target's samples of a router
+type routerTarget struct {
+	Target       string    `json:"target"`
+	Hops         []int     `json:"hops"` // Positions the router was seen at on this path
+	Samples      int       `json:"samples"`
+	AvgLoss      float64   `json:"avg_loss"`
+	AvgLatencyMs float64   `json:"avg_latency_ms"` // Over samples that got an answer
+	FirstSeen    time.Time `json:"first_seen"`
+	LastSeen     time.Time `json:"last_seen"`
+}
+
+// handleHopSeries returns a target's per-hop samples, oldest first.
+// Query: target, hop (position, optional), ip (optional), start, end
+func (s *Server) handleHopSeries(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+	hop := 0
+	if v := c.Query("hop"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "hop must be a positive number"})
+			return
+		}
+		hop = n
+	}
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetHopSeries(target, hop, c.Query("ip"), start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get hop series for %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch hop history"})
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// handleRouterSeries returns a router's samples across every target whose
+// path crossed it, oldest first, with a summary per target.
+// Query: ip, start, end
+func (s *Server) handleRouterSeries(c *gin.Context) {
+	ip := c.Query("ip")
+	if ip == "" || ip == "*" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "ip is required"})
+		return
+	}
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetRouterSeries(ip, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get router series for %s: %v", ip, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch router history"})
+		return
+	}
+
+	byTarget := map[string]*routerTarget{}
+	answered := map[string]int{}
+	var targets []*routerTarget
+	for _, r := range records {
+		sum := byTarget[r.Target]
+		if sum == nil {
+			sum = &routerTarget{Target: r.Target, FirstSeen: r.CreatedAt}
+			byTarget[r.Target] = sum
+			targets = append(targets, sum)
+		}
+		if !slices.Contains(sum.Hops, r.Hop) {
+			sum.Hops = append(sum.Hops, r.Hop)
+		}
+		sum.Samples++
+		sum.AvgLoss += r.Loss
+		if r.Loss < 100 && r.LatencyAvg > 0 {
+			sum.AvgLatencyMs += r.LatencyAvg
+			answered[r.Target]++
+		}
+		sum.LastSeen = r.CreatedAt
+	}
+	for _, sum := range targets {
+		sum.AvgLoss /= float64(sum.Samples)
+		if n := answered[sum.Target]; n > 0 {
+			sum.AvgLatencyMs /= float64(n)
+		}
+		sort.Ints(sum.Hops)
+	}
+	if targets == nil {
+		targets = []*routerTarget{}
+	}
+	if records == nil {
+		records = []storage.HopRecord{}
+	}
+	c.JSON(http.StatusOK, gin.H{"ip": ip, "targets": targets, "records": records})
+}
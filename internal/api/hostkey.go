@@ -0,0 +1,86 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+	"golang.org/x/crypto/ssh"
+)
+
+// targetFromParam loads the target named by the :id path parameter,
+// writing the error response itself when it cannot
+func (s *Server) targetFromParam(c *gin.Context) (*storage.Target, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return nil, false
+	}
+	t, err := s.db.GetTargetByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Target not found"})
+		return nil, false
+	}
+	return t, true
+}
+
+// handleSetHostKey pins an SSH host key ahead of the first connection.
+// Body: {"host_key": "ssh-ed25519 AAAA..."} (authorized_keys or known_hosts line)
+func (s *Server) handleSetHostKey(c *gin.Context) {
+	t, ok := s.targetFromParam(c)
+	if !ok {
+		return
+	}
+	var req struct {
+		HostKey string `json:"host_key"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil || req.HostKey == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "host_key is required"})
+		return
+	}
+	key, err := prober.ParseHostKey(req.HostKey)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.db.PinTargetHostKey(t.Address, prober.FormatHostKey(key)); err != nil {
+		logging.Error("api", "Failed to pin host key for target %d: %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save host key"})
+		return
+	}
+	logging.Info("security", "SSH host key for %s pinned by admin", t.Address)
+	c.JSON(http.StatusOK, gin.H{"message": "Host key pinned", "fingerprint": ssh.FingerprintSHA256(key)})
+}
+
+// handleAcceptHostKey trusts the key a target offered after a mismatch (key rotation)
+func (s *Server) handleAcceptHostKey(c *gin.Context) {
+	t, ok := s.targetFromParam(c)
+	if !ok {
+		return
+	}
+	if err := s.db.AcceptPendingHostKey(t.ID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	logging.Warn("security", "SSH host key rotation for %s accepted by admin", t.Address)
+	c.JSON(http.StatusOK, gin.H{"message": "Host key accepted"})
+}
+
+// handleForgetHostKey drops the pinned key; the next login pins whatever is offered
+func (s *Server) handleForgetHostKey(c *gin.Context) {
+	t, ok := s.targetFromParam(c)
+	if !ok {
+		return
+	}
+	if err := s.db.PinTargetHostKey(t.Address, ""); err != nil {
+		logging.Error("api", "Failed to clear host key for target %d: %v", t.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to clear host key"})
+		return
+	}
+	logging.Warn("security", "SSH host key for %s cleared by admin", t.Address)
+	c.JSON(http.StatusOK, gin.H{"message": "Host key cleared"})
+}
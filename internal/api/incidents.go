@@ -0,0 +1,29 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// handleIncidents lists incidents overlapping the time range, newest first.
+// Query: target (empty = all targets), start, end. With a target the
+// response also carries its MTTR/MTBF summary over the range.
+func (s *Server) handleIncidents(c *gin.Context) {
+	target := c.Query("target")
+	start, end := parseTimeRange(c)
+	incidents, err := s.db.GetIncidents(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get incidents for %q: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch incidents"})
+		return
+	}
+
+	resp := gin.H{"incidents": incidents}
+	if target != "" {
+		resp["summary"] = storage.SummarizeIncidents(incidents, start, end)
+	}
+	c.JSON(http.StatusOK, resp)
+}
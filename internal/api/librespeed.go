@@ -0,0 +1,66 @@
+package api
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// LibreSpeed backend limits (mirrors garbage.php)
+const (
+	libreSpeedChunkBytes   = 1024 * 1024
+	libreSpeedDefaultCount = 4
+	libreSpeedMaxCount     = 1024
+)
+
+// setLibreSpeedNoCache applies the cache headers the LibreSpeed backend sends on every response
+func setLibreSpeedNoCache(c *gin.Context) {
+	c.Header("Cache-Control", "no-store, no-cache, must-revalidate, max-age=0, s-maxage=0")
+	c.Header("Pragma", "no-cache")
+}
+
+// handleLibreSpeedGarbage streams ckSize MiB of random data (garbage.php)
+func (s *Server) handleLibreSpeedGarbage(c *gin.Context) {
+	count := libreSpeedDefaultCount
+	if raw := c.Query("ckSize"); raw != "" {
+		if parsed, err := strconv.Atoi(raw); err == nil && parsed > 0 {
+			count = parsed
+		}
+	}
+	if count > libreSpeedMaxCount {
+		count = libreSpeedMaxCount
+	}
+	size := int64(count) * libreSpeedChunkBytes
+
+	setLibreSpeedNoCache(c)
+	c.Header("Content-Description", "File Transfer")
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Disposition", "attachment; filename=random.dat")
+	c.Header("Content-Transfer-Encoding", "binary")
+	c.Header("Content-Length", strconv.FormatInt(size, 10))
+	c.Status(http.StatusOK)
+
+	// Clients abort downloads when their test window ends
+	_ = writeGeneratedBytes(c.Writer, size)
+}
+
+// handleLibreSpeedEmpty answers ping probes and discards upload bodies (empty.php)
+func (s *Server) handleLibreSpeedEmpty(c *gin.Context) {
+	if c.Request.Body != nil {
+		io.Copy(io.Discard, http.MaxBytesReader(c.Writer, c.Request.Body, speedTestMaxBytes))
+	}
+	setLibreSpeedNoCache(c)
+	c.Header("Connection", "keep-alive")
+	c.Status(http.StatusOK)
+}
+
+// handleLibreSpeedGetIP reports the client address as seen by this server (getIP.php)
+func (s *Server) handleLibreSpeedGetIP(c *gin.Context) {
+	setLibreSpeedNoCache(c)
+	c.JSON(http.StatusOK, gin.H{
+		"processedString": c.ClientIP(),
+		"rawIspInfo":      "",
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/cron"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// Longest a single occurrence of a window may last
+const maxWindowDuration = 7 * 24 * time.Hour
+
+// windowView is a MaintenanceWindow with whether it is in effect now
+type windowView struct {
+	storage.MaintenanceWindow
+	Active    bool       `json:"active"`
+	ActiveEnd *time.Time `json:"active_until,omitempty"`
+}
+
+func (s *Server) handleGetMaintenanceWindows(c *gin.Context) {
+	windows, err := s.db.GetMaintenanceWindows(false)
+	if err != nil {
+		logging.Error("api", "Failed to get maintenance windows: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch maintenance windows"})
+		return
+	}
+	now := time.Now()
+	views := make([]windowView, 0, len(windows))
+	for _, w := range windows {
+		v := windowView{MaintenanceWindow: w}
+		if active, until := w.ActiveAt(now); active {
+			v.Active, v.ActiveEnd = true, &until
+		}
+		views = append(views, v)
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// handleSaveMaintenanceWindow creates (ID 0) or updates a window
+func (s *Server) handleSaveMaintenanceWindow(c *gin.Context) {
+	var w storage.MaintenanceWindow
+	if err := c.ShouldBindJSON(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateMaintenanceWindow(&w); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if w.ID != 0 {
+		existing, err := s.db.GetMaintenanceWindowByID(w.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Maintenance window not found"})
+			return
+		}
+		w.CreatedAt = existing.CreatedAt
+	}
+	if err := s.db.SaveMaintenanceWindow(&w); err != nil {
+		logging.Error("api", "Failed to save maintenance window %q: %v", w.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save maintenance window"})
+		return
+	}
+	c.JSON(http.StatusOK, w)
+}
+
+func (s *Server) handleDeleteMaintenanceWindow(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := s.db.DeleteMaintenanceWindow(uint(id)); err != nil {
+		logging.Error("api", "Failed to delete maintenance window %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete maintenance window"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Maintenance window deleted"})
+}
+
+// validateMaintenanceWindow checks a window and normalizes its lists
+func validateMaintenanceWindow(w *storage.MaintenanceWindow) error {
+	w.Name = strings.TrimSpace(w.Name)
+	if w.Name == "" || len(w.Name) > 64 {
+		return fmt.Errorf("name is required (at most 64 characters)")
+	}
+	if w.Kind != storage.WindowMaintenance && w.Kind != storage.WindowSilence {
+		return fmt.Errorf("kind must be %q or %q", storage.WindowMaintenance, storage.WindowSilence)
+	}
+	w.Targets = strings.Join(storage.SplitList(w.Targets), ",")
+	w.Tags = strings.Join(storage.SplitList(w.Tags), ",")
+	if len(w.Targets) > 4096 || len(w.Tags) > 1024 {
+		return fmt.Errorf("scope is too long")
+	}
+
+	w.Cron = strings.TrimSpace(w.Cron)
+	if w.Cron == "" {
+		w.DurationMin = 0
+		if w.StartsAt == nil || w.EndsAt == nil {
+			return fmt.Errorf("a one-off window needs a start and an end")
+		}
+		if !w.EndsAt.After(*w.StartsAt) {
+			return fmt.Errorf("window must end after it starts")
+		}
+		return nil
+	}
+	if _, err := cron.Parse(w.Cron); err != nil {
+		return err
+	}
+	if w.DurationMin <= 0 || time.Duration(w.DurationMin)*time.Minute > maxWindowDuration {
+		return fmt.Errorf("duration must be between 1 minute and 7 days")
+	}
+	if w.StartsAt != nil && w.EndsAt != nil && !w.EndsAt.After(*w.StartsAt) {
+		return fmt.Errorf("window must end after it starts")
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/internal/monitor"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// handleMetrics returns check probe samples.
+// Query: target, probe (e.g. ssh_session), name (e.g. auth_ms), start, end.
+// Leaving probe or name empty returns every series for the target.
+func (s *Server) handleMetrics(c *gin.Context) {
+	target := c.Query("target")
+	probe := c.Query("probe")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetMetricHistory(target, probe, c.Query("name"), start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get %s metrics for %s: %v", probe, target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch metrics"})
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
+
+// handleHostClock returns this host's clock offset against RS_NTP_REFERENCE.
+// Query: name (e.g. offset_ms, empty = all), start, end
+func (s *Server) handleHostClock(c *gin.Context) {
+	ref := os.Getenv("RS_NTP_REFERENCE")
+	if ref == "" {
+		c.JSON(http.StatusOK, gin.H{"reference": "", "records": []interface{}{}})
+		return
+	}
+
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetMetricHistory(ref, monitor.MetricProbeHostClock, c.Query("name"), start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get host clock history: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch host clock history"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"reference": ref, "records": records})
+}
+
+// handleInterfaceHistory returns SNMP interface rates for a target.
+// Query: target, interface (empty = all polled interfaces), start, end
+func (s *Server) handleInterfaceHistory(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetInterfaceHistory(target, c.Query("interface"), start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get interface history for %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch interface history"})
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
@@ -0,0 +1,167 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/notify"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// channelView is a NotificationChannel as the API shows it: config as an
+// object, secrets left out and only listed by name when set
+type channelView struct {
+	storage.NotificationChannel
+	Config     map[string]interface{} `json:"config"`
+	SecretsSet []string               `json:"secrets_set"`
+}
+
+func (s *Server) channelView(ch storage.NotificationChannel) channelView {
+	v := channelView{NotificationChannel: ch, Config: map[string]interface{}{}, SecretsSet: []string{}}
+	if ch.Config != "" {
+		_ = json.Unmarshal([]byte(ch.Config), &v.Config)
+	}
+	if secrets, err := s.db.ChannelSecrets(&ch); err == nil {
+		for _, k := range notify.SecretFields(ch.Type) {
+			if secrets[k] != "" {
+				v.SecretsSet = append(v.SecretsSet, k)
+			}
+		}
+	}
+	return v
+}
+
+func (s *Server) handleGetChannels(c *gin.Context) {
+	channels, err := s.db.GetChannels(false)
+	if err != nil {
+		logging.Error("api", "Failed to get notification channels: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notification channels"})
+		return
+	}
+	views := make([]channelView, 0, len(channels))
+	for _, ch := range channels {
+		views = append(views, s.channelView(ch))
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// handleSaveChannel creates (ID 0) or updates a channel. Secret fields in
+// config are split off and sealed; an empty value keeps the stored secret
+// and null removes it, so the UI never needs to see secrets to edit a channel.
+func (s *Server) handleSaveChannel(c *gin.Context) {
+	var req struct {
+		ID          uint                   `json:"id"`
+		Name        string                 `json:"name"`
+		Type        string                 `json:"type"`
+		Enabled     bool                   `json:"enabled"`
+		RatePerHour int                    `json:"rate_per_hour"`
+		Config      map[string]interface{} `json:"config"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" || len(req.Name) > 64 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "name is required (at most 64 characters)"})
+		return
+	}
+	if req.RatePerHour < 0 || req.RatePerHour > 3600 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rate limit must be between 0 and 3600 per hour"})
+		return
+	}
+
+	ch := storage.NotificationChannel{ID: req.ID, Name: req.Name, Type: req.Type, Enabled: req.Enabled, RatePerHour: req.RatePerHour}
+	secrets := map[string]string{}
+	if req.ID != 0 {
+		existing, err := s.db.GetChannelByID(req.ID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+			return
+		}
+		ch.CreatedAt = existing.CreatedAt
+		// Changing the type drops secrets meant for the old one
+		if existing.Type == req.Type {
+			if secrets, err = s.db.ChannelSecrets(existing); err != nil {
+				logging.Warn("api", "Stored secrets of channel %d are unreadable, they must be re-entered: %v", req.ID, err)
+				secrets = map[string]string{}
+			}
+		}
+	}
+
+	config := req.Config
+	if config == nil {
+		config = map[string]interface{}{}
+	}
+	for _, k := range notify.SecretFields(req.Type) {
+		v, present := config[k]
+		delete(config, k)
+		switch val := v.(type) {
+		case nil:
+			if present {
+				delete(secrets, k)
+			}
+		case string:
+			if val != "" {
+				secrets[k] = val
+			}
+		default:
+			// Structured secrets (webhook headers) are stored as JSON
+			if raw, err := json.Marshal(val); err == nil && string(raw) != "{}" {
+				secrets[k] = string(raw)
+			}
+		}
+	}
+	raw, _ := json.Marshal(config)
+	ch.Config = string(raw)
+
+	// Building the sender validates type, URLs, addresses and templates
+	if _, err := notify.New(ch.Type, ch.Config, secrets); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := s.db.SaveChannel(&ch, secrets); err != nil {
+		logging.Error("api", "Failed to save notification channel %q: %v", ch.Name, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save notification channel"})
+		return
+	}
+	c.JSON(http.StatusOK, s.channelView(ch))
+}
+
+func (s *Server) handleDeleteChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	if err := s.db.DeleteChannel(uint(id)); err != nil {
+		logging.Error("api", "Failed to delete notification channel %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification channel"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification channel deleted"})
+}
+
+// handleTestChannel sends a test message through a saved channel and
+// reports the channel's error, if any
+func (s *Server) handleTestChannel(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid ID"})
+		return
+	}
+	ch, err := s.db.GetChannelByID(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification channel not found"})
+		return
+	}
+	if err := s.monitor.TestChannel(ch); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Test notification sent"})
+}
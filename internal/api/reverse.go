@@ -0,0 +1,54 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// handleReverseTrace returns the latest path measured from an SSH target back
+// toward us. Same layout as /trace plus vantage, tool and measured_at.
+// Query: target (vantage point address), lang
+func (s *Server) handleReverseTrace(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+
+	rec, err := s.db.GetLatestReversePath(target)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "reverse trace not found"})
+		return
+	}
+
+	var payload map[string]interface{}
+	if err := json.Unmarshal(localizeTraceJSON(rec.TraceJson, c.Query("lang")), &payload); err != nil {
+		payload = map[string]interface{}{"target": rec.Destination, "hops": []interface{}{}}
+	}
+	payload["vantage"] = rec.Target
+	payload["tool"] = rec.Tool
+	payload["measured_at"] = rec.CreatedAt
+	c.JSON(http.StatusOK, payload)
+}
+
+// handleReverseHistory returns reverse path latency/loss over time.
+// Query: target, start, end (RFC3339, default last 6 hours)
+func (s *Server) handleReverseHistory(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetReversePathHistory(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get reverse history for %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch reverse history"})
+		return
+	}
+	c.JSON(http.StatusOK, records)
+}
@@ -0,0 +1,116 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// routePathView is a path version with its lists split out, how long it
+// has been active and the latency seen over the part inside the range
+type routePathView struct {
+	storage.RoutePath
+	Hops           []string `json:"hops"`
+	ASPath         []string `json:"as_path"`
+	Current        bool     `json:"current"`
+	ActiveSec      float64  `json:"active_sec"`      // Whole version, up to now for the current one
+	AvgLatencyMs   float64  `json:"avg_latency_ms"`  // Over the version's overlap with the range
+	LatencySamples int64    `json:"latency_samples"` // Regular successful cycles averaged
+}
+
+type routeChangeView struct {
+	storage.RouteChange
+	FromHops   []string        `json:"from_hops"`
+	ToHops     []string        `json:"to_hops"`
+	FromASPath []string        `json:"from_as_path"`
+	ToASPath   []string        `json:"to_as_path"`
+	Diff       json.RawMessage `json:"diff"`
+}
+
+// handleRoutePaths lists a target's path versions overlapping the range,
+// oldest first. Query: target, start, end (RFC3339, default last 6 hours)
+func (s *Server) handleRoutePaths(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+	start, end := parseTimeRange(c)
+	paths, err := s.db.GetRoutePaths(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get path versions for %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch path versions"})
+		return
+	}
+
+	now := time.Now()
+	views := make([]routePathView, 0, len(paths))
+	for _, p := range paths {
+		v := routePathView{
+			RoutePath: p,
+			Hops:      nonNil(storage.SplitList(p.Hops)),
+			ASPath:    nonNil(storage.SplitList(p.ASPath)),
+			Current:   p.EndedAt == nil,
+		}
+		until := now
+		if p.EndedAt != nil {
+			until = *p.EndedAt
+		}
+		v.ActiveSec = until.Sub(p.StartedAt).Seconds()
+
+		from, to := p.StartedAt, until
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if avg, n, err := s.db.GetLatencyStats(target, from, to); err == nil {
+			v.AvgLatencyMs, v.LatencySamples = avg, n
+		}
+		views = append(views, v)
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// handleRouteChanges lists route changes in the range, newest first.
+// Query: target (empty = all targets), start, end
+func (s *Server) handleRouteChanges(c *gin.Context) {
+	target := c.Query("target")
+	start, end := parseTimeRange(c)
+	changes, err := s.db.GetRouteChanges(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get route changes for %q: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch route changes"})
+		return
+	}
+
+	views := make([]routeChangeView, 0, len(changes))
+	for _, ch := range changes {
+		v := routeChangeView{
+			RouteChange: ch,
+			FromHops:    nonNil(storage.SplitList(ch.FromHops)),
+			ToHops:      nonNil(storage.SplitList(ch.ToHops)),
+			FromASPath:  nonNil(storage.SplitList(ch.FromASPath)),
+			ToASPath:    nonNil(storage.SplitList(ch.ToASPath)),
+			Diff:        json.RawMessage("[]"),
+		}
+		if json.Valid([]byte(ch.Diff)) {
+			v.Diff = json.RawMessage(ch.Diff)
+		}
+		views = append(views, v)
+	}
+	c.JSON(http.StatusOK, views)
+}
+
+// nonNil keeps empty lists as [] rather than null in responses
+func nonNil(list []string) []string {
+	if list == nil {
+		return []string{}
+	}
+	return list
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/yuanweize/RouteLens/internal/auth"
 	"github.com/yuanweize/RouteLens/internal/monitor"
 	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
 	"github.com/yuanweize/RouteLens/pkg/storage"
 )
 
@@ -80,6 +81,11 @@ type Server struct {
 	distFS   fs.FS
 	dbPath   string
 	settings SystemSettings
+
+	// speedTestToken enables the peer speed test endpoints when set (RS_SPEEDTEST_TOKEN)
+	speedTestToken string
+	// libreSpeedEnabled exposes LibreSpeed-compatible backend endpoints (RS_LIBRESPEED_ENABLED)
+	libreSpeedEnabled bool
 }
 
 func NewServer(db *storage.DB, mon *monitor.Service, distFS fs.FS, dbPath string) *Server {
@@ -95,6 +101,8 @@ func NewServer(db *storage.DB, mon *monitor.Service, distFS fs.FS, dbPath string
 			SpeedTestInterval: 5,
 			PingInterval:      30,
 		},
+		speedTestToken:    os.Getenv("RS_SPEEDTEST_TOKEN"),
+		libreSpeedEnabled: os.Getenv("RS_LIBRESPEED_ENABLED") == "true",
 	}
 	s.setupRoutes()
 	return s
@@ -112,8 +120,12 @@ func (s *Server) setupRoutes() {
 		if origin == "" || strings.HasPrefix(origin, "http://localhost") || strings.HasPrefix(origin, "http://127.0.0.1") {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 		}
+		// LibreSpeed web clients are usually hosted on another origin
+		if s.libreSpeedEnabled && strings.HasPrefix(c.Request.URL.Path, "/backend/") {
+			c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
+		}
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-RouteLens-Token")
 		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
 		c.Writer.Header().Set("X-Content-Type-Options", "nosniff")
 		c.Writer.Header().Set("X-Frame-Options", "DENY")
@@ -186,13 +198,45 @@ func (s *Server) setupRoutes() {
 	s.router.GET("/api/v1/system/info", s.handleSystemInfo)      // Public: version info is not sensitive
 	s.router.GET("/api/v1/system/releases", s.handleGetReleases) // Public: GitHub releases info
 
+	// Peer Speed Test API (opt-in): authenticated by a shared token instead of JWT
+	// so other RouteLens instances can measure throughput against this one
+	if s.speedTestToken != "" {
+		speed := s.router.Group("/api/v1/speedtest")
+		speed.Use(SpeedTestTokenMiddleware(s.speedTestToken))
+		{
+			speed.GET("/download", s.handleSpeedTestDownload)
+			speed.POST("/upload", s.handleSpeedTestUpload)
+		}
+		logging.Info("api", "Peer speed test endpoints enabled at /api/v1/speedtest")
+	}
+
+	// LibreSpeed Backend (opt-in, public): same layout as the PHP backend so any
+	// LibreSpeed web client or RouteLens MODE_LIBRESPEED probe can test against us
+	if s.libreSpeedEnabled {
+		ls := s.router.Group("/backend")
+		{
+			ls.GET("/garbage.php", s.handleLibreSpeedGarbage)
+			ls.GET("/empty.php", s.handleLibreSpeedEmpty)
+			ls.POST("/empty.php", s.handleLibreSpeedEmpty)
+			ls.GET("/getIP.php", s.handleLibreSpeedGetIP)
+		}
+		logging.Info("api", "LibreSpeed backend endpoints enabled at /backend")
+	}
+
 	// Protected API
 	api := s.router.Group("/api/v1")
 	api.Use(auth.AuthMiddleware())
 	{
 		api.GET("/status", s.handleStatus)
 		api.GET("/history", s.handleHistory)
+		api.GET("/availability", s.handleAvailability)
+		api.GET("/incidents", s.handleIncidents)
 		api.GET("/trace", s.handleTrace)
+		api.GET("/trace/reverse", s.handleReverseTrace)
+		api.GET("/history/reverse", s.handleReverseHistory)
+		api.GET("/metrics", s.handleMetrics)
+		api.GET("/clock", s.handleHostClock)
+		api.GET("/history/interfaces", s.handleInterfaceHistory)
 		api.POST("/probe", s.handleProbe)
 		api.POST("/user/password", s.handleUpdatePassword)
 
@@ -201,6 +245,29 @@ func (s *Server) setupRoutes() {
 		api.POST("/targets", s.handleSaveTarget)
 		api.DELETE("/targets/:id", s.handleDeleteTarget)
 
+		// Alerting
+		api.GET("/alerts", s.handleAlerts)
+		api.GET("/alerts/rules", s.handleGetAlertRules)
+		api.POST("/alerts/rules", s.handleSaveAlertRule)
+		api.DELETE("/alerts/rules/:id", s.handleDeleteAlertRule)
+		api.GET("/notifications/channels", s.handleGetChannels)
+		api.POST("/notifications/channels", s.handleSaveChannel)
+		api.DELETE("/notifications/channels/:id", s.handleDeleteChannel)
+		api.POST("/notifications/channels/:id/test", s.handleTestChannel)
+		api.GET("/routes/paths", s.handleRoutePaths)
+		api.GET("/routes/changes", s.handleRouteChanges)
+		api.GET("/hops", s.handleHopSeries)
+		api.GET("/hops/router", s.handleRouterSeries)
+		api.GET("/dependencies/suggestions", s.handleSuggestParents)
+		api.GET("/maintenance", s.handleGetMaintenanceWindows)
+		api.POST("/maintenance", s.handleSaveMaintenanceWindow)
+		api.DELETE("/maintenance/:id", s.handleDeleteMaintenanceWindow)
+
+		// SSH host key pinning
+		api.PUT("/targets/:id/hostkey", s.handleSetHostKey)
+		api.POST("/targets/:id/hostkey/accept", s.handleAcceptHostKey)
+		api.DELETE("/targets/:id/hostkey", s.handleForgetHostKey)
+
 		// System Logs
 		api.GET("/logs", s.handleGetLogs)
 
@@ -384,30 +451,52 @@ func (s *Server) handleHistory(c *gin.Context) {
 		return
 	}
 
-	startStr := c.Query("start")
-	endStr := c.Query("end")
+	start, end := parseTimeRange(c)
+	records, err := s.db.GetHistory(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get history for %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
+		return
+	}
+
+	c.JSON(http.StatusOK, records)
+}
 
+// handleAvailability reports the share of ping cycles that succeeded, with
+// failed cycles broken down by status
+func (s *Server) handleAvailability(c *gin.Context) {
+	target := c.Query("target")
+	if target == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "target is required"})
+		return
+	}
+
+	start, end := parseTimeRange(c)
+	avail, err := s.db.GetAvailability(target, start, end)
+	if err != nil {
+		logging.Error("api", "Failed to get availability for %s: %v", target, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch availability"})
+		return
+	}
+
+	c.JSON(http.StatusOK, avail)
+}
+
+// parseTimeRange reads RFC3339 start/end query parameters, defaulting to the last 6 hours
+func parseTimeRange(c *gin.Context) (time.Time, time.Time) {
 	end := time.Now()
 	start := end.Add(-6 * time.Hour)
-	if startStr != "" {
+	if startStr := c.Query("start"); startStr != "" {
 		if parsed, err := time.Parse(time.RFC3339, startStr); err == nil {
 			start = parsed
 		}
 	}
-	if endStr != "" {
+	if endStr := c.Query("end"); endStr != "" {
 		if parsed, err := time.Parse(time.RFC3339, endStr); err == nil {
 			end = parsed
 		}
 	}
-
-	records, err := s.db.GetHistory(target, start, end)
-	if err != nil {
-		logging.Error("api", "Failed to get history for %s: %v", target, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch history"})
-		return
-	}
-
-	c.JSON(http.StatusOK, records)
+	return start, end
 }
 
 func (s *Server) handleProbe(c *gin.Context) {
@@ -442,19 +531,19 @@ func (s *Server) handleTrace(c *gin.Context) {
 		return
 	}
 
-	// Check if language localization is needed
-	lang := c.Query("lang")
+	c.Data(http.StatusOK, "application/json", localizeTraceJSON(rec.TraceJson, c.Query("lang")))
+}
+
+// localizeTraceJSON swaps hop city/subdiv/country for their English versions
+// unless lang is Chinese (the stored default)
+func localizeTraceJSON(raw []byte, lang string) []byte {
 	if lang == "" || strings.HasPrefix(lang, "zh") {
-		// Default: return raw JSON (Chinese)
-		c.Data(http.StatusOK, "application/json", rec.TraceJson)
-		return
+		return raw
 	}
 
-	// For English, swap city/subdiv/country with their _en versions
 	var payload map[string]interface{}
-	if err := json.Unmarshal(rec.TraceJson, &payload); err != nil {
-		c.Data(http.StatusOK, "application/json", rec.TraceJson)
-		return
+	if err := json.Unmarshal(raw, &payload); err != nil {
+		return raw
 	}
 
 	if hops, ok := payload["hops"].([]interface{}); ok {
@@ -476,10 +565,9 @@ func (s *Server) handleTrace(c *gin.Context) {
 
 	localizedJson, err := json.Marshal(payload)
 	if err != nil {
-		c.Data(http.StatusOK, "application/json", rec.TraceJson)
-		return
+		return raw
 	}
-	c.Data(http.StatusOK, "application/json", localizedJson)
+	return localizedJson
 }
 
 func (s *Server) handleGetTargets(c *gin.Context) {
@@ -515,21 +603,65 @@ func (s *Server) handleSaveTarget(c *gin.Context) {
 	}
 
 	// Clean SSH key in probe_config: remove \r\n and normalize to \n
-	if t.ProbeConfig != "" && t.ProbeType == storage.ProbeModeSSH {
+	if t.ProbeConfig != "" && (t.ProbeType == storage.ProbeModeSSH || t.ProbeType == storage.ProbeModeSSHSession) {
 		t.ProbeConfig = cleanSSHKeyInConfig(t.ProbeConfig)
 	}
 
+	// Host keys may be pre-seeded here; pending keys only come from probes
+	t.SSHHostKeyPending = ""
+	if t.SSHHostKey != "" {
+		key, err := prober.ParseHostKey(t.SSHHostKey)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		t.SSHHostKey = prober.FormatHostKey(key)
+	}
+
 	if t.ProbeType == "" {
 		t.ProbeType = storage.ProbeModeICMP
 	}
 	switch t.ProbeType {
-	case storage.ProbeModeICMP, storage.ProbeModeHTTP, storage.ProbeModeSSH, storage.ProbeModeIPERF:
+	case storage.ProbeModeICMP, storage.ProbeModeHTTP, storage.ProbeModeSSH, storage.ProbeModeIPERF,
+		storage.ProbeModeRouteLens, storage.ProbeModeLibreSpeed, storage.ProbeModeSSHSession, storage.ProbeModePlugin,
+		storage.ProbeModeGRPC, storage.ProbeModeNTP, storage.ProbeModeTWAMP,
+		storage.ProbeModeUDPEcho:
 		// ok
 	default:
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid probe_type"})
 		return
 	}
 
+	t.Tags = strings.Join(storage.SplitList(t.Tags), ",")
+	if len(t.Tags) > 255 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "tags are too long"})
+		return
+	}
+	t.Parent = strings.TrimSpace(t.Parent)
+	if t.Parent != "" {
+		if err := s.validateParent(t.ID, t.Address, t.Parent); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	if t.SNMPConfig != "" && !json.Valid([]byte(t.SNMPConfig)) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "snmp_config must be valid JSON"})
+		return
+	}
+
+	// Reject plugins outside RS_PLUGIN_PATHS up front rather than on the first cycle
+	if t.ProbeType == storage.ProbeModePlugin {
+		var cfg struct {
+			Command string `json:"command"`
+		}
+		_ = json.Unmarshal([]byte(t.ProbeConfig), &cfg)
+		if _, err := prober.ResolvePlugin(cfg.Command, prober.ParsePluginAllowList(os.Getenv("RS_PLUGIN_PATHS"))); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
 	// Distinguish between Create (ID=0) and Update (ID>0)
 	if t.ID == 0 {
 		// Create new target
@@ -0,0 +1,101 @@
+package api
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// Speed test payload limits for the built-in peer endpoints
+const (
+	speedTestDefaultBytes = 25 * 1024 * 1024
+	speedTestMaxBytes     = 1024 * 1024 * 1024
+	speedTestChunkSize    = 64 * 1024
+)
+
+// speedTestChunk is a block of random bytes reused for every download.
+// Random data keeps transparent compression on the path from inflating results.
+var speedTestChunk = func() []byte {
+	buf := make([]byte, speedTestChunkSize)
+	_, _ = rand.Read(buf)
+	return buf
+}()
+
+// SpeedTestTokenMiddleware guards the peer speed test endpoints with a shared token.
+// Peers send it as "X-RouteLens-Token: <token>" or "Authorization: Bearer <token>".
+func SpeedTestTokenMiddleware(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-RouteLens-Token")
+		if provided == "" {
+			provided = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		}
+
+		if provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			logging.Warn("security", "Rejected speed test request from %s: invalid token", c.ClientIP())
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid speed test token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// handleSpeedTestDownload streams generated bytes to the peer.
+// Query: bytes (optional, default 25MB, max 1GB)
+func (s *Server) handleSpeedTestDownload(c *gin.Context) {
+	size := int64(speedTestDefaultBytes)
+	if raw := c.Query("bytes"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid bytes parameter"})
+			return
+		}
+		size = parsed
+	}
+	if size > speedTestMaxBytes {
+		size = speedTestMaxBytes
+	}
+
+	c.Header("Content-Type", "application/octet-stream")
+	c.Header("Content-Length", strconv.FormatInt(size, 10))
+	c.Header("Cache-Control", "no-store")
+	c.Status(http.StatusOK)
+
+	// Peer may abort mid-transfer; nothing left to report in that case
+	_ = writeGeneratedBytes(c.Writer, size)
+}
+
+// writeGeneratedBytes writes size bytes of incompressible filler to w
+func writeGeneratedBytes(w io.Writer, size int64) error {
+	remaining := size
+	for remaining > 0 {
+		chunk := speedTestChunk
+		if remaining < int64(len(chunk)) {
+			chunk = chunk[:remaining]
+		}
+		n, err := w.Write(chunk)
+		if err != nil {
+			return err
+		}
+		remaining -= int64(n)
+	}
+	return nil
+}
+
+// handleSpeedTestUpload reads and discards the request body.
+func (s *Server) handleSpeedTestUpload(c *gin.Context) {
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, speedTestMaxBytes)
+	n, err := io.Copy(io.Discard, body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Upload interrupted", "bytes": n})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"bytes": n})
+}
@@ -0,0 +1,146 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// Defaults for RS_ADAPTIVE_INTERVAL, RS_ADAPTIVE_COOLDOWN and RS_ADAPTIVE_LOSS
+const (
+	defaultAdaptiveInterval = 5 * time.Second
+	defaultAdaptiveCooldown = 5 * time.Minute
+	defaultAdaptiveLoss     = 10.0
+	// Pings per adaptive sample; fewer than a regular cycle so a sample
+	// fits in the interval
+	adaptivePings = 3
+)
+
+type adaptiveState struct {
+	since   time.Time
+	lastBad time.Time
+}
+
+// adaptiveSettings reads the adaptive probing settings; an interval of 0
+// turns adaptive probing off
+func adaptiveSettings() (interval, cooldown time.Duration, lossPct float64) {
+	interval, cooldown, lossPct = defaultAdaptiveInterval, defaultAdaptiveCooldown, defaultAdaptiveLoss
+	if v := os.Getenv("RS_ADAPTIVE_INTERVAL"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			interval = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("RS_ADAPTIVE_COOLDOWN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			cooldown = time.Duration(n) * time.Second
+		}
+	}
+	if v := os.Getenv("RS_ADAPTIVE_LOSS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f > 0 {
+			lossPct = f
+		}
+	}
+	return interval, cooldown, lossPct
+}
+
+// adaptiveDegraded reports whether a ping cycle calls for closer watching:
+// it failed, lost packets past RS_ADAPTIVE_LOSS or would count toward an
+// incident
+func adaptiveDegraded(rec *storage.MonitorRecord, lossPct float64) bool {
+	return rec.Status != storage.ProbeStatusOK || rec.PacketLoss >= lossPct || incidentCategory(rec) != ""
+}
+
+// noteAdaptive feeds a ping cycle outcome to adaptive probing: a degraded
+// regular cycle switches the target to fast probing, and any degraded
+// sample keeps it there
+func (s *Service) noteAdaptive(t storage.Target, rec *storage.MonitorRecord) {
+	interval, _, lossPct := adaptiveSettings()
+	if interval <= 0 || rec.Maintenance || !adaptiveDegraded(rec, lossPct) {
+		return
+	}
+
+	s.adaptiveMu.Lock()
+	st, running := s.adaptive[t.Address]
+	if running {
+		st.lastBad = rec.CreatedAt
+	} else if !rec.Adaptive {
+		st = &adaptiveState{since: rec.CreatedAt, lastBad: rec.CreatedAt}
+		s.adaptive[t.Address] = st
+	}
+	s.adaptiveMu.Unlock()
+	if running || rec.Adaptive {
+		return
+	}
+
+	logging.Warn("monitor", "[Adaptive] %s degraded (loss %.1f%%, %s), probing every %s", t.Name, rec.PacketLoss, rec.Status, interval)
+	go s.runAdaptive(t, st, interval)
+}
+
+// runAdaptive traces the target right away, then pings it every interval
+// until it has been healthy for the cooldown
+func (s *Service) runAdaptive(t storage.Target, st *adaptiveState, interval time.Duration) {
+	s.pingTrace(t, true)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		_, cooldown, _ := adaptiveSettings()
+		s.adaptiveMu.Lock()
+		healthyFor := time.Since(st.lastBad)
+		done := healthyFor >= cooldown || !s.isProbed(t.Address)
+		if done {
+			delete(s.adaptive, t.Address)
+		}
+		s.adaptiveMu.Unlock()
+		if done {
+			logging.Info("monitor", "[Adaptive] %s back to the regular interval after %s",
+				t.Name, time.Since(st.since).Round(time.Second))
+			return
+		}
+		s.adaptivePing(t)
+	}
+}
+
+// adaptivePing takes one quick ping sample between regular cycles
+func (s *Service) adaptivePing(t storage.Target) {
+	res, err := prober.NewICMPPinger(t.Address, adaptivePings).Run()
+	if err != nil {
+		s.saveOutage(t, classifyPingError(err), err, true)
+		return
+	}
+	rec := &storage.MonitorRecord{
+		Target:     t.Address,
+		CreatedAt:  time.Now(),
+		LatencyMs:  float64(res.AvgRtt.Microseconds()) / 1000.0,
+		PacketLoss: res.LossRate,
+		Status:     storage.ProbeStatusOK,
+	}
+	if res.LossRate >= 100 {
+		rec.Status = storage.ProbeStatusTimeout
+		rec.Error = fmt.Sprintf("no reply to %d pings", res.PacketsSent)
+	}
+	s.savePingCycle(t, rec, true)
+}
+
+// isProbed reports whether the target is still enabled
+func (s *Service) isProbed(address string) bool {
+	s.targetsMu.RLock()
+	defer s.targetsMu.RUnlock()
+	for _, t := range s.targets {
+		if t.Address == address {
+			return t.Enabled
+		}
+	}
+	return false
+}
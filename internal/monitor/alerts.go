@@ -0,0 +1,242 @@
+package monitor
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+const (
+	// Baselines are averages over days of history; recomputing them every
+	// cycle would scan that history for each rule and target
+	alertBaselineTTL = 10 * time.Minute
+	// Fewer samples than this is no baseline at all (a target added today)
+	alertBaselineMinSamples = 10
+)
+
+type alertBaseline struct {
+	value float64
+	ok    bool
+	at    time.Time
+}
+
+// evaluateAlerts steps every enabled rule in scope for the target with a
+// just-saved record. Maintenance samples leave alert state as it was.
+func (s *Service) evaluateAlerts(t storage.Target, rec *storage.MonitorRecord) {
+	if rec.Maintenance {
+		return
+	}
+	rules, err := s.db.GetAlertRules(true)
+	if err != nil {
+		logging.Error("monitor", "Failed to load alert rules: %v", err)
+		return
+	}
+
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	for _, rule := range rules {
+		if !alertRuleApplies(rule, t) {
+			continue
+		}
+		value, ok := alertValue(rule.Metric, rec)
+		if !ok {
+			continue
+		}
+		threshold, resolveAt, ok := s.alertThresholds(rule, t.Address)
+		if !ok {
+			continue
+		}
+
+		st, err := s.db.GetAlertState(rule.ID, t.Address)
+		if err != nil {
+			logging.Error("monitor", "Failed to load alert state for rule %d on %s: %v", rule.ID, t.Name, err)
+			continue
+		}
+		if st == nil {
+			st = &storage.AlertState{RuleID: rule.ID, Target: t.Address, State: storage.AlertStateOK}
+		}
+		transition := stepAlert(rule, st, value, threshold, resolveAt, rec.CreatedAt)
+		event := &storage.AlertEvent{
+			CreatedAt: rec.CreatedAt,
+			RuleID:    rule.ID,
+			RuleName:  rule.Name,
+			Target:    t.Address,
+			State:     transition,
+			Value:     value,
+			Threshold: threshold,
+		}
+		switch transition {
+		case storage.AlertStateFiring:
+			event.CausedBy = s.failingParent(t)
+			event.Silenced = event.CausedBy != "" || s.silenced(t, rec.CreatedAt)
+			st.Suppressed = event.Silenced
+		case storage.AlertStateResolved:
+			event.Silenced = st.Suppressed
+			st.Suppressed = false
+		}
+		if err := s.db.SaveAlertState(st); err != nil {
+			logging.Error("monitor", "Failed to save alert state for rule %d on %s: %v", rule.ID, t.Name, err)
+			continue
+		}
+		if transition == "" {
+			continue
+		}
+
+		if transition == storage.AlertStateFiring {
+			event.Message = fmt.Sprintf("%s on %s: %s is %.2f (%s %.2f)", rule.Name, t.Name, rule.Metric, value, rule.Operator, threshold)
+			logging.Warn("monitor", "[Alert] %s", event.Message)
+		} else {
+			event.Threshold = resolveAt
+			event.Message = fmt.Sprintf("%s on %s resolved: %s is %.2f", rule.Name, t.Name, rule.Metric, value)
+			logging.Info("monitor", "[Alert] %s", event.Message)
+		}
+		if err := s.db.SaveAlertEvent(event); err != nil {
+			logging.Error("monitor", "Failed to save alert event for rule %d on %s: %v", rule.ID, t.Name, err)
+		}
+		if event.CausedBy != "" {
+			logging.Info("monitor", "[Alert] %s depends on failing %s, not notifying", t.Name, event.CausedBy)
+			continue
+		}
+		if event.Silenced {
+			logging.Info("monitor", "[Alert] %s is silenced, not notifying", t.Name)
+			continue
+		}
+		if transition == storage.AlertStateFiring && t.Parent != "" {
+			go s.notifyUnlessParentFails(t, rule, *event)
+			continue
+		}
+		s.notifyAlert(t, rule, event)
+	}
+}
+
+// alertRuleApplies checks a rule's target and group scope
+func alertRuleApplies(rule storage.AlertRule, t storage.Target) bool {
+	return (rule.Target == "" || rule.Target == t.Address) &&
+		(rule.Group == "" || rule.Group == t.Group)
+}
+
+// alertValue picks the rule's metric out of a record. Ping cycles carry
+// latency and loss, speed tests the speeds; a failed cycle has no latency,
+// and a zero speed means that direction wasn't tested.
+func alertValue(metric string, rec *storage.MonitorRecord) (float64, bool) {
+	pingCycle := rec.Status != ""
+	switch metric {
+	case storage.AlertMetricLatency:
+		return rec.LatencyMs, rec.Status == storage.ProbeStatusOK
+	case storage.AlertMetricLoss:
+		return rec.PacketLoss, pingCycle
+	case storage.AlertMetricSpeedDown:
+		return rec.SpeedDown, !pingCycle && rec.SpeedDown > 0
+	case storage.AlertMetricSpeedUp:
+		return rec.SpeedUp, !pingCycle && rec.SpeedUp > 0
+	}
+	return 0, false
+}
+
+// alertThresholds returns the fire and resolve thresholds for a target,
+// scaled by the baseline when the rule has one. ok is false while there
+// isn't enough history for a baseline.
+func (s *Service) alertThresholds(rule storage.AlertRule, target string) (threshold, resolveAt float64, ok bool) {
+	threshold, resolveAt = rule.Threshold, rule.Threshold
+	if rule.ResolveThreshold != nil {
+		resolveAt = *rule.ResolveThreshold
+	}
+	if rule.BaselineDays <= 0 {
+		return threshold, resolveAt, true
+	}
+	base, ok := s.metricBaseline(target, rule.Metric, rule.BaselineDays)
+	if !ok {
+		return 0, 0, false
+	}
+	return threshold * base, resolveAt * base, true
+}
+
+// metricBaseline is the cached average of a metric over the last days
+func (s *Service) metricBaseline(target, metric string, days int) (float64, bool) {
+	key := fmt.Sprintf("%s|%s|%d", target, metric, days)
+	s.baselineMu.Lock()
+	defer s.baselineMu.Unlock()
+	if b, ok := s.baselines[key]; ok && time.Since(b.at) < alertBaselineTTL {
+		return b.value, b.ok
+	}
+
+	avg, count, err := s.db.GetMetricBaseline(target, metric, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		logging.Error("monitor", "Failed to compute %d-day %s baseline for %s: %v", days, metric, target, err)
+		return 0, false
+	}
+	// A multiple of zero (e.g. a loss-free week) would fire on any loss
+	b := alertBaseline{value: avg, ok: count >= alertBaselineMinSamples && avg > 0, at: time.Now()}
+	if s.baselines == nil {
+		s.baselines = make(map[string]alertBaseline)
+	}
+	s.baselines[key] = b
+	return b.value, b.ok
+}
+
+// stepAlert advances one rule/target state by a new value and returns the
+// transition it caused: AlertStateFiring, AlertStateResolved or "".
+func stepAlert(rule storage.AlertRule, st *storage.AlertState, value, threshold, resolveAt float64, now time.Time) string {
+	st.LastValue = value
+	st.Threshold = threshold
+
+	if alertCompare(rule.Operator, value, threshold) {
+		st.Breaches++
+		st.Recoveries = 0
+		if st.BreachSince == nil {
+			since := now
+			st.BreachSince = &since
+		}
+		if st.State == storage.AlertStateFiring {
+			return ""
+		}
+		if st.Breaches >= max(1, rule.Cycles) && now.Sub(*st.BreachSince) >= time.Duration(rule.ForSec)*time.Second {
+			fired := now
+			st.State = storage.AlertStateFiring
+			st.FiredAt = &fired
+			st.ResolvedAt = nil
+			return storage.AlertStateFiring
+		}
+		st.State = storage.AlertStatePending
+		return ""
+	}
+
+	st.Breaches = 0
+	st.BreachSince = nil
+	switch st.State {
+	case storage.AlertStatePending:
+		st.State = storage.AlertStateOK
+	case storage.AlertStateFiring:
+		// Between the thresholds is the hysteresis band: no longer bad
+		// enough to fire, not yet good enough to resolve
+		if alertCompare(rule.Operator, value, resolveAt) {
+			st.Recoveries = 0
+			return ""
+		}
+		st.Recoveries++
+		if st.Recoveries >= max(1, rule.RecoverCycles) {
+			resolved := now
+			st.State = storage.AlertStateOK
+			st.Recoveries = 0
+			st.ResolvedAt = &resolved
+			return storage.AlertStateResolved
+		}
+	}
+	return ""
+}
+
+func alertCompare(op string, value, threshold float64) bool {
+	switch op {
+	case ">":
+		return value > threshold
+	case ">=":
+		return value >= threshold
+	case "<":
+		return value < threshold
+	case "<=":
+		return value <= threshold
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// bufferbloatProbeConfig is the optional "bufferbloat" object in any speed
+// probe's config
+type bufferbloatProbeConfig struct {
+	Enabled bool   `json:"enabled"`
+	Method  string `json:"method"` // "icmp" (default) or "tcp"
+	// TCP method: a port that isn't part of the test itself (an iperf3
+	// server, for one, rejects extra connections while busy)
+	Port int `json:"port"`
+}
+
+func parseBufferbloatConfig(raw string) (*bufferbloatProbeConfig, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var wrapper struct {
+		Bufferbloat *bufferbloatProbeConfig `json:"bufferbloat"`
+	}
+	if err := json.Unmarshal([]byte(raw), &wrapper); err != nil {
+		return nil, err
+	}
+	cfg := wrapper.Bufferbloat
+	if cfg == nil || !cfg.Enabled {
+		return nil, nil
+	}
+	switch cfg.Method {
+	case "", prober.BloatMethodICMP:
+	case prober.BloatMethodTCP:
+		if cfg.Port <= 0 || cfg.Port > 65535 {
+			return nil, fmt.Errorf("bufferbloat tcp method needs a port")
+		}
+	default:
+		return nil, fmt.Errorf("unknown bufferbloat method %q", cfg.Method)
+	}
+	return cfg, nil
+}
+
+// startBufferbloatSampler starts latency sampling for a speed test and
+// waits out the idle baseline. Returns nil when disabled or unavailable;
+// the speed test runs either way.
+func startBufferbloatSampler(t storage.Target) *prober.LatencySampler {
+	cfg, err := parseBufferbloatConfig(t.ProbeConfig)
+	if err != nil {
+		logging.Warn("speedtest", "[Bloat] Ignoring bufferbloat config for %s: %v", t.Name, err)
+		return nil
+	}
+	if cfg == nil {
+		return nil
+	}
+	sampler := prober.NewLatencySampler(t.Address, cfg.Method, cfg.Port)
+	if err := sampler.Start(); err != nil {
+		logging.Warn("speedtest", "[Bloat] Latency sampling unavailable for %s: %v", t.Name, err)
+		return nil
+	}
+	time.Sleep(prober.BufferbloatIdleWindow)
+	return sampler
+}
+
+// finishBufferbloat stops the sampler and attaches the analysis to res
+func finishBufferbloat(t storage.Target, sampler *prober.LatencySampler, res *prober.SpeedResult) {
+	samples := sampler.Stop()
+	down, up := res.Phases()
+	res.Bufferbloat = prober.AnalyzeBufferbloat(sampler.Method, samples, down, up)
+	if b := res.Bufferbloat; b != nil {
+		logging.Info("speedtest", "[Bloat] %s: grade %s, idle %.1fms, loaded down %.1fms (+%.1f), up %.1fms (+%.1f)",
+			t.Name, b.Grade, durationMs(b.Idle), durationMs(b.LoadedDown), durationMs(b.DownIncrease),
+			durationMs(b.LoadedUp), durationMs(b.UpIncrease))
+	} else {
+		logging.Warn("speedtest", "[Bloat] Not enough latency samples for %s to grade bufferbloat", t.Name)
+	}
+}
+
+// bufferbloatDetails is the stored form of prober.BufferbloatResult
+type bufferbloatDetails struct {
+	Method         string  `json:"method"`
+	IdleMs         float64 `json:"idle_ms"`
+	LoadedDownMs   float64 `json:"loaded_down_ms,omitempty"`
+	LoadedUpMs     float64 `json:"loaded_up_ms,omitempty"`
+	DownIncreaseMs float64 `json:"down_increase_ms"`
+	UpIncreaseMs   float64 `json:"up_increase_ms"`
+	IdleSamples    int     `json:"idle_samples"`
+	DownSamples    int     `json:"down_samples"`
+	UpSamples      int     `json:"up_samples"`
+	LoadedLoss     float64 `json:"loaded_loss"`
+	Grade          string  `json:"grade"`
+}
+
+func newBufferbloatDetails(b *prober.BufferbloatResult) *bufferbloatDetails {
+	if b == nil {
+		return nil
+	}
+	return &bufferbloatDetails{
+		Method:         b.Method,
+		IdleMs:         durationMs(b.Idle),
+		LoadedDownMs:   durationMs(b.LoadedDown),
+		LoadedUpMs:     durationMs(b.LoadedUp),
+		DownIncreaseMs: durationMs(b.DownIncrease),
+		UpIncreaseMs:   durationMs(b.UpIncrease),
+		IdleSamples:    b.IdleSamples,
+		DownSamples:    b.DownSamples,
+		UpSamples:      b.UpSamples,
+		LoadedLoss:     b.LoadedLoss,
+		Grade:          b.Grade,
+	}
+}
@@ -0,0 +1,557 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// Probe names stored in MetricRecord.Probe
+const (
+	metricProbeSSHSession = "ssh_session"
+	metricProbePlugin     = "plugin"
+	metricProbeGRPC       = "grpc"
+	metricProbeNTP        = "ntp"
+	metricProbeTWAMP      = "twamp"
+	metricProbeUDPEcho    = "udp_echo"
+	// The RouteLens host's own clock against RS_NTP_REFERENCE; the series
+	// target is the reference server
+	MetricProbeHostClock = "host_clock"
+)
+
+// pluginProbeConfig runs a Nagios-compatible check plugin. Args are passed
+// as-is without a shell; $HOSTADDRESS$ is replaced with the target address.
+type pluginProbeConfig struct {
+	Command    string   `json:"command"`
+	Args       []string `json:"args"`
+	TimeoutSec int      `json:"timeout"`
+}
+
+// grpcProbeConfig targets a grpc.health.v1 Health service on the target address
+type grpcProbeConfig struct {
+	Port       int    `json:"port"`
+	Service    string `json:"service"` // Empty = overall server health
+	TLS        bool   `json:"tls"`
+	Insecure   bool   `json:"insecure"` // Skip certificate verification
+	ServerName string `json:"server_name"`
+	CAFile     string `json:"ca_file"`
+	TimeoutSec int    `json:"timeout"`
+}
+
+func parseGRPCConfig(raw string) (grpcProbeConfig, error) {
+	var cfg grpcProbeConfig
+	if raw == "" {
+		return cfg, fmt.Errorf("grpc config is required")
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return cfg, fmt.Errorf("port is required")
+	}
+	if cfg.TimeoutSec < 0 || cfg.TimeoutSec > 30 {
+		return cfg, fmt.Errorf("timeout must be between 0 and 30 seconds")
+	}
+	return cfg, nil
+}
+
+type ntpProbeConfig struct {
+	Port       int `json:"port"` // Default 123
+	TimeoutSec int `json:"timeout"`
+}
+
+// twampProbeConfig is a TWAMP-Light session against a reflector on the target
+type twampProbeConfig struct {
+	Port       int  `json:"port"`        // Default 862
+	Count      int  `json:"count"`       // Packets per cycle, default 100
+	IntervalMs int  `json:"interval_ms"` // Default 20
+	PacketSize int  `json:"packet_size"` // Bytes incl. padding, default/minimum 41
+	Synced     bool `json:"clock_synced"`
+}
+
+func parseTWAMPConfig(raw string) (twampProbeConfig, error) {
+	var cfg twampProbeConfig
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			return cfg, err
+		}
+	}
+	if cfg.Port < 0 || cfg.Port > 65535 {
+		return cfg, fmt.Errorf("invalid port %d", cfg.Port)
+	}
+	// A session has to finish well inside the 30s ping cycle
+	if cfg.Count < 0 || cfg.Count > 1000 {
+		return cfg, fmt.Errorf("count must be between 1 and 1000")
+	}
+	if cfg.IntervalMs < 0 || time.Duration(cfg.Count*cfg.IntervalMs)*time.Millisecond > 20*time.Second {
+		return cfg, fmt.Errorf("count x interval must not exceed 20 seconds")
+	}
+	if cfg.PacketSize < 0 || cfg.PacketSize > 1472 {
+		return cfg, fmt.Errorf("packet size must be at most 1472 bytes")
+	}
+	return cfg, nil
+}
+
+// udpEchoProbeConfig streams to a RouteLens UDP echo responder (RS_UDP_ECHO_PORT)
+type udpEchoProbeConfig struct {
+	Port        int `json:"port"`
+	Rate        int `json:"pps"`         // Default 50
+	PacketSize  int `json:"packet_size"` // Default 200
+	DurationSec int `json:"duration"`    // Default 10
+}
+
+func parseUDPEchoConfig(raw string) (udpEchoProbeConfig, error) {
+	var cfg udpEchoProbeConfig
+	if raw == "" {
+		return cfg, fmt.Errorf("udp echo config is required")
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.Port <= 0 || cfg.Port > 65535 {
+		return cfg, fmt.Errorf("port is required")
+	}
+	if cfg.Rate < 0 || cfg.Rate > 1000 {
+		return cfg, fmt.Errorf("pps must be between 1 and 1000")
+	}
+	if cfg.PacketSize != 0 && (cfg.PacketSize < prober.MinUDPEchoPacketSize || cfg.PacketSize > 1472) {
+		return cfg, fmt.Errorf("packet size must be between %d and 1472 bytes", prober.MinUDPEchoPacketSize)
+	}
+	// A stream has to finish well inside the 30s ping cycle
+	if cfg.DurationSec < 0 || cfg.DurationSec > 20 {
+		return cfg, fmt.Errorf("duration must be between 1 and 20 seconds")
+	}
+	return cfg, nil
+}
+
+// parsePluginConfig validates the config and resolves the command against RS_PLUGIN_PATHS
+func parsePluginConfig(raw string) (pluginProbeConfig, error) {
+	var cfg pluginProbeConfig
+	if raw == "" {
+		return cfg, fmt.Errorf("plugin config is required")
+	}
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return cfg, err
+	}
+	if cfg.TimeoutSec < 0 || time.Duration(cfg.TimeoutSec)*time.Second > prober.MaxPluginTimeout {
+		return cfg, fmt.Errorf("timeout must be between 0 and %d seconds", int(prober.MaxPluginTimeout.Seconds()))
+	}
+	real, err := prober.ResolvePlugin(cfg.Command, prober.ParsePluginAllowList(os.Getenv("RS_PLUGIN_PATHS")))
+	if err != nil {
+		return cfg, err
+	}
+	cfg.Command = real
+	return cfg, nil
+}
+
+// runCheckForTarget runs a check probe (storage.IsCheckProbe) and stores its
+// samples as MetricRecords. Check probes run every ping cycle.
+func (s *Service) runCheckForTarget(t storage.Target) {
+	var metrics []storage.MetricRecord
+	var err error
+	var errMsg string
+
+	switch t.ProbeType {
+	case storage.ProbeModeSSHSession:
+		metrics, err = s.runSSHSessionCheck(t)
+		if err != nil {
+			errMsg = s.sshErrorMessage(t, err)
+		}
+	case storage.ProbeModePlugin:
+		metrics, err = s.runPluginCheck(t)
+		if err != nil {
+			errMsg = err.Error()
+		}
+	case storage.ProbeModeGRPC:
+		metrics, err = s.runGRPCCheck(t)
+		if err != nil {
+			errMsg = "gRPC: " + err.Error()
+		}
+	case storage.ProbeModeNTP:
+		metrics, err = s.runNTPCheck(t)
+		if err != nil {
+			errMsg = "NTP: " + err.Error()
+		}
+	case storage.ProbeModeTWAMP:
+		metrics, err = s.runTWAMPCheck(t)
+		if err != nil {
+			errMsg = "TWAMP: " + err.Error()
+		}
+	case storage.ProbeModeUDPEcho:
+		metrics, err = s.runUDPEchoCheck(t)
+		if err != nil {
+			errMsg = "UDP echo: " + err.Error()
+		}
+	default:
+		return
+	}
+
+	if len(metrics) > 0 {
+		if saveErr := s.db.SaveMetrics(metrics); saveErr != nil {
+			logging.Error("probe", "Failed to save %s metrics for %s: %v", t.ProbeType, t.Name, saveErr)
+		}
+	}
+	if err != nil {
+		logging.Error("probe", "[%s] Check failed for %s: %v", t.ProbeType, t.Name, err)
+		s.db.UpdateTargetError(t.Address, errMsg)
+		return
+	}
+	s.db.ClearTargetError(t.Address)
+}
+
+func (s *Service) runSSHSessionCheck(t storage.Target) ([]storage.MetricRecord, error) {
+	sshCfg, err := parseSSHConfig(t.ProbeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Config error: %v", err)
+	}
+	var cfg sshProbeConfig
+	_ = json.Unmarshal([]byte(t.ProbeConfig), &cfg)
+
+	sshCfg.Host = t.Address
+	sshCfg.HostKey = t.SSHHostKey
+	if fresh, dbErr := s.db.GetTargetByID(t.ID); dbErr == nil {
+		sshCfg.HostKey = fresh.SSHHostKey
+	}
+
+	runner := prober.NewSSHSpeedTester(sshCfg)
+	timing, runErr := runner.MeasureSession(cfg.Command)
+	if learned := runner.LearnedHostKey(); learned != "" {
+		logging.Info("probe", "[SSH] Pinned host key for %s on first use", t.Address)
+		s.db.PinTargetHostKey(t.Address, learned)
+	}
+	if learned := runner.LearnedJumpHostKeys(); len(learned) > 0 {
+		s.pinJumpHostKeys(t, learned)
+	}
+	if timing == nil {
+		return nil, runErr
+	}
+
+	now := time.Now()
+	metric := func(name string, d time.Duration) storage.MetricRecord {
+		return storage.MetricRecord{
+			CreatedAt: now,
+			Target:    t.Address,
+			Probe:     metricProbeSSHSession,
+			Name:      name,
+			Value:     durationMs(d),
+			Unit:      "ms",
+		}
+	}
+	// A failed login keeps the phases it got through, auth up to the failure
+	var metrics []storage.MetricRecord
+	if timing.TCPConnect > 0 {
+		metrics = append(metrics, metric("tcp_connect_ms", timing.TCPConnect))
+	}
+	if timing.KeyExchange > 0 {
+		metrics = append(metrics, metric("kex_ms", timing.KeyExchange))
+	}
+	if timing.Auth > 0 {
+		metrics = append(metrics, metric("auth_ms", timing.Auth))
+	}
+	if timing.ChannelOpen > 0 {
+		metrics = append(metrics, metric("channel_open_ms", timing.ChannelOpen))
+	}
+	if timing.Command > 0 {
+		metrics = append(metrics, metric("command_ms", timing.Command))
+	}
+	if timing.Keepalive > 0 {
+		metrics = append(metrics, metric("keepalive_ms", timing.Keepalive))
+	}
+	for _, j := range timing.JumpHosts {
+		metrics = append(metrics, metric("jump_connect_ms:"+j.Host, j.ConnectTime))
+	}
+
+	if runErr == nil {
+		logging.Info("probe", "[SSH] Session %s: tcp=%.1fms kex=%.1fms auth=%.1fms channel=%.1fms cmd=%.1fms",
+			t.Name, durationMs(timing.TCPConnect), durationMs(timing.KeyExchange), durationMs(timing.Auth),
+			durationMs(timing.ChannelOpen), durationMs(timing.Command))
+	}
+	return metrics, runErr
+}
+
+// runPluginCheck runs the plugin and records its perfdata. Any status but OK
+// is reported as an error so it shows up like other probe failures.
+func (s *Service) runPluginCheck(t storage.Target) ([]storage.MetricRecord, error) {
+	cfg, err := parsePluginConfig(t.ProbeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Config error: %v", err)
+	}
+	args := make([]string, len(cfg.Args))
+	for i, a := range cfg.Args {
+		args[i] = strings.ReplaceAll(a, prober.PluginHostMacro, t.Address)
+	}
+
+	runner := prober.NewPluginRunner(cfg.Command, args, time.Duration(cfg.TimeoutSec)*time.Second)
+	res, err := runner.Run()
+	if err != nil {
+		s.db.UpdateTargetCheckResult(t.Address, prober.PluginUnknown.String(), err.Error())
+		return nil, err
+	}
+	s.db.UpdateTargetCheckResult(t.Address, res.Status.String(), res.Message)
+
+	now := time.Now()
+	metrics := []storage.MetricRecord{{
+		CreatedAt: now,
+		Target:    t.Address,
+		Probe:     metricProbePlugin,
+		Name:      "execution_ms",
+		Value:     durationMs(res.Duration),
+		Unit:      "ms",
+	}}
+	for _, p := range res.PerfData {
+		metrics = append(metrics, storage.MetricRecord{
+			CreatedAt: now,
+			Target:    t.Address,
+			Probe:     metricProbePlugin,
+			Name:      p.Label,
+			Value:     p.Value,
+			Unit:      p.Unit,
+		})
+	}
+
+	logging.Info("probe", "[Plugin] %s: %s - %s", t.Name, res.Status, firstLine(res.Message))
+	if res.Status != prober.PluginOK {
+		return metrics, fmt.Errorf("%s: %s", res.Status, firstLine(res.Message))
+	}
+	return metrics, nil
+}
+
+// runGRPCCheck calls Health/Check and records connect and RPC latency.
+// Anything but SERVING is reported as an error.
+func (s *Service) runGRPCCheck(t storage.Target) ([]storage.MetricRecord, error) {
+	cfg, err := parseGRPCConfig(t.ProbeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Config error: %v", err)
+	}
+
+	runner := prober.NewGRPCHealthProber(prober.GRPCHealthConfig{
+		Address:            net.JoinHostPort(t.Address, strconv.Itoa(cfg.Port)),
+		Service:            cfg.Service,
+		TLS:                cfg.TLS,
+		InsecureSkipVerify: cfg.Insecure,
+		ServerName:         cfg.ServerName,
+		CAFile:             cfg.CAFile,
+		Timeout:            time.Duration(cfg.TimeoutSec) * time.Second,
+	})
+	res, err := runner.Check()
+	if err != nil {
+		s.db.UpdateTargetCheckResult(t.Address, prober.GRPCHealthUnknown, err.Error())
+		return nil, err
+	}
+	s.db.UpdateTargetCheckResult(t.Address, res.Status, "")
+
+	serving := 0.0
+	if res.Status == prober.GRPCHealthServing {
+		serving = 1
+	}
+	now := time.Now()
+	metric := func(name string, v float64, unit string) storage.MetricRecord {
+		return storage.MetricRecord{CreatedAt: now, Target: t.Address, Probe: metricProbeGRPC, Name: name, Value: v, Unit: unit}
+	}
+	metrics := []storage.MetricRecord{
+		metric("connect_ms", durationMs(res.ConnectTime), "ms"),
+		metric("rpc_ms", durationMs(res.RPCLatency), "ms"),
+		metric("serving", serving, ""),
+	}
+
+	logging.Info("probe", "[gRPC] %s: %s (rpc %.1fms)", t.Name, res.Status, durationMs(res.RPCLatency))
+	if res.Status != prober.GRPCHealthServing {
+		if cfg.Service != "" {
+			return metrics, fmt.Errorf("service %q is %s", cfg.Service, res.Status)
+		}
+		return metrics, fmt.Errorf("server is %s", res.Status)
+	}
+	return metrics, nil
+}
+
+// runNTPCheck measures the target's clock against ours
+func (s *Service) runNTPCheck(t storage.Target) ([]storage.MetricRecord, error) {
+	var cfg ntpProbeConfig
+	if t.ProbeConfig != "" {
+		if err := json.Unmarshal([]byte(t.ProbeConfig), &cfg); err != nil {
+			return nil, fmt.Errorf("Config error: %v", err)
+		}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = prober.DefaultNTPPort
+	}
+
+	runner := prober.NewNTPProber(net.JoinHostPort(t.Address, strconv.Itoa(cfg.Port)))
+	if cfg.TimeoutSec > 0 && cfg.TimeoutSec <= 30 {
+		runner.Timeout = time.Duration(cfg.TimeoutSec) * time.Second
+	}
+	res, err := runner.Query()
+	if err != nil {
+		return nil, err
+	}
+
+	status := "SYNCED"
+	if !res.Synchronized() {
+		status = "UNSYNCED"
+	}
+	s.db.UpdateTargetCheckResult(t.Address, status,
+		fmt.Sprintf("stratum %d, refid %s, leap %s", res.Stratum, res.ReferenceID, res.LeapString()))
+
+	metrics := ntpMetrics(t.Address, metricProbeNTP, res)
+	logging.Info("probe", "[NTP] %s: offset %.3fms, delay %.1fms, stratum %d, refid %s",
+		t.Name, durationMs(res.Offset), durationMs(res.Delay), res.Stratum, res.ReferenceID)
+	if !res.Synchronized() {
+		return metrics, fmt.Errorf("server clock is not synchronized (stratum %d, leap %s)", res.Stratum, res.LeapString())
+	}
+	return metrics, nil
+}
+
+func (s *Service) runTWAMPCheck(t storage.Target) ([]storage.MetricRecord, error) {
+	cfg, err := parseTWAMPConfig(t.ProbeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Config error: %v", err)
+	}
+	if cfg.Port == 0 {
+		cfg.Port = prober.DefaultTWAMPPort
+	}
+
+	res, err := prober.NewTWAMPSender(prober.TWAMPConfig{
+		Address:     net.JoinHostPort(t.Address, strconv.Itoa(cfg.Port)),
+		Count:       cfg.Count,
+		Interval:    time.Duration(cfg.IntervalMs) * time.Millisecond,
+		PacketSize:  cfg.PacketSize,
+		ClockSynced: cfg.Synced,
+	}).Run()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	metric := func(name string, v float64, unit string) storage.MetricRecord {
+		return storage.MetricRecord{CreatedAt: now, Target: t.Address, Probe: metricProbeTWAMP, Name: name, Value: v, Unit: unit}
+	}
+	metrics := []storage.MetricRecord{metric("loss", res.Loss, "%")}
+	if res.Received == 0 {
+		return metrics, fmt.Errorf("no replies from reflector (%d packets sent)", res.Sent)
+	}
+	metrics = append(metrics,
+		metric("rtt_ms", durationMs(res.RTTAvg), "ms"),
+		metric("rtt_min_ms", durationMs(res.RTTMin), "ms"),
+		metric("rtt_max_ms", durationMs(res.RTTMax), "ms"),
+		metric("fwd_jitter_ms", durationMs(res.ForwardJitter), "ms"),
+		metric("rev_jitter_ms", durationMs(res.ReverseJitter), "ms"),
+	)
+	if res.Directional {
+		metrics = append(metrics, metric("fwd_loss", res.ForwardLoss, "%"), metric("rev_loss", res.ReverseLoss, "%"))
+	}
+	if res.Synced {
+		metrics = append(metrics, metric("fwd_delay_ms", durationMs(res.ForwardDelay), "ms"), metric("rev_delay_ms", durationMs(res.ReverseDelay), "ms"))
+	}
+	// One-way delays need both clocks synchronized; the reflector says so
+	// with the S bit of every reply
+	if cfg.Synced {
+		if res.ReflectorSynced {
+			s.db.UpdateTargetCheckResult(t.Address, "SYNCED", "")
+		} else {
+			logging.Warn("probe", "[TWAMP] %s: reflector clock is not synchronized, one-way delays skipped", t.Name)
+			s.db.UpdateTargetCheckResult(t.Address, "UNSYNCED",
+				"The reflector does not claim a synchronized clock (S bit unset), so one-way delays are not recorded")
+		}
+	} else if t.LastStatus != "" {
+		s.db.UpdateTargetCheckResult(t.Address, "", "")
+	}
+
+	logging.Info("probe", "[TWAMP] %s: rtt %.2fms, loss %.1f%% (fwd %.1f%%, rev %.1f%%), jitter fwd %.2fms rev %.2fms",
+		t.Name, durationMs(res.RTTAvg), res.Loss, res.ForwardLoss, res.ReverseLoss,
+		durationMs(res.ForwardJitter), durationMs(res.ReverseJitter))
+	return metrics, nil
+}
+
+func (s *Service) runUDPEchoCheck(t storage.Target) ([]storage.MetricRecord, error) {
+	cfg, err := parseUDPEchoConfig(t.ProbeConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Config error: %v", err)
+	}
+
+	res, err := prober.NewUDPEchoSender(prober.UDPEchoConfig{
+		Address:    net.JoinHostPort(t.Address, strconv.Itoa(cfg.Port)),
+		Rate:       cfg.Rate,
+		PacketSize: cfg.PacketSize,
+		Duration:   time.Duration(cfg.DurationSec) * time.Second,
+	}).Run()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	metric := func(name string, v float64, unit string) storage.MetricRecord {
+		return storage.MetricRecord{CreatedAt: now, Target: t.Address, Probe: metricProbeUDPEcho, Name: name, Value: v, Unit: unit}
+	}
+	metrics := []storage.MetricRecord{metric("fwd_loss", res.ForwardLoss, "%")}
+	if res.Received == 0 && res.Reflected == 0 {
+		return metrics, fmt.Errorf("no replies from responder (%d packets sent)", res.Sent)
+	}
+	metrics = append(metrics,
+		metric("rev_loss", res.ReverseLoss, "%"),
+		metric("fwd_reorder", res.ForwardReorder, "%"),
+	)
+	if res.Received > 0 {
+		metrics = append(metrics,
+			metric("rev_reorder", res.ReverseReorder, "%"),
+			metric("rtt_ms", durationMs(res.RTTAvg), "ms"),
+			metric("fwd_jitter_ms", durationMs(res.ForwardJitter), "ms"),
+			metric("rev_jitter_ms", durationMs(res.ReverseJitter), "ms"),
+		)
+	}
+
+	logging.Info("probe", "[UDP] %s: loss fwd %.1f%% rev %.1f%%, reorder fwd %.1f%% rev %.1f%%, jitter fwd %.2fms rev %.2fms",
+		t.Name, res.ForwardLoss, res.ReverseLoss, res.ForwardReorder, res.ReverseReorder,
+		durationMs(res.ForwardJitter), durationMs(res.ReverseJitter))
+	return metrics, nil
+}
+
+// runHostClockCheck measures our own clock against RS_NTP_REFERENCE, if set
+func (s *Service) runHostClockCheck() {
+	ref := os.Getenv("RS_NTP_REFERENCE")
+	if ref == "" {
+		return
+	}
+	res, err := prober.NewNTPProber(ref).Query()
+	if err != nil {
+		logging.Warn("monitor", "[NTP] Host clock check against %s failed: %v", ref, err)
+		return
+	}
+	if err := s.db.SaveMetrics(ntpMetrics(ref, MetricProbeHostClock, res)); err != nil {
+		logging.Error("monitor", "Failed to save host clock metrics: %v", err)
+	}
+	// Beyond a second, TLS validation and log correlation start to suffer
+	if res.Offset > time.Second || res.Offset < -time.Second {
+		logging.Warn("monitor", "[NTP] Host clock is off by %s against %s", res.Offset, ref)
+	}
+}
+
+func ntpMetrics(target, probe string, res *prober.NTPResult) []storage.MetricRecord {
+	now := time.Now()
+	metric := func(name string, v float64, unit string) storage.MetricRecord {
+		return storage.MetricRecord{CreatedAt: now, Target: target, Probe: probe, Name: name, Value: v, Unit: unit}
+	}
+	// Raw 32-bit reference ID, so a change of upstream shows as a step,
+	// labelled with the decoded form
+	refID := metric("refid", float64(res.RefIDRaw), "")
+	refID.Label = res.ReferenceID
+	return []storage.MetricRecord{
+		metric("offset_ms", durationMs(res.Offset), "ms"),
+		metric("delay_ms", durationMs(res.Delay), "ms"),
+		metric("stratum", float64(res.Stratum), ""),
+		metric("leap", float64(res.Leap), ""),
+		refID,
+	}
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return line
+}
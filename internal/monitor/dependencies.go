@@ -0,0 +1,201 @@
+package monitor
+
+import (
+	"net"
+	"sort"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+const (
+	// Every target is probed at once each cycle, so a child may record its
+	// failure a little before its parent does
+	parentCauseGrace = time.Minute
+	// How long a child's alert waits for its parent's side of the cycle
+	parentNotifyDelay = 30 * time.Second
+)
+
+// failingParent returns the address the target's trouble is put down to:
+// the parent's own cause when it has one, else the parent while it has an
+// open incident. Empty when there is no parent or it is healthy.
+func (s *Service) failingParent(t storage.Target) string {
+	if t.Parent == "" || t.Parent == t.Address {
+		return ""
+	}
+	inc, err := s.db.GetOpenIncident(t.Parent)
+	if err != nil {
+		logging.Error("monitor", "Failed to load open incident for parent %s of %s: %v", t.Parent, t.Name, err)
+		return ""
+	}
+	if inc == nil {
+		return ""
+	}
+	if inc.CausedBy != "" {
+		return inc.CausedBy
+	}
+	return t.Parent
+}
+
+// notifyUnlessParentFails holds a child's firing alert back for a cycle;
+// if the parent turns out to be failing too, the alert is put down to it
+// instead of being sent
+func (s *Service) notifyUnlessParentFails(t storage.Target, rule storage.AlertRule, event storage.AlertEvent) {
+	time.Sleep(parentNotifyDelay)
+	cause := s.failingParent(t)
+	if cause == "" {
+		s.notifyAlert(t, rule, &event)
+		return
+	}
+	logging.Info("monitor", "[Alert] %s depends on failing %s, not notifying", t.Name, cause)
+	s.alertMu.Lock()
+	defer s.alertMu.Unlock()
+	if err := s.db.SuppressAlertEvent(&event, cause); err != nil {
+		logging.Error("monitor", "Failed to mark alert event %d as caused by %s: %v", event.ID, cause, err)
+	}
+}
+
+// markDependents puts the recently opened incidents of everything below a
+// target that just failed down to it
+func (s *Service) markDependents(t storage.Target, since time.Time) {
+	dependents := s.dependents(t.Address)
+	if len(dependents) == 0 {
+		return
+	}
+	n, err := s.db.MarkIncidentsCausedBy(dependents, t.Address, since.Add(-parentCauseGrace))
+	if err != nil {
+		logging.Error("monitor", "Failed to mark incidents caused by %s: %v", t.Name, err)
+		return
+	}
+	if n > 0 {
+		logging.Info("monitor", "[Incident] %d dependent incident(s) put down to %s", n, t.Name)
+	}
+}
+
+// dependents lists the addresses of all targets below one, at any depth
+func (s *Service) dependents(address string) []string {
+	s.targetsMu.RLock()
+	children := make(map[string][]string)
+	for _, t := range s.targets {
+		if t.Parent != "" && t.Parent != t.Address {
+			children[t.Parent] = append(children[t.Parent], t.Address)
+		}
+	}
+	s.targetsMu.RUnlock()
+
+	var out []string
+	seen := map[string]bool{address: true}
+	queue := []string{address}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		for _, c := range children[next] {
+			if !seen[c] {
+				seen[c] = true
+				out = append(out, c)
+				queue = append(queue, c)
+			}
+		}
+	}
+	return out
+}
+
+// ParentSuggestion is a hop several targets' paths share, as a candidate
+// parent for them
+type ParentSuggestion struct {
+	Hop      string   `json:"hop"` // IP of the shared hop
+	Host     string   `json:"host,omitempty"`
+	Depth    int      `json:"depth"`            // Responding hops up to and including this one
+	Target   string   `json:"target,omitempty"` // Existing target at this hop, if any
+	Children []string `json:"children"`         // Targets whose latest path goes through it
+}
+
+type pathNode struct {
+	ip, host string
+	depth    int
+	targets  []string
+	next     map[string]*pathNode
+}
+
+// SuggestParents compares the latest stored path of every target. Where
+// paths share a prefix, the last shared hop before they split (and any
+// hop that is itself a target) is suggested as the parent of the targets
+// behind it. Deepest suggestions come first.
+func (s *Service) SuggestParents() ([]ParentSuggestion, error) {
+	targets, err := s.db.GetTargets(false)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &pathNode{next: map[string]*pathNode{}}
+	byIP := make(map[string]string) // Hop IP -> target address
+	for _, t := range targets {
+		if net.ParseIP(t.Address) != nil {
+			byIP[t.Address] = t.Address
+		}
+		rec, err := s.db.GetLatestTrace(t.Address)
+		if err != nil {
+			continue
+		}
+		node := root
+		var last string
+		for _, h := range storage.ParsePath(rec.TraceJson) {
+			// Silent hops say nothing about where paths meet
+			if !h.Responded() {
+				continue
+			}
+			child, ok := node.next[h.IP]
+			if !ok {
+				child = &pathNode{ip: h.IP, host: h.Host, depth: node.depth + 1, next: map[string]*pathNode{}}
+				node.next[h.IP] = child
+			}
+			child.targets = append(child.targets, t.Address)
+			node, last = child, h.IP
+		}
+		// A hostname target is found at the end of its own path
+		if last != "" {
+			if _, ok := byIP[last]; !ok {
+				byIP[last] = t.Address
+			}
+		}
+	}
+
+	var out []ParentSuggestion
+	var walk func(n *pathNode)
+	walk = func(n *pathNode) {
+		for _, c := range n.next {
+			walk(c)
+		}
+		if n.ip == "" {
+			return
+		}
+		target := byIP[n.ip]
+		children := make([]string, 0, len(n.targets))
+		for _, addr := range n.targets {
+			if addr != target {
+				children = append(children, addr)
+			}
+		}
+		// Only where the shared prefix ends: past it, a child hop would
+		// be shared by the same targets
+		split := len(n.targets) >= 2
+		for _, c := range n.next {
+			if len(c.targets) == len(n.targets) {
+				split = false
+			}
+		}
+		if len(children) > 0 && (split || target != "") {
+			sort.Strings(children)
+			out = append(out, ParentSuggestion{Hop: n.ip, Host: n.host, Depth: n.depth, Target: target, Children: children})
+		}
+	}
+	walk(root)
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Depth != out[j].Depth {
+			return out[i].Depth > out[j].Depth
+		}
+		return out[i].Hop < out[j].Hop
+	})
+	return out, nil
+}
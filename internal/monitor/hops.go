@@ -0,0 +1,41 @@
+package monitor
+
+import (
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// saveHops stores a cycle's trace hop by hop. Failed cycles are kept too:
+// where their trace stops is often the interesting part.
+func (s *Service) saveHops(t storage.Target, rec *storage.MonitorRecord) {
+	// Without the saved record there is nothing to tie the hops to
+	if rec.ID == 0 || len(rec.TraceJson) == 0 {
+		return
+	}
+	path := storage.ParsePath(rec.TraceJson)
+	if len(path) == 0 {
+		return
+	}
+
+	records := make([]storage.HopRecord, 0, len(path))
+	for _, h := range path {
+		hr := storage.HopRecord{
+			CreatedAt:    rec.CreatedAt,
+			Target:       t.Address,
+			Hop:          h.Hop,
+			RecordID:     rec.ID,
+			Loss:         h.Loss,
+			LatencyLast:  h.LatencyLast,
+			LatencyAvg:   h.LatencyAvg,
+			LatencyBest:  h.LatencyBest,
+			LatencyWorst: h.LatencyWorst,
+		}
+		if h.Responded() {
+			hr.IP, hr.Host, hr.ASN = h.IP, h.Host, h.ASN
+		}
+		records = append(records, hr)
+	}
+	if err := s.db.SaveHops(records); err != nil {
+		logging.Error("monitor", "Failed to save hops of %s: %v", t.Name, err)
+	}
+}
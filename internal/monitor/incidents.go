@@ -0,0 +1,98 @@
+package monitor
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// Loss at or above this opens a degradation incident unless RS_INCIDENT_LOSS says otherwise
+const defaultIncidentLoss = 50.0
+
+// incidentThresholds reads the degradation thresholds; 0 disables one
+func incidentThresholds() (lossPct, latencyMs float64) {
+	lossPct = defaultIncidentLoss
+	if v := os.Getenv("RS_INCIDENT_LOSS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			lossPct = f
+		}
+	}
+	if v := os.Getenv("RS_INCIDENT_LATENCY_MS"); v != "" {
+		if f, err := strconv.ParseFloat(v, 64); err == nil && f >= 0 {
+			latencyMs = f
+		}
+	}
+	return lossPct, latencyMs
+}
+
+// incidentCategory returns why a ping cycle counts against the target, or
+// "" for a healthy cycle
+func incidentCategory(rec *storage.MonitorRecord) string {
+	if rec.Status != storage.ProbeStatusOK {
+		return rec.Status
+	}
+	lossPct, latencyMs := incidentThresholds()
+	switch {
+	case lossPct > 0 && rec.PacketLoss >= lossPct:
+		return storage.IncidentPacketLoss
+	case latencyMs > 0 && rec.LatencyMs >= latencyMs:
+		return storage.IncidentHighLatency
+	}
+	return ""
+}
+
+// trackIncident opens, extends or closes the target's incident from one
+// ping cycle outcome. During maintenance a healthy cycle still closes an
+// incident, but failures neither open nor extend one.
+func (s *Service) trackIncident(t storage.Target, rec *storage.MonitorRecord) {
+	s.incidentMu.Lock()
+	defer s.incidentMu.Unlock()
+
+	category := incidentCategory(rec)
+	if rec.Maintenance && category != "" {
+		return
+	}
+	open, err := s.db.GetOpenIncident(t.Address)
+	if err != nil {
+		logging.Error("monitor", "Failed to load open incident for %s: %v", t.Name, err)
+		return
+	}
+
+	if category == "" {
+		if open == nil {
+			return
+		}
+		ended := rec.CreatedAt
+		open.EndedAt = &ended
+		open.DurationSec = ended.Sub(open.StartedAt).Seconds()
+		logging.Info("monitor", "[Incident] %s recovered after %s (%s)", t.Name,
+			time.Duration(open.DurationSec*float64(time.Second)).Round(time.Second), open.Category)
+	} else {
+		if open == nil {
+			open = &storage.Incident{Target: t.Address, StartedAt: rec.CreatedAt, Category: category, CausedBy: s.failingParent(t)}
+			if open.CausedBy != "" {
+				logging.Warn("monitor", "[Incident] %s opened: %s, caused by parent %s", t.Name, category, open.CausedBy)
+			} else {
+				logging.Warn("monitor", "[Incident] %s opened: %s", t.Name, category)
+				s.markDependents(t, rec.CreatedAt)
+			}
+		}
+		open.Cycles++
+		open.PeakLoss = max(open.PeakLoss, rec.PacketLoss)
+		open.PeakLatencyMs = max(open.PeakLatencyMs, rec.LatencyMs)
+		switch {
+		case rec.Error != "":
+			open.LastError = rec.Error
+		case category == storage.IncidentPacketLoss || category == storage.IncidentHighLatency:
+			open.LastError = fmt.Sprintf("loss %.1f%%, latency %.1fms", rec.PacketLoss, rec.LatencyMs)
+		}
+	}
+
+	if err := s.db.SaveIncident(open); err != nil {
+		logging.Error("monitor", "Failed to save incident for %s: %v", t.Name, err)
+	}
+}
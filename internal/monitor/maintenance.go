@@ -0,0 +1,42 @@
+package monitor
+
+import (
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// activeWindow returns the first enabled window of the kind that covers
+// the target at now, or nil
+func (s *Service) activeWindow(t storage.Target, kind string, now time.Time) *storage.MaintenanceWindow {
+	windows, err := s.db.GetMaintenanceWindows(true)
+	if err != nil {
+		logging.Error("monitor", "Failed to load maintenance windows: %v", err)
+		return nil
+	}
+	for i := range windows {
+		w := &windows[i]
+		if w.Kind != kind || !w.Covers(t) {
+			continue
+		}
+		if active, _ := w.ActiveAt(now); active {
+			return w
+		}
+	}
+	return nil
+}
+
+// markMaintenance flags a record taken during a maintenance window of its
+// target, before it is saved
+func (s *Service) markMaintenance(t storage.Target, rec *storage.MonitorRecord) {
+	if w := s.activeWindow(t, storage.WindowMaintenance, rec.CreatedAt); w != nil {
+		rec.Maintenance = true
+		logging.Debug("monitor", "[Maintenance] %s is in window %q, sample marked", t.Name, w.Name)
+	}
+}
+
+// silenced reports whether notifications for the target are silenced at now
+func (s *Service) silenced(t storage.Target, now time.Time) bool {
+	return s.activeWindow(t, storage.WindowSilence, now) != nil
+}
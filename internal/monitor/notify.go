@@ -0,0 +1,100 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/notify"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+const (
+	// Four tries over about 15s, then give up on that event
+	notifyAttempts = 4
+	notifyBackoff  = 2 * time.Second
+	notifyTimeout  = 2 * time.Minute
+)
+
+// notifyAlert sends an alert event to every enabled channel in the
+// background, so slow channels never hold up a probe cycle
+func (s *Service) notifyAlert(t storage.Target, rule storage.AlertRule, event *storage.AlertEvent) {
+	channels, err := s.db.GetChannels(true)
+	if err != nil {
+		logging.Error("notify", "Failed to load notification channels: %v", err)
+		return
+	}
+	if len(channels) == 0 {
+		return
+	}
+	msg := &notify.Message{
+		Title:      fmt.Sprintf("[%s] %s: %s", strings.ToUpper(event.State), rule.Name, t.Name),
+		Body:       event.Message,
+		State:      event.State,
+		Rule:       rule.Name,
+		Target:     t.Address,
+		TargetName: t.Name,
+		Metric:     rule.Metric,
+		Value:      event.Value,
+		Threshold:  event.Threshold,
+		Time:       event.CreatedAt,
+	}
+	for _, ch := range channels {
+		if !s.notifyLimiter.Allow(ch.ID, ch.RatePerHour) {
+			logging.Warn("notify", "[%s] Rate limit of %d/hour reached, dropped: %s", ch.Name, ch.RatePerHour, msg.Title)
+			continue
+		}
+		go s.deliver(ch, msg)
+	}
+}
+
+func (s *Service) deliver(ch storage.NotificationChannel, msg *notify.Message) {
+	sender, err := s.channelSender(&ch)
+	if err == nil {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		err = notify.SendWithRetry(ctx, sender, msg, notifyAttempts, notifyBackoff)
+		cancel()
+	}
+	if err != nil {
+		logging.Error("notify", "[%s] Delivery failed: %v", ch.Name, err)
+		s.db.UpdateChannelDelivery(ch.ID, err.Error())
+		return
+	}
+	logging.Info("notify", "[%s] Sent: %s", ch.Name, msg.Title)
+	s.db.UpdateChannelDelivery(ch.ID, "")
+}
+
+func (s *Service) channelSender(ch *storage.NotificationChannel) (notify.Sender, error) {
+	secrets, err := s.db.ChannelSecrets(ch)
+	if err != nil {
+		return nil, err
+	}
+	return notify.New(ch.Type, ch.Config, secrets)
+}
+
+// TestChannel sends a test message right away, once and outside the rate
+// limit, so the caller sees the channel's own error
+func (s *Service) TestChannel(ch *storage.NotificationChannel) error {
+	sender, err := s.channelSender(ch)
+	if err != nil {
+		return err
+	}
+	msg := &notify.Message{
+		Title: "[TEST] RouteLens notification",
+		Body:  fmt.Sprintf("Test message for channel %q. If you can read this, alerts will reach you here.", ch.Name),
+		State: "test",
+		Rule:  "test",
+		Time:  time.Now(),
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	err = sender.Send(ctx, msg)
+	if err != nil {
+		s.db.UpdateChannelDelivery(ch.ID, err.Error())
+		return err
+	}
+	s.db.UpdateChannelDelivery(ch.ID, "")
+	return nil
+}
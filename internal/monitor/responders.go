@@ -0,0 +1,54 @@
+package monitor
+
+import (
+	"os"
+	"strconv"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
+)
+
+// startResponders runs the optional UDP responders other RouteLens instances
+// (or any standards-based sender) probe against
+func (s *Service) startResponders() {
+	if port, ok := responderPort("RS_TWAMP_PORT"); ok {
+		s.twampReflector = prober.NewTWAMPReflector()
+		// Only the operator knows whether this host's clock is disciplined
+		s.twampReflector.Synced = os.Getenv("RS_TWAMP_SYNCED") == "true"
+		go func() {
+			if err := s.twampReflector.ListenAndServe(":" + strconv.Itoa(port)); err != nil {
+				logging.Error("monitor", "TWAMP reflector stopped: %v", err)
+			}
+		}()
+	}
+	if port, ok := responderPort("RS_UDP_ECHO_PORT"); ok {
+		s.udpEchoResponder = prober.NewUDPEchoResponder()
+		go func() {
+			if err := s.udpEchoResponder.ListenAndServe(":" + strconv.Itoa(port)); err != nil {
+				logging.Error("monitor", "UDP echo responder stopped: %v", err)
+			}
+		}()
+	}
+}
+
+func responderPort(env string) (int, bool) {
+	v := os.Getenv(env)
+	if v == "" {
+		return 0, false
+	}
+	port, err := strconv.Atoi(v)
+	if err != nil || port <= 0 || port > 65535 {
+		logging.Error("monitor", "Invalid %s %q, responder disabled", env, v)
+		return 0, false
+	}
+	return port, true
+}
+
+func (s *Service) stopResponders() {
+	if s.twampReflector != nil {
+		s.twampReflector.Close()
+	}
+	if s.udpEchoResponder != nil {
+		s.udpEchoResponder.Close()
+	}
+}
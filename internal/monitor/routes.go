@@ -0,0 +1,192 @@
+package monitor
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// A different path must show up this many traces in a row before it
+// replaces the current one; a single odd trace is usually a hop that
+// didn't answer in time
+const routeConfirmTraces = 2
+
+type routeCandidate struct {
+	fingerprint string
+	traces      int
+	since       time.Time
+}
+
+// hopDiff is one step of the edit from an old hop sequence to a new one
+type hopDiff struct {
+	Op string `json:"op"` // "=" kept, "-" dropped, "+" added
+	IP string `json:"ip"`
+}
+
+// trackRoute compares a successful cycle's trace with the target's current
+// path version, extending it or, once a different path is confirmed,
+// starting a new version and recording the change
+func (s *Service) trackRoute(t storage.Target, rec *storage.MonitorRecord) {
+	// A failed cycle's path stops short wherever the outage is
+	if rec.Status != storage.ProbeStatusOK || len(rec.TraceJson) == 0 {
+		return
+	}
+	ips, asPath := pathSignature(storage.ParsePath(rec.TraceJson))
+	if len(ips) == 0 {
+		return
+	}
+	fp, asFP := fingerprint(ips), fingerprint(asPath)
+
+	s.routeMu.Lock()
+	defer s.routeMu.Unlock()
+	cur, err := s.db.GetCurrentRoutePath(t.Address)
+	if err != nil {
+		logging.Error("monitor", "Failed to load current path of %s: %v", t.Name, err)
+		return
+	}
+	if cur == nil || cur.Fingerprint == fp {
+		if cur == nil {
+			cur = &storage.RoutePath{Target: t.Address, StartedAt: rec.CreatedAt, Fingerprint: fp, ASFinger: asFP,
+				Hops: strings.Join(ips, ","), ASPath: strings.Join(asPath, ",")}
+		}
+		cur.LastSeenAt = rec.CreatedAt
+		cur.Samples++
+		delete(s.routeCandidates, t.Address)
+		if err := s.db.SaveRoutePath(cur); err != nil {
+			logging.Error("monitor", "Failed to save path of %s: %v", t.Name, err)
+		}
+		return
+	}
+
+	cand := s.routeCandidates[t.Address]
+	if cand == nil || cand.fingerprint != fp {
+		cand = &routeCandidate{fingerprint: fp, since: rec.CreatedAt}
+		s.routeCandidates[t.Address] = cand
+	}
+	cand.traces++
+	if cand.traces < routeConfirmTraces {
+		return
+	}
+	delete(s.routeCandidates, t.Address)
+
+	// The new path took over when it was first seen
+	ended := cand.since
+	cur.EndedAt = &ended
+	next := &storage.RoutePath{
+		Target:      t.Address,
+		StartedAt:   cand.since,
+		LastSeenAt:  rec.CreatedAt,
+		Samples:     cand.traces,
+		Fingerprint: fp,
+		ASFinger:    asFP,
+		Hops:        strings.Join(ips, ","),
+		ASPath:      strings.Join(asPath, ","),
+	}
+	change := &storage.RouteChange{
+		CreatedAt:  cand.since,
+		Target:     t.Address,
+		Level:      storage.RouteChangeIP,
+		FromHops:   cur.Hops,
+		ToHops:     next.Hops,
+		FromASPath: cur.ASPath,
+		ToASPath:   next.ASPath,
+	}
+	// Without ASN data on either side there's no telling networks apart
+	if cur.ASFinger != "" && asFP != "" && cur.ASFinger != asFP {
+		change.Level = storage.RouteChangeASN
+	}
+	diff, _ := json.Marshal(diffHops(storage.SplitList(cur.Hops), ips))
+	change.Diff = string(diff)
+
+	if err := s.db.ChangeRoute(cur, next, change); err != nil {
+		logging.Error("monitor", "Failed to record path change of %s: %v", t.Name, err)
+		return
+	}
+	logging.Warn("monitor", "[Route] %s path changed (%s level): %d -> %d hops, AS path %s -> %s",
+		t.Name, change.Level, len(storage.SplitList(cur.Hops)), len(ips), orDash(cur.ASPath), orDash(next.ASPath))
+}
+
+// pathSignature reduces a trace to what identifies its path: the IPs of
+// the hops that answered, and the ASNs they belong to with repeats
+// collapsed. Silent hops come and go, so they are left out.
+func pathSignature(hops []storage.PathHop) (ips, asPath []string) {
+	for _, h := range hops {
+		if !h.Responded() {
+			continue
+		}
+		if len(ips) == 0 || ips[len(ips)-1] != h.IP {
+			ips = append(ips, h.IP)
+		}
+		asn := strings.ToUpper(strings.TrimSpace(h.ASN))
+		if asn == "" || strings.Contains(asn, "?") {
+			continue
+		}
+		if len(asPath) == 0 || asPath[len(asPath)-1] != asn {
+			asPath = append(asPath, asn)
+		}
+	}
+	return ips, asPath
+}
+
+func fingerprint(parts []string) string {
+	if len(parts) == 0 {
+		return ""
+	}
+	sum := sha1.Sum([]byte(strings.Join(parts, ">")))
+	return hex.EncodeToString(sum[:])
+}
+
+// diffHops is the shortest edit from one hop sequence to another, from
+// their longest common subsequence
+func diffHops(from, to []string) []hopDiff {
+	n, m := len(from), len(to)
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []hopDiff
+	i, j := 0, 0
+	for i < n && j < m {
+		switch {
+		case from[i] == to[j]:
+			out = append(out, hopDiff{Op: "=", IP: from[i]})
+			i++
+			j++
+		case lcs[i+1][j] >= lcs[i][j+1]:
+			out = append(out, hopDiff{Op: "-", IP: from[i]})
+			i++
+		default:
+			out = append(out, hopDiff{Op: "+", IP: to[j]})
+			j++
+		}
+	}
+	for ; i < n; i++ {
+		out = append(out, hopDiff{Op: "-", IP: from[i]})
+	}
+	for ; j < m; j++ {
+		out = append(out, hopDiff{Op: "+", IP: to[j]})
+	}
+	return out
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -2,6 +2,7 @@ package monitor
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -9,12 +10,14 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/yuanweize/RouteLens/pkg/geoip"
 	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/notify"
 	"github.com/yuanweize/RouteLens/pkg/prober"
 	"github.com/yuanweize/RouteLens/pkg/storage"
 )
@@ -29,6 +32,26 @@ type Service struct {
 	heartbeatTicker *time.Ticker
 	stopChan        chan struct{}
 	geoProvider     *geoip.Provider
+
+	snmpMu   sync.Mutex
+	snmpLast map[string]prober.InterfaceCounters // Previous SNMP reading per target|interface
+
+	twampReflector   *prober.TWAMPReflector   // Nil unless RS_TWAMP_PORT is set
+	udpEchoResponder *prober.UDPEchoResponder // Nil unless RS_UDP_ECHO_PORT is set
+
+	incidentMu sync.Mutex // Serializes incident open/close per cycle
+
+	adaptiveMu sync.Mutex
+	adaptive   map[string]*adaptiveState // Targets being probed faster, by address
+
+	routeMu         sync.Mutex
+	routeCandidates map[string]*routeCandidate // Unconfirmed new path per target
+
+	alertMu    sync.Mutex // Serializes alert state updates
+	baselineMu sync.Mutex
+	baselines  map[string]alertBaseline // Alert rule baselines per target|metric|days
+
+	notifyLimiter *notify.RateLimiter // Per-channel deliveries in the last hour
 }
 
 func NewService(db *storage.DB) *Service {
@@ -37,6 +60,13 @@ func NewService(db *storage.DB) *Service {
 		db:          db,
 		stopChan:    make(chan struct{}),
 		geoProvider: geoProvider,
+		snmpLast:    make(map[string]prober.InterfaceCounters),
+		baselines:   make(map[string]alertBaseline),
+		adaptive:    make(map[string]*adaptiveState),
+
+		routeCandidates: make(map[string]*routeCandidate),
+
+		notifyLimiter: notify.NewRateLimiter(time.Hour),
 	}
 	s.refreshTargets() // Initial load
 	return s
@@ -59,6 +89,8 @@ func (s *Service) Start() {
 	s.refreshTicker = time.NewTicker(1 * time.Minute)
 	s.heartbeatTicker = time.NewTicker(60 * time.Second) // Heartbeat every 60s
 
+	s.startResponders()
+
 	// Run initial cycles immediately on startup
 	go func() {
 		time.Sleep(2 * time.Second) // Wait for service to fully initialize
@@ -71,6 +103,7 @@ func (s *Service) Start() {
 }
 
 func (s *Service) Stop() {
+	s.stopResponders()
 	if s.geoProvider != nil {
 		s.geoProvider.Close()
 	}
@@ -117,7 +150,14 @@ func (s *Service) runPingTraceCycle() {
 		}
 		enabledTargets++
 		go s.runPingTraceForTarget(target)
+		if storage.IsCheckProbe(target.ProbeType) {
+			go s.runCheckForTarget(target)
+		}
+		if target.SNMPConfig != "" {
+			go s.runSNMPForTarget(target)
+		}
 	}
+	go s.runHostClockCheck()
 	logging.Info("monitor", "Starting ping/trace cycle for %d targets (total: %d)", enabledTargets, len(targetsCopy))
 }
 
@@ -132,8 +172,8 @@ func (s *Service) runSpeedCycle() {
 		if !target.Enabled {
 			continue // Skip disabled targets
 		}
-		if target.ProbeType == storage.ProbeModeICMP || target.ProbeType == "" {
-			continue // No speed test for ICMP only mode
+		if !hasSpeedTest(target) {
+			continue // No speed test for ICMP only mode and check probes
 		}
 		speedTargets = append(speedTargets, target)
 	}
@@ -150,15 +190,31 @@ func (s *Service) runSpeedCycle() {
 	}
 }
 
+// hasSpeedTest reports whether the target's probe mode is a bandwidth test
+func hasSpeedTest(t storage.Target) bool {
+	return t.ProbeType != storage.ProbeModeICMP && t.ProbeType != "" && !storage.IsCheckProbe(t.ProbeType)
+}
+
 func (s *Service) runPingTraceForTarget(t storage.Target) {
+	s.pingTrace(t, false)
+}
+
+// pingTrace runs one ping + trace cycle. Adaptive cycles are the extra ones
+// run while a target is degraded (see adaptive.go).
+func (s *Service) pingTrace(t storage.Target, adaptive bool) {
 	logging.Debug("probe", "[MTR] Starting probe for %s (%s)", t.Name, t.Address)
 
 	// 1. Ping (fallback latency)
+	if err := prober.ValidateTarget(t.Address); err != nil {
+		s.saveOutage(t, storage.ProbeStatusConfigError, err, adaptive)
+		return
+	}
 	pinger := prober.NewICMPPinger(t.Address, 5)
 	pingRes, err := pinger.Run()
 	if err != nil {
 		log.Printf("Ping failed for %s: %v", t.Name, err)
 		logging.Error("probe", "[ICMP] Ping failed for %s (%s): %v", t.Name, t.Address, err)
+		s.saveOutage(t, classifyPingError(err), err, adaptive)
 		return
 	}
 	logging.Info("probe", "[ICMP] Ping OK for %s: latency=%.1fms, loss=%.1f%%", t.Name, float64(pingRes.AvgRtt.Microseconds())/1000.0, pingRes.LossRate)
@@ -192,10 +248,63 @@ func (s *Service) runPingTraceForTarget(t storage.Target) {
 		TraceJson:  traceBytes,
 		SpeedUp:    0,
 		SpeedDown:  0,
+		Status:     storage.ProbeStatusOK,
+	}
+	// Still traced above: where the path stops is the useful part of an outage
+	if packetLoss >= 100 {
+		rec.Status = storage.ProbeStatusTimeout
+		rec.Error = fmt.Sprintf("no reply to %d pings", pingRes.PacketsSent)
+		logging.Warn("probe", "[ICMP] No replies from %s (%s)", t.Name, t.Address)
 	}
+	s.savePingCycle(t, rec, adaptive)
+}
+
+// saveOutage records a ping cycle that produced no measurement, so the gap
+// reads as downtime rather than missing data
+func (s *Service) saveOutage(t storage.Target, status string, cause error, adaptive bool) {
+	rec := &storage.MonitorRecord{
+		Target:     t.Address,
+		CreatedAt:  time.Now(),
+		PacketLoss: 100,
+		Status:     status,
+		Error:      cause.Error(),
+	}
+	s.savePingCycle(t, rec, adaptive)
+}
+
+// savePingCycle stores a ping cycle outcome. Only regular cycles feed
+// incidents and alerts, whose cycle counts assume the regular interval.
+func (s *Service) savePingCycle(t storage.Target, rec *storage.MonitorRecord, adaptive bool) {
+	rec.Adaptive = adaptive
+	s.markMaintenance(t, rec)
 	if err := s.db.SaveRecord(rec); err != nil {
 		log.Printf("Failed to save record for %s: %v", t.Name, err)
 	}
+	s.saveHops(t, rec)
+	s.trackRoute(t, rec)
+	if !adaptive {
+		s.trackIncident(t, rec)
+		s.evaluateAlerts(t, rec)
+	}
+	s.noteAdaptive(t, rec)
+}
+
+// classifyPingError maps an ICMPPinger error to a ProbeStatus
+func classifyPingError(err error) string {
+	var dnsErr *net.DNSError
+	var addrErr *net.AddrError
+	switch {
+	case errors.As(err, &dnsErr):
+		return storage.ProbeStatusResolveFailure
+	case errors.As(err, &addrErr):
+		// e.g. an IPv6 literal, which the IPv4 pinger can't probe
+		return storage.ProbeStatusConfigError
+	case errors.Is(err, os.ErrPermission):
+		return storage.ProbeStatusPermissionError
+	default:
+		// Unreachable networks and the like: the probe went nowhere
+		return storage.ProbeStatusTimeout
+	}
 }
 
 func (s *Service) runSpeedForTarget(t storage.Target) {
@@ -205,6 +314,12 @@ func (s *Service) runSpeedForTarget(t storage.Target) {
 
 	logging.Info("speedtest", "[%s] >>> Starting speed test for %s (%s)", t.ProbeType, t.Name, t.Address)
 
+	// Optional latency-under-load sampling; runs across the whole test
+	bloatSampler := startBufferbloatSampler(t)
+	if bloatSampler != nil {
+		defer bloatSampler.Stop()
+	}
+
 	switch t.ProbeType {
 	case storage.ProbeModeSSH:
 		logging.Info("speedtest", "[SSH] Parsing SSH config for %s...", t.Name)
@@ -217,9 +332,25 @@ func (s *Service) runSpeedForTarget(t storage.Target) {
 			return
 		}
 		sshCfg.Host = t.Address
+		// Read the pinned key fresh: the cached target list may predate an accept
+		sshCfg.HostKey = t.SSHHostKey
+		if fresh, dbErr := s.db.GetTargetByID(t.ID); dbErr == nil {
+			sshCfg.HostKey = fresh.SSHHostKey
+		}
 		logging.Info("speedtest", "[SSH] Connecting to %s@%s:%d...", sshCfg.User, sshCfg.Host, sshCfg.Port)
 		runner := prober.NewSSHSpeedTester(sshCfg)
 		speedRes, err = runner.Run()
+		if learned := runner.LearnedHostKey(); learned != "" {
+			logging.Info("speedtest", "[SSH] Pinned host key for %s on first use", t.Address)
+			s.db.PinTargetHostKey(t.Address, learned)
+		}
+		if learned := runner.LearnedJumpHostKeys(); len(learned) > 0 {
+			s.pinJumpHostKeys(t, learned)
+		}
+		// Skip when the target is unreachable or untrusted; the speed test error says why
+		if rev := parseSSHReverseConfig(t.ProbeConfig); rev != nil && err == nil {
+			s.runReverseForTarget(t, sshCfg, rev)
+		}
 
 	case storage.ProbeModeHTTP:
 		url, cfgErr := parseHTTPConfig(t.ProbeConfig)
@@ -233,34 +364,52 @@ func (s *Service) runSpeedForTarget(t storage.Target) {
 		speedRes, err = runner.Run()
 
 	case storage.ProbeModeIPERF:
-		port := 5201
-		cfgPort, cfgErr := parseIperfConfig(t.ProbeConfig)
+		iperfOpts, cfgErr := parseIperfConfig(t.ProbeConfig)
 		if cfgErr != nil {
 			configErr = cfgErr
 			log.Printf("Invalid IPERF config for %s: %v", t.Name, cfgErr)
 			s.db.UpdateTargetError(t.Address, fmt.Sprintf("Config error: %v", cfgErr))
 			return
 		}
-		if cfgPort != 0 {
-			port = cfgPort
+		runner := prober.NewIperfProber(t.Address, iperfOpts)
+		logging.Info("speedtest", "[IPERF] Testing %s:%d (udp=%v, reverse=%v, parallel=%d, duration=%s)",
+			t.Address, runner.Opts.Port, runner.Opts.UDP, runner.Opts.Reverse, runner.Opts.Parallel, runner.Opts.Duration)
+		speedRes, err = runner.Run()
+
+	case storage.ProbeModeRouteLens:
+		peerCfg, cfgErr := parseRouteLensConfig(t.ProbeConfig, t.Address)
+		if cfgErr != nil {
+			configErr = cfgErr
+			log.Printf("Invalid RouteLens peer config for %s: %v", t.Name, cfgErr)
+			s.db.UpdateTargetError(t.Address, fmt.Sprintf("Config error: %v", cfgErr))
+			return
+		}
+		logging.Info("speedtest", "[PEER] Testing against RouteLens peer %s", peerCfg.URL)
+		runner := prober.NewRouteLensSpeedTester(peerCfg.URL, peerCfg.Token, peerCfg.TestBytes)
+		speedRes, err = runner.Run()
+
+	case storage.ProbeModeLibreSpeed:
+		lsCfg, cfgErr := parseLibreSpeedConfig(t.ProbeConfig, t.Address)
+		if cfgErr != nil {
+			configErr = cfgErr
+			log.Printf("Invalid LibreSpeed config for %s: %v", t.Name, cfgErr)
+			s.db.UpdateTargetError(t.Address, fmt.Sprintf("Config error: %v", cfgErr))
+			return
+		}
+		logging.Info("speedtest", "[LibreSpeed] Testing against %s", lsCfg.URL)
+		runner := prober.NewLibreSpeedTester(lsCfg.URL)
+		if lsCfg.Streams > 0 {
+			runner.Streams = lsCfg.Streams
+		}
+		if lsCfg.DurationSec > 0 {
+			runner.Duration = time.Duration(lsCfg.DurationSec) * time.Second
 		}
-		runner := prober.NewIperfProber(t.Address, port)
 		speedRes, err = runner.Run()
 	}
 
 	// Handle probe errors - store them for UI display
 	if err != nil {
-		errMsg := err.Error()
-		// Categorize common SSH errors for better UX
-		if strings.Contains(errMsg, "ssh") || strings.Contains(errMsg, "SSH") {
-			if strings.Contains(errMsg, "handshake") || strings.Contains(errMsg, "key") {
-				errMsg = "SSH: Authentication failed - check credentials/key"
-			} else if strings.Contains(errMsg, "connection refused") {
-				errMsg = "SSH: Connection refused - check host/port"
-			} else if strings.Contains(errMsg, "timeout") || strings.Contains(errMsg, "i/o timeout") {
-				errMsg = "SSH: Connection timeout - host unreachable"
-			}
-		}
+		errMsg := s.sshErrorMessage(t, err)
 		log.Printf("Speed test failed for %s (%s): %v", t.Name, t.ProbeType, err)
 		logging.Error("speedtest", "Speed test failed for %s (%s): %v", t.Name, t.ProbeType, err)
 		s.db.UpdateTargetError(t.Address, errMsg)
@@ -272,10 +421,16 @@ func (s *Service) runSpeedForTarget(t storage.Target) {
 		s.db.ClearTargetError(t.Address)
 		if speedRes != nil {
 			logging.Info("speedtest", "Speed test completed for %s: Down=%.1f Mbps, Up=%.1f Mbps", t.Name, speedRes.DownloadSpeed, speedRes.UploadSpeed)
+			if speedRes.Latency > 0 {
+				logging.Info("speedtest", "Speed test latency for %s: ping=%.1fms, jitter=%.1fms", t.Name, durationMs(speedRes.Latency), durationMs(speedRes.Jitter))
+			}
 		}
 	}
 
 	if speedRes != nil {
+		if bloatSampler != nil {
+			finishBufferbloat(t, bloatSampler, speedRes)
+		}
 		rec := &storage.MonitorRecord{
 			Target:     t.Address,
 			CreatedAt:  time.Now(),
@@ -283,10 +438,16 @@ func (s *Service) runSpeedForTarget(t storage.Target) {
 			PacketLoss: 0,
 			SpeedUp:    speedRes.UploadSpeed,
 			SpeedDown:  speedRes.DownloadSpeed,
+			SpeedJson:  serializeSpeedDetails(speedRes),
 		}
+		if speedRes.Bufferbloat != nil {
+			rec.BloatGrade = speedRes.Bufferbloat.Grade
+		}
+		s.markMaintenance(t, rec)
 		if err := s.db.SaveRecord(rec); err != nil {
 			log.Printf("Failed to save speed record for %s: %v", t.Name, err)
 		}
+		s.evaluateAlerts(t, rec)
 	}
 }
 
@@ -299,7 +460,9 @@ func (s *Service) TriggerProbe(target string) {
 	if target == "" {
 		for _, t := range targetsCopy {
 			go s.runPingTraceForTarget(t)
-			if t.ProbeType != storage.ProbeModeICMP && t.ProbeType != "" {
+			if storage.IsCheckProbe(t.ProbeType) {
+				go s.runCheckForTarget(t)
+			} else if hasSpeedTest(t) {
 				go s.runSpeedForTarget(t)
 			}
 		}
@@ -309,7 +472,9 @@ func (s *Service) TriggerProbe(target string) {
 	for _, t := range targetsCopy {
 		if t.Address == target {
 			go s.runPingTraceForTarget(t)
-			if t.ProbeType != storage.ProbeModeICMP && t.ProbeType != "" {
+			if storage.IsCheckProbe(t.ProbeType) {
+				go s.runCheckForTarget(t)
+			} else if hasSpeedTest(t) {
 				go s.runSpeedForTarget(t)
 			}
 			return
@@ -324,6 +489,35 @@ type sshProbeConfig struct {
 	KeyText   string `json:"key_text"`
 	Port      int    `json:"port"`
 	TestBytes int64  `json:"test_bytes"`
+	Method    string `json:"method"`     // "exec" (default) or "sftp"
+	RemoteDir string `json:"remote_dir"` // SFTP temporary file directory
+	// Parallel exec mode, time-bounded instead of byte-bounded
+	Streams     int `json:"streams"`     // Channels per connection
+	Connections int `json:"connections"` // TCP connections
+	DurationSec int `json:"duration"`
+	// Bastions to chain through, in order
+	JumpHosts []sshJumpHostConfig `json:"jump_hosts"`
+	// Reverse path probing from this host
+	Reverse *sshReverseConfig `json:"reverse"`
+	// MODE_SSH_SESSION: command whose round trip is timed
+	Command string `json:"command"`
+}
+
+// sshReverseConfig makes the SSH target a vantage point probing back toward us
+type sshReverseConfig struct {
+	Enabled     bool   `json:"enabled"`
+	Destination string `json:"destination"` // Empty = RS_PUBLIC_ADDRESS, else our address as the remote sees it
+	Count       int    `json:"count"`
+}
+
+type sshJumpHostConfig struct {
+	Host     string `json:"host"`
+	Port     int    `json:"port"`
+	User     string `json:"user"`
+	Password string `json:"password"`
+	KeyPath  string `json:"key_path"`
+	KeyText  string `json:"key_text"`
+	HostKey  string `json:"host_key"` // Learned on first use when empty
 }
 
 type httpProbeConfig struct {
@@ -331,7 +525,21 @@ type httpProbeConfig struct {
 }
 
 type iperfProbeConfig struct {
-	Port int `json:"port"`
+	Port        int    `json:"port"`
+	UDP         bool   `json:"udp"`
+	Bitrate     string `json:"bitrate"` // bits/sec per stream, K/M/G suffixes allowed
+	Reverse     bool   `json:"reverse"` // Only measure server -> client
+	Parallel    int    `json:"parallel"`
+	DurationSec int    `json:"duration"`
+	Congestion  string `json:"congestion"`
+	BlockSize   int    `json:"len"`
+}
+
+// routelensProbeConfig points at another RouteLens instance with speed test endpoints enabled
+type routelensProbeConfig struct {
+	URL       string `json:"url"`   // Peer base URL, defaults to http://<address>:8080
+	Token     string `json:"token"` // Peer's RS_SPEEDTEST_TOKEN
+	TestBytes int64  `json:"test_bytes"`
 }
 
 func parseSSHConfig(raw string) (prober.SSHConfig, error) {
@@ -343,12 +551,49 @@ func parseSSHConfig(raw string) (prober.SSHConfig, error) {
 		return prober.SSHConfig{}, err
 	}
 	sshCfg := prober.SSHConfig{
-		User:      cfg.User,
-		Password:  cfg.Password,
-		KeyPath:   cfg.KeyPath,
-		KeyText:   cfg.KeyText,
-		Port:      cfg.Port,
-		TestBytes: cfg.TestBytes,
+		User:        cfg.User,
+		Password:    cfg.Password,
+		KeyPath:     cfg.KeyPath,
+		KeyText:     cfg.KeyText,
+		Port:        cfg.Port,
+		TestBytes:   cfg.TestBytes,
+		Method:      cfg.Method,
+		RemoteDir:   cfg.RemoteDir,
+		Streams:     cfg.Streams,
+		Connections: cfg.Connections,
+		Duration:    time.Duration(cfg.DurationSec) * time.Second,
+	}
+	switch sshCfg.Method {
+	case "", prober.SSHMethodExec, prober.SSHMethodSFTP:
+	default:
+		return prober.SSHConfig{}, fmt.Errorf("unknown ssh method %q", sshCfg.Method)
+	}
+	// OpenSSH allows 10 sessions per connection by default (MaxSessions)
+	if cfg.Streams > 10 {
+		return prober.SSHConfig{}, fmt.Errorf("streams must be at most 10")
+	}
+	if cfg.Connections > 16 {
+		return prober.SSHConfig{}, fmt.Errorf("connections must be at most 16")
+	}
+	if cfg.DurationSec < 0 || cfg.DurationSec > 60 {
+		return prober.SSHConfig{}, fmt.Errorf("duration must be between 0 and 60 seconds")
+	}
+	for i, j := range cfg.JumpHosts {
+		if j.Host == "" {
+			return prober.SSHConfig{}, fmt.Errorf("jump host %d: host is required", i+1)
+		}
+		sshCfg.JumpHosts = append(sshCfg.JumpHosts, prober.SSHJumpHost{
+			Host:     j.Host,
+			Port:     j.Port,
+			User:     j.User,
+			Password: j.Password,
+			KeyPath:  j.KeyPath,
+			KeyText:  j.KeyText,
+			HostKey:  j.HostKey,
+		})
+	}
+	if sshCfg.Method == prober.SSHMethodSFTP && (cfg.Streams > 1 || cfg.Connections > 1 || cfg.DurationSec > 0) {
+		return prober.SSHConfig{}, fmt.Errorf("parallel and time-bounded tests require the exec method")
 	}
 	if sshCfg.Port == 0 {
 		sshCfg.Port = 22
@@ -359,6 +604,118 @@ func parseSSHConfig(raw string) (prober.SSHConfig, error) {
 	return sshCfg, nil
 }
 
+// parseSSHReverseConfig returns the reverse probe settings, or nil when disabled
+func parseSSHReverseConfig(raw string) *sshReverseConfig {
+	var cfg sshProbeConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil || cfg.Reverse == nil || !cfg.Reverse.Enabled {
+		return nil
+	}
+	if cfg.Reverse.Destination == "" {
+		cfg.Reverse.Destination = os.Getenv("RS_PUBLIC_ADDRESS")
+	}
+	if cfg.Reverse.Count <= 0 || cfg.Reverse.Count > 100 {
+		cfg.Reverse.Count = 10
+	}
+	return cfg.Reverse
+}
+
+// runReverseForTarget runs mtr/ping on the SSH target toward rev.Destination and
+// stores the result as the reverse path. Failures are logged only: the target
+// itself is healthy, it just lacks the tools or permissions.
+func (s *Service) runReverseForTarget(t storage.Target, sshCfg prober.SSHConfig, rev *sshReverseConfig) {
+	// The speed test may have just pinned the key; use it for this connection too
+	if fresh, err := s.db.GetTargetByID(t.ID); err == nil {
+		sshCfg.HostKey = fresh.SSHHostKey
+	}
+	runner := prober.NewSSHRemoteProber(sshCfg, rev.Destination)
+	runner.Count = rev.Count
+	res, err := runner.Run()
+	if err != nil {
+		logging.Warn("monitor", "[Reverse] Remote probe from %s failed: %v", t.Name, err)
+		return
+	}
+
+	rec := &storage.ReversePathRecord{
+		Target:      t.Address,
+		CreatedAt:   time.Now(),
+		Destination: res.Destination,
+		Tool:        res.Tool,
+		LatencyMs:   res.LatencyMs,
+		PacketLoss:  res.Loss,
+		TraceJson:   s.serializeTraceFromMTR(res.Trace, false),
+	}
+	if err := s.db.SaveReversePath(rec); err != nil {
+		log.Printf("Failed to save reverse path for %s: %v", t.Name, err)
+		return
+	}
+	logging.Info("monitor", "[Reverse] %s -> %s via %s: %.1fms, %.1f%% loss, %d hops",
+		t.Name, res.Destination, res.Tool, res.LatencyMs, res.Loss, len(res.Trace.Hops))
+}
+
+// sshErrorMessage turns an SSH failure into a message for the UI. A host key
+// mismatch on the target itself leaves the offered key pending for an admin.
+func (s *Service) sshErrorMessage(t storage.Target, err error) string {
+	errMsg := err.Error()
+	var mismatch *prober.HostKeyMismatchError
+	if errors.As(err, &mismatch) && mismatch.JumpHost {
+		// Bastion keys live in probe_config; the admin edits them there
+		return fmt.Sprintf("SSH: Jump host %s key mismatch - expected %s, got %s. Update its host_key if it was rotated", mismatch.Host, mismatch.Expected, mismatch.Got)
+	} else if mismatch != nil {
+		// Keep the offered key so an admin can accept it if the rotation was legitimate
+		s.db.SetPendingHostKey(t.Address, mismatch.Key)
+		return fmt.Sprintf("SSH: Host key mismatch - expected %s, got %s. Accept the new key if it was rotated", mismatch.Expected, mismatch.Got)
+	} else if strings.Contains(errMsg, "ssh") || strings.Contains(errMsg, "SSH") {
+		// Categorize common SSH errors for better UX
+		if strings.Contains(errMsg, "handshake") || strings.Contains(errMsg, "key") {
+			return "SSH: Authentication failed - check credentials/key"
+		} else if strings.Contains(errMsg, "connection refused") {
+			return "SSH: Connection refused - check host/port"
+		} else if strings.Contains(errMsg, "timeout") || strings.Contains(errMsg, "i/o timeout") {
+			return "SSH: Connection timeout - host unreachable"
+		}
+	}
+	return errMsg
+}
+
+// pinJumpHostKeys writes bastion keys learned on first use back into the
+// target's probe_config, leaving every other field untouched
+func (s *Service) pinJumpHostKeys(t storage.Target, learned map[int]string) {
+	// Start from the stored config: the cached copy may predate an edit
+	fresh, err := s.db.GetTargetByID(t.ID)
+	if err != nil {
+		return
+	}
+	var raw map[string]interface{}
+	if err := json.Unmarshal([]byte(fresh.ProbeConfig), &raw); err != nil {
+		return
+	}
+	jumps, ok := raw["jump_hosts"].([]interface{})
+	if !ok {
+		return
+	}
+	for i, key := range learned {
+		if i >= len(jumps) {
+			continue
+		}
+		jump, ok := jumps[i].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		// Never overwrite a key pinned since the probe started
+		if existing, _ := jump["host_key"].(string); existing == "" {
+			jump["host_key"] = key
+			logging.Info("speedtest", "[SSH] Pinned host key for jump host %v on first use", jump["host"])
+		}
+	}
+	updated, err := json.Marshal(raw)
+	if err != nil {
+		return
+	}
+	if err := s.db.UpdateTargetProbeConfig(t.ID, string(updated)); err != nil {
+		logging.Error("speedtest", "[SSH] Failed to save jump host keys for %s: %v", t.Name, err)
+	}
+}
+
 func parseHTTPConfig(raw string) (string, error) {
 	if raw == "" {
 		return "", fmt.Errorf("http url is required")
@@ -370,15 +727,204 @@ func parseHTTPConfig(raw string) (string, error) {
 	return cfg.URL, nil
 }
 
-func parseIperfConfig(raw string) (int, error) {
+func parseIperfConfig(raw string) (prober.IperfOptions, error) {
 	if raw == "" {
-		return 0, nil
+		return prober.IperfOptions{}, nil
 	}
 	var cfg iperfProbeConfig
 	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
-		return 0, err
+		return prober.IperfOptions{}, err
+	}
+	bitrate, err := parseBitrate(cfg.Bitrate)
+	if err != nil {
+		return prober.IperfOptions{}, err
+	}
+	if cfg.Parallel > 128 {
+		return prober.IperfOptions{}, fmt.Errorf("parallel must be at most 128")
+	}
+	if cfg.DurationSec > 60 {
+		return prober.IperfOptions{}, fmt.Errorf("duration must be at most 60 seconds")
+	}
+	if err := prober.ValidateIperfBlockSize(cfg.BlockSize, cfg.UDP); err != nil {
+		return prober.IperfOptions{}, err
+	}
+	return prober.IperfOptions{
+		Port:       cfg.Port,
+		UDP:        cfg.UDP,
+		Bitrate:    bitrate,
+		Reverse:    cfg.Reverse,
+		Parallel:   cfg.Parallel,
+		Duration:   time.Duration(cfg.DurationSec) * time.Second,
+		Congestion: cfg.Congestion,
+		BlockSize:  cfg.BlockSize,
+	}, nil
+}
+
+// parseBitrate parses iperf3-style rates such as "500K", "100M" or "1G"
+func parseBitrate(raw string) (int64, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return 0, nil
+	}
+	multiplier := 1.0
+	switch strings.ToUpper(raw[len(raw)-1:]) {
+	case "K":
+		multiplier = 1e3
+	case "M":
+		multiplier = 1e6
+	case "G":
+		multiplier = 1e9
+	}
+	if multiplier != 1.0 {
+		raw = raw[:len(raw)-1]
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil || value < 0 {
+		return 0, fmt.Errorf("invalid bitrate %q", raw)
+	}
+	return int64(value * multiplier), nil
+}
+
+func parseRouteLensConfig(raw, address string) (routelensProbeConfig, error) {
+	if raw == "" {
+		return routelensProbeConfig{}, fmt.Errorf("peer token is required")
 	}
-	return cfg.Port, nil
+	var cfg routelensProbeConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return routelensProbeConfig{}, err
+	}
+	if cfg.Token == "" {
+		return routelensProbeConfig{}, fmt.Errorf("peer token is required")
+	}
+	if cfg.URL == "" {
+		cfg.URL = fmt.Sprintf("http://%s", net.JoinHostPort(address, "8080"))
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return routelensProbeConfig{}, fmt.Errorf("peer url must start with http:// or https://")
+	}
+	return cfg, nil
+}
+
+// librespeedProbeConfig targets a LibreSpeed-compatible backend
+type librespeedProbeConfig struct {
+	URL         string `json:"url"` // Backend base URL (the directory containing backend/)
+	Streams     int    `json:"streams"`
+	DurationSec int    `json:"duration"`
+}
+
+func parseLibreSpeedConfig(raw, address string) (librespeedProbeConfig, error) {
+	var cfg librespeedProbeConfig
+	if raw != "" {
+		if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+			return librespeedProbeConfig{}, err
+		}
+	}
+	if cfg.URL == "" {
+		cfg.URL = fmt.Sprintf("http://%s/", address)
+	}
+	if !strings.HasPrefix(cfg.URL, "http://") && !strings.HasPrefix(cfg.URL, "https://") {
+		return librespeedProbeConfig{}, fmt.Errorf("librespeed url must start with http:// or https://")
+	}
+	return cfg, nil
+}
+
+// speedDetails is the SpeedJson payload stored alongside up/down throughput
+type speedDetails struct {
+	LatencyMs float64           `json:"latency_ms,omitempty"`
+	JitterMs  float64           `json:"jitter_ms,omitempty"`
+	Upload    *transferDetails  `json:"upload,omitempty"`
+	Download  *transferDetails  `json:"download,omitempty"`
+	JumpHosts []jumpHostDetails `json:"jump_hosts,omitempty"`
+	// Latency under load, when the speed probe samples it
+	Bufferbloat *bufferbloatDetails `json:"bufferbloat,omitempty"`
+}
+
+// jumpHostDetails is the stored form of prober.JumpHostStats
+type jumpHostDetails struct {
+	Host      string  `json:"host"`
+	ConnectMs float64 `json:"connect_ms"`
+	RttMs     float64 `json:"rtt_ms"`
+}
+
+// transferDetails is the stored form of prober.TransferStats
+type transferDetails struct {
+	Protocol    string          `json:"protocol,omitempty"`
+	Bytes       int64           `json:"bytes"`
+	DurationMs  float64         `json:"duration_ms"`
+	Mbps        float64         `json:"mbps"`
+	Retransmits *int64          `json:"retransmits,omitempty"`
+	JitterMs    float64         `json:"jitter_ms,omitempty"`
+	Packets     int64           `json:"packets,omitempty"`
+	LostPackets int64           `json:"lost_packets,omitempty"`
+	LossPercent float64         `json:"loss_percent,omitempty"`
+	Congestion  string          `json:"congestion,omitempty"`
+	Streams     []streamDetails `json:"streams,omitempty"`
+}
+
+type streamDetails struct {
+	Index int     `json:"index"`
+	Bytes int64   `json:"bytes"`
+	Mbps  float64 `json:"mbps"`
+}
+
+func newTransferDetails(t *prober.TransferStats) *transferDetails {
+	if t == nil {
+		return nil
+	}
+	d := &transferDetails{
+		Protocol:    t.Protocol,
+		Bytes:       t.Bytes,
+		DurationMs:  durationMs(t.Duration),
+		Mbps:        t.Mbps(),
+		JitterMs:    durationMs(t.Jitter),
+		Packets:     t.Packets,
+		LostPackets: t.LostPackets,
+		LossPercent: t.LossPercent,
+		Congestion:  t.Congestion,
+	}
+	if t.Retransmits >= 0 {
+		retrans := t.Retransmits
+		d.Retransmits = &retrans
+	}
+	// A single stream carries everything; the breakdown adds nothing
+	if len(t.Streams) > 1 {
+		for _, st := range t.Streams {
+			d.Streams = append(d.Streams, streamDetails{Index: st.Index, Bytes: st.Bytes, Mbps: st.Mbps})
+		}
+	}
+	return d
+}
+
+func serializeSpeedDetails(res *prober.SpeedResult) []byte {
+	details := speedDetails{
+		LatencyMs: durationMs(res.Latency),
+		JitterMs:  durationMs(res.Jitter),
+		Upload:    newTransferDetails(res.Upload),
+		Download:  newTransferDetails(res.Download),
+
+		Bufferbloat: newBufferbloatDetails(res.Bufferbloat),
+	}
+	for _, j := range res.JumpHosts {
+		details.JumpHosts = append(details.JumpHosts, jumpHostDetails{
+			Host:      j.Host,
+			ConnectMs: durationMs(j.ConnectTime),
+			RttMs:     durationMs(j.RTT),
+		})
+	}
+	if details.LatencyMs == 0 && details.JitterMs == 0 && details.Upload == nil && details.Download == nil && details.JumpHosts == nil &&
+		details.Bufferbloat == nil {
+		return nil
+	}
+	bytes, err := json.Marshal(details)
+	if err != nil {
+		return nil
+	}
+	return bytes
+}
+
+// durationMs converts a duration to fractional milliseconds
+func durationMs(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000.0
 }
 
 type traceHop struct {
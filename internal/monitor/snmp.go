@@ -0,0 +1,118 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"github.com/yuanweize/RouteLens/pkg/prober"
+	"github.com/yuanweize/RouteLens/pkg/storage"
+)
+
+// snmpProbeConfig is Target.SNMPConfig
+type snmpProbeConfig struct {
+	Host       string   `json:"host"` // Empty = target address
+	Port       int      `json:"port"`
+	Version    string   `json:"version"` // "2c" (default) or "3"
+	Community  string   `json:"community"`
+	Interfaces []string `json:"interfaces"` // ifIndex, ifName or ifDescr
+	TimeoutSec int      `json:"timeout"`
+	// v3 USM
+	User         string `json:"user"`
+	AuthProtocol string `json:"auth_protocol"`
+	AuthPassword string `json:"auth_password"`
+	PrivProtocol string `json:"priv_protocol"`
+	PrivPassword string `json:"priv_password"`
+}
+
+func parseSNMPConfig(raw string) (prober.SNMPConfig, []string, error) {
+	var cfg snmpProbeConfig
+	if err := json.Unmarshal([]byte(raw), &cfg); err != nil {
+		return prober.SNMPConfig{}, nil, err
+	}
+	if len(cfg.Interfaces) == 0 {
+		return prober.SNMPConfig{}, nil, fmt.Errorf("at least one interface is required")
+	}
+	if cfg.TimeoutSec < 0 || cfg.TimeoutSec > 30 {
+		return prober.SNMPConfig{}, nil, fmt.Errorf("timeout must be between 0 and 30 seconds")
+	}
+	snmpCfg := prober.SNMPConfig{
+		Host:         cfg.Host,
+		Port:         cfg.Port,
+		Version:      cfg.Version,
+		Community:    cfg.Community,
+		Timeout:      time.Duration(cfg.TimeoutSec) * time.Second,
+		User:         cfg.User,
+		AuthProtocol: cfg.AuthProtocol,
+		AuthPassword: cfg.AuthPassword,
+		PrivProtocol: cfg.PrivProtocol,
+		PrivPassword: cfg.PrivPassword,
+	}
+	if err := prober.ValidateSNMPConfig(snmpCfg); err != nil {
+		return prober.SNMPConfig{}, nil, err
+	}
+	return snmpCfg, cfg.Interfaces, nil
+}
+
+// runSNMPForTarget polls interface counters and stores rates against the
+// previous poll. The first poll after startup only primes the counters.
+func (s *Service) runSNMPForTarget(t storage.Target) {
+	cfg, interfaces, err := parseSNMPConfig(t.SNMPConfig)
+	if err != nil {
+		s.db.UpdateTargetSNMPError(t.Address, fmt.Sprintf("SNMP config error: %v", err))
+		return
+	}
+	if cfg.Host == "" {
+		cfg.Host = t.Address
+	}
+
+	counters, err := prober.NewSNMPPoller(cfg).PollInterfaces(interfaces)
+	if err != nil {
+		logging.Warn("monitor", "[SNMP] Poll failed for %s: %v", t.Name, err)
+		s.db.UpdateTargetSNMPError(t.Address, fmt.Sprintf("SNMP: %v", err))
+		return
+	}
+	if t.SNMPError != "" {
+		s.db.UpdateTargetSNMPError(t.Address, "")
+	}
+
+	var records []storage.InterfaceRecord
+	s.snmpMu.Lock()
+	for _, cur := range counters {
+		key := t.Address + "|" + cur.Name
+		prev, seen := s.snmpLast[key]
+		s.snmpLast[key] = cur
+		if !seen {
+			continue
+		}
+		r, ok := prober.CounterRates(prev, cur)
+		if !ok {
+			logging.Info("monitor", "[SNMP] Counters on %s %s went backwards, skipping interval", t.Name, cur.Name)
+			continue
+		}
+		records = append(records, storage.InterfaceRecord{
+			CreatedAt:   cur.Time,
+			Target:      t.Address,
+			Interface:   cur.Name,
+			IfIndex:     cur.Index,
+			InBps:       r.InBps,
+			OutBps:      r.OutBps,
+			InUtil:      r.InUtil,
+			OutUtil:     r.OutUtil,
+			InErrors:    r.InErrors,
+			OutErrors:   r.OutErrors,
+			InDiscards:  r.InDiscards,
+			OutDiscards: r.OutDiscards,
+		})
+	}
+	s.snmpMu.Unlock()
+
+	if err := s.db.SaveInterfaceRecords(records); err != nil {
+		logging.Error("monitor", "Failed to save interface records for %s: %v", t.Name, err)
+		return
+	}
+	for _, r := range records {
+		logging.Debug("monitor", "[SNMP] %s %s: in %.1f Mbps, out %.1f Mbps", t.Name, r.Interface, r.InBps/1e6, r.OutBps/1e6)
+	}
+}
@@ -0,0 +1,254 @@
+// Package notify delivers alert messages over webhooks, email, Telegram
+// compatible bots and ntfy/Gotify push servers.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+)
+
+// Channel types
+const (
+	TypeWebhook  = "webhook"
+	TypeSMTP     = "smtp"
+	TypeTelegram = "telegram"
+	TypeNtfy     = "ntfy"
+	TypeGotify   = "gotify"
+)
+
+// Message is one notification. Its fields are what templates can use, e.g.
+// {{.Title}} or {{json .Body}} inside a JSON webhook body.
+type Message struct {
+	Title      string    `json:"title"`
+	Body       string    `json:"body"`
+	State      string    `json:"state"` // firing, resolved or test
+	Rule       string    `json:"rule"`
+	Target     string    `json:"target"` // Address
+	TargetName string    `json:"target_name"`
+	Metric     string    `json:"metric"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	Time       time.Time `json:"time"`
+}
+
+// Sender delivers messages over one configured channel
+type Sender interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// SecretFields lists the config keys of a channel type that must be stored
+// encrypted and never returned by the API
+func SecretFields(kind string) []string {
+	switch kind {
+	case TypeWebhook:
+		return []string{"headers"}
+	case TypeSMTP:
+		return []string{"password"}
+	case TypeTelegram:
+		return []string{"token"}
+	case TypeNtfy:
+		return []string{"token", "password"}
+	case TypeGotify:
+		return []string{"token"}
+	}
+	return nil
+}
+
+// New builds a Sender from a channel's JSON config and its secrets, which
+// are merged back in under the SecretFields keys
+func New(kind, config string, secrets map[string]string) (Sender, error) {
+	fields := make(map[string]interface{})
+	if config != "" {
+		if err := json.Unmarshal([]byte(config), &fields); err != nil {
+			return nil, fmt.Errorf("invalid channel config: %w", err)
+		}
+	}
+	for k, v := range secrets {
+		if v == "" {
+			continue
+		}
+		// Webhook headers are a JSON object kept whole as one secret
+		var obj map[string]string
+		if k == "headers" && json.Unmarshal([]byte(v), &obj) == nil {
+			fields[k] = obj
+		} else {
+			fields[k] = v
+		}
+	}
+	merged, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+
+	switch kind {
+	case TypeWebhook:
+		var cfg WebhookConfig
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return nil, err
+		}
+		return NewWebhook(cfg)
+	case TypeSMTP:
+		var cfg SMTPConfig
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return nil, err
+		}
+		return NewSMTP(cfg)
+	case TypeTelegram:
+		var cfg TelegramConfig
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return nil, err
+		}
+		return NewTelegram(cfg)
+	case TypeNtfy:
+		var cfg NtfyConfig
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return nil, err
+		}
+		return NewNtfy(cfg)
+	case TypeGotify:
+		var cfg GotifyConfig
+		if err := json.Unmarshal(merged, &cfg); err != nil {
+			return nil, err
+		}
+		return NewGotify(cfg)
+	}
+	return nil, fmt.Errorf("unknown channel type %q", kind)
+}
+
+// PermanentError is a failure retrying won't fix, e.g. a rejected token
+type PermanentError struct {
+	Err error
+}
+
+func (e *PermanentError) Error() string { return e.Err.Error() }
+func (e *PermanentError) Unwrap() error { return e.Err }
+
+// SendWithRetry tries up to attempts times, doubling the wait from backoff
+// after each failure. Permanent errors and a done context stop early.
+func SendWithRetry(ctx context.Context, s Sender, msg *Message, attempts int, backoff time.Duration) error {
+	var err error
+	for i := 0; i < max(1, attempts); i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("%w (last error: %v)", ctx.Err(), err)
+			case <-time.After(backoff << (i - 1)):
+			}
+		}
+		if err = s.Send(ctx, msg); err == nil {
+			return nil
+		}
+		var perm *PermanentError
+		if errors.As(err, &perm) {
+			return err
+		}
+	}
+	return err
+}
+
+// RateLimiter allows a number of deliveries per key in a sliding window
+type RateLimiter struct {
+	mu     sync.Mutex
+	sent   map[uint][]time.Time
+	window time.Duration
+}
+
+func NewRateLimiter(window time.Duration) *RateLimiter {
+	return &RateLimiter{sent: make(map[uint][]time.Time), window: window}
+}
+
+// Allow records a delivery for key unless limit were exceeded; limit <= 0
+// means unlimited
+func (rl *RateLimiter) Allow(key uint, limit int) bool {
+	if limit <= 0 {
+		return true
+	}
+	rl.mu.Lock()
+	defer rl.mu.Unlock()
+	now := time.Now()
+	recent := rl.sent[key][:0]
+	for _, t := range rl.sent[key] {
+		if now.Sub(t) < rl.window {
+			recent = append(recent, t)
+		}
+	}
+	if len(recent) >= limit {
+		rl.sent[key] = recent
+		return false
+	}
+	rl.sent[key] = append(recent, now)
+	return true
+}
+
+// --- Helpers shared by the senders ---
+
+var templateFuncs = template.FuncMap{
+	// json quotes a value for use inside a JSON template
+	"json": func(v interface{}) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+	"upper": strings.ToUpper,
+}
+
+// parseTemplate compiles an optional template; empty text returns nil
+func parseTemplate(name, text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+	tmpl, err := template.New(name).Funcs(templateFuncs).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s template: %w", name, err)
+	}
+	return tmpl, nil
+}
+
+// render executes tmpl, or returns fallback without one
+func render(tmpl *template.Template, msg *Message, fallback string) (string, error) {
+	if tmpl == nil {
+		return fallback, nil
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, msg); err != nil {
+		return "", &PermanentError{fmt.Errorf("template: %w", err)}
+	}
+	return buf.String(), nil
+}
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// doHTTP sends a request and fails on any non-2xx status. Client errors other
+// than 408 and 429 are permanent: resending the same request won't help.
+// service names the server in errors, since URLs may carry tokens.
+func doHTTP(req *http.Request, service string) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		// url.Error quotes the full URL; keep only the cause
+		var uerr *url.Error
+		if errors.As(err, &uerr) {
+			err = uerr.Err
+		}
+		return nil, fmt.Errorf("%s: %w", service, err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return body, nil
+	}
+	err = fmt.Errorf("%s returned %s: %s", service, resp.Status, strings.TrimSpace(string(body)))
+	if resp.StatusCode >= 400 && resp.StatusCode < 500 &&
+		resp.StatusCode != http.StatusRequestTimeout && resp.StatusCode != http.StatusTooManyRequests {
+		return nil, &PermanentError{err}
+	}
+	return nil, err
+}
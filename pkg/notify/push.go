@@ -0,0 +1,194 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// --- Telegram ---
+
+const defaultTelegramAPI = "https://api.telegram.org"
+
+// TelegramConfig sends through the Bot API sendMessage method. APIURL can
+// point at a self-hosted Bot API server or any compatible gateway.
+type TelegramConfig struct {
+	APIURL    string `json:"api_url"`
+	Token     string `json:"token"` // Stored encrypted
+	ChatID    string `json:"chat_id"`
+	ParseMode string `json:"parse_mode"` // Optional: HTML, Markdown or MarkdownV2
+	Template  string `json:"template"`   // Default: title and body on separate lines
+}
+
+type Telegram struct {
+	cfg  TelegramConfig
+	text *template.Template
+}
+
+func NewTelegram(cfg TelegramConfig) (*Telegram, error) {
+	if cfg.APIURL == "" {
+		cfg.APIURL = defaultTelegramAPI
+	}
+	if err := validateHTTPURL(cfg.APIURL); err != nil {
+		return nil, err
+	}
+	if cfg.Token == "" || cfg.ChatID == "" {
+		return nil, fmt.Errorf("telegram token and chat id are required")
+	}
+	tmpl, err := parseTemplate("template", cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &Telegram{cfg: cfg, text: tmpl}, nil
+}
+
+func (t *Telegram) Send(ctx context.Context, msg *Message) error {
+	text, err := render(t.text, msg, msg.Title+"\n"+msg.Body)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{
+		"chat_id":                  t.cfg.ChatID,
+		"text":                     text,
+		"disable_web_page_preview": true,
+	}
+	if t.cfg.ParseMode != "" {
+		payload["parse_mode"] = t.cfg.ParseMode
+	}
+	body, _ := json.Marshal(payload)
+	url := strings.TrimRight(t.cfg.APIURL, "/") + "/bot" + t.cfg.Token + "/sendMessage"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{fmt.Errorf("telegram: invalid api url")}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	// The token is part of the URL, so errors only name the host
+	resp, err := doHTTP(req, "telegram "+req.URL.Host)
+	if err != nil {
+		return err
+	}
+	var result struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if json.Unmarshal(resp, &result) == nil && !result.OK {
+		return &PermanentError{fmt.Errorf("telegram: %s", result.Description)}
+	}
+	return nil
+}
+
+// --- ntfy ---
+
+// NtfyConfig publishes to a topic URL such as https://ntfy.sh/my-alerts
+type NtfyConfig struct {
+	URL      string `json:"url"`
+	Token    string `json:"token"`    // Access token; stored encrypted
+	Username string `json:"username"` // Basic auth, instead of a token
+	Password string `json:"password"` // Stored encrypted
+	Priority int    `json:"priority"` // 1-5, 0 = server default
+	Template string `json:"template"` // Default {{.Body}}
+}
+
+type Ntfy struct {
+	cfg  NtfyConfig
+	body *template.Template
+}
+
+func NewNtfy(cfg NtfyConfig) (*Ntfy, error) {
+	if err := validateHTTPURL(cfg.URL); err != nil {
+		return nil, err
+	}
+	if cfg.Priority < 0 || cfg.Priority > 5 {
+		return nil, fmt.Errorf("ntfy priority must be between 1 and 5")
+	}
+	tmpl, err := parseTemplate("template", cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &Ntfy{cfg: cfg, body: tmpl}, nil
+}
+
+func (n *Ntfy) Send(ctx context.Context, msg *Message) error {
+	body, err := render(n.body, msg, msg.Body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, strings.NewReader(body))
+	if err != nil {
+		return &PermanentError{err}
+	}
+	// Header values must be ASCII-safe; ntfy decodes RFC 2047 titles
+	req.Header.Set("Title", mime.QEncoding.Encode("utf-8", msg.Title))
+	if n.cfg.Priority > 0 {
+		req.Header.Set("Priority", strconv.Itoa(n.cfg.Priority))
+	}
+	switch msg.State {
+	case "firing":
+		req.Header.Set("Tags", "rotating_light")
+	case "resolved":
+		req.Header.Set("Tags", "white_check_mark")
+	}
+	switch {
+	case n.cfg.Token != "":
+		req.Header.Set("Authorization", "Bearer "+n.cfg.Token)
+	case n.cfg.Username != "":
+		req.SetBasicAuth(n.cfg.Username, n.cfg.Password)
+	}
+	_, err = doHTTP(req, "ntfy "+req.URL.Host)
+	return err
+}
+
+// --- Gotify ---
+
+// GotifyConfig posts to a Gotify server with an application token
+type GotifyConfig struct {
+	URL      string `json:"url"`   // Server base URL
+	Token    string `json:"token"` // Application token; stored encrypted
+	Priority int    `json:"priority"`
+	Template string `json:"template"` // Default {{.Body}}
+}
+
+type Gotify struct {
+	cfg  GotifyConfig
+	body *template.Template
+}
+
+func NewGotify(cfg GotifyConfig) (*Gotify, error) {
+	if err := validateHTTPURL(cfg.URL); err != nil {
+		return nil, err
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("gotify application token is required")
+	}
+	tmpl, err := parseTemplate("template", cfg.Template)
+	if err != nil {
+		return nil, err
+	}
+	return &Gotify{cfg: cfg, body: tmpl}, nil
+}
+
+func (g *Gotify) Send(ctx context.Context, msg *Message) error {
+	text, err := render(g.body, msg, msg.Body)
+	if err != nil {
+		return err
+	}
+	payload := map[string]interface{}{"title": msg.Title, "message": text}
+	if g.cfg.Priority > 0 {
+		payload["priority"] = g.cfg.Priority
+	}
+	body, _ := json.Marshal(payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimRight(g.cfg.URL, "/")+"/message", bytes.NewReader(body))
+	if err != nil {
+		return &PermanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Gotify-Key", g.cfg.Token)
+	_, err = doHTTP(req, "gotify "+req.URL.Host)
+	return err
+}
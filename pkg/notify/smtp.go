@@ -0,0 +1,189 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// SMTP connection security
+const (
+	SMTPStartTLS = "starttls" // Upgrade a plain connection, usually port 587 (default)
+	SMTPTLS      = "tls"      // Implicit TLS, usually port 465
+	SMTPNone     = "none"     // Plain text, for local relays only
+)
+
+type SMTPConfig struct {
+	Host            string   `json:"host"`
+	Port            int      `json:"port"` // Default 587, or 465 with tls
+	Security        string   `json:"security"`
+	Username        string   `json:"username"` // Empty = no AUTH
+	Password        string   `json:"password"` // Stored encrypted
+	From            string   `json:"from"`
+	To              []string `json:"to"`
+	SubjectTemplate string   `json:"subject_template"` // Default {{.Title}}
+	BodyTemplate    string   `json:"body_template"`    // Default {{.Body}}
+}
+
+type SMTP struct {
+	cfg     SMTPConfig
+	subject *template.Template
+	body    *template.Template
+}
+
+func NewSMTP(cfg SMTPConfig) (*SMTP, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("smtp host is required")
+	}
+	switch cfg.Security {
+	case "":
+		cfg.Security = SMTPStartTLS
+	case SMTPStartTLS, SMTPTLS, SMTPNone:
+	default:
+		return nil, fmt.Errorf("smtp security must be starttls, tls or none")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+		if cfg.Security == SMTPTLS {
+			cfg.Port = 465
+		}
+	}
+	if _, err := mail.ParseAddress(cfg.From); err != nil {
+		return nil, fmt.Errorf("invalid from address: %w", err)
+	}
+	if len(cfg.To) == 0 {
+		return nil, fmt.Errorf("at least one recipient is required")
+	}
+	for _, to := range cfg.To {
+		if _, err := mail.ParseAddress(to); err != nil {
+			return nil, fmt.Errorf("invalid recipient %q: %w", to, err)
+		}
+	}
+	subject, err := parseTemplate("subject", cfg.SubjectTemplate)
+	if err != nil {
+		return nil, err
+	}
+	body, err := parseTemplate("body", cfg.BodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &SMTP{cfg: cfg, subject: subject, body: body}, nil
+}
+
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	subject, err := render(s.subject, msg, msg.Title)
+	if err != nil {
+		return err
+	}
+	body, err := render(s.body, msg, msg.Body)
+	if err != nil {
+		return err
+	}
+	data, err := s.compose(strings.TrimSpace(subject), body)
+	if err != nil {
+		return err
+	}
+
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	var conn net.Conn
+	if s.cfg.Security == SMTPTLS {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: s.cfg.Host}}).DialContext(ctx, "tcp", addr)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	conn.SetDeadline(deadline)
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp %s: %w", addr, err)
+	}
+	defer c.Close()
+	if s.cfg.Security == SMTPStartTLS {
+		if ok, _ := c.Extension("STARTTLS"); !ok {
+			return &PermanentError{fmt.Errorf("smtp %s does not offer STARTTLS", addr)}
+		}
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return fmt.Errorf("smtp starttls: %w", err)
+		}
+	}
+	if s.cfg.Username != "" {
+		// PlainAuth itself refuses to send the password unencrypted except to localhost
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return &PermanentError{fmt.Errorf("smtp auth: %w", err)}
+		}
+	}
+
+	from, _ := mail.ParseAddress(s.cfg.From)
+	if err := c.Mail(from.Address); err != nil {
+		return smtpError("MAIL FROM", err)
+	}
+	for _, to := range s.cfg.To {
+		rcpt, _ := mail.ParseAddress(to)
+		if err := c.Rcpt(rcpt.Address); err != nil {
+			return smtpError("RCPT TO "+rcpt.Address, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return smtpError("DATA", err)
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return smtpError("DATA", err)
+	}
+	return c.Quit()
+}
+
+func (s *SMTP) compose(subject, body string) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(k, v string) { fmt.Fprintf(&buf, "%s: %s\r\n", k, v) }
+	header("From", s.cfg.From)
+	header("To", strings.Join(s.cfg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", subject))
+	header("Date", time.Now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	header("Content-Type", "text/plain; charset=utf-8")
+	header("Content-Transfer-Encoding", "quoted-printable")
+	buf.WriteString("\r\n")
+	qp := quotedprintable.NewWriter(&buf)
+	if _, err := qp.Write([]byte(strings.ReplaceAll(body, "\n", "\r\n"))); err != nil {
+		return nil, err
+	}
+	if err := qp.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// smtpError marks 5xx replies permanent; 4xx are the server asking us to retry
+func smtpError(stage string, err error) error {
+	var reply *textproto.Error
+	wrapped := fmt.Errorf("smtp %s: %w", stage, err)
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return &PermanentError{wrapped}
+	}
+	return wrapped
+}
@@ -0,0 +1,77 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"text/template"
+)
+
+// WebhookConfig posts each message to a URL. Without a body template the
+// body is the Message as JSON.
+type WebhookConfig struct {
+	URL          string            `json:"url"`
+	Method       string            `json:"method"`       // Default POST
+	ContentType  string            `json:"content_type"` // Default application/json
+	BodyTemplate string            `json:"body_template"`
+	Headers      map[string]string `json:"headers"` // e.g. Authorization; stored encrypted
+}
+
+type Webhook struct {
+	cfg  WebhookConfig
+	body *template.Template // Nil = Message as JSON
+}
+
+func NewWebhook(cfg WebhookConfig) (*Webhook, error) {
+	if err := validateHTTPURL(cfg.URL); err != nil {
+		return nil, err
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodPost
+	}
+	cfg.Method = strings.ToUpper(cfg.Method)
+	if cfg.Method != http.MethodPost && cfg.Method != http.MethodPut {
+		return nil, fmt.Errorf("webhook method must be POST or PUT")
+	}
+	if cfg.ContentType == "" {
+		cfg.ContentType = "application/json"
+	}
+	tmpl, err := parseTemplate("body", cfg.BodyTemplate)
+	if err != nil {
+		return nil, err
+	}
+	return &Webhook{cfg: cfg, body: tmpl}, nil
+}
+
+func (w *Webhook) Send(ctx context.Context, msg *Message) error {
+	def, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	body, err := render(w.body, msg, string(def))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, w.cfg.Method, w.cfg.URL, strings.NewReader(body))
+	if err != nil {
+		return &PermanentError{err}
+	}
+	req.Header.Set("Content-Type", w.cfg.ContentType)
+	req.Header.Set("User-Agent", "RouteLens")
+	for k, v := range w.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+	_, err = doHTTP(req, "webhook "+req.URL.Host)
+	return err
+}
+
+func validateHTTPURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("url must be an http(s) URL")
+	}
+	return nil
+}
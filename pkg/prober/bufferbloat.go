@@ -0,0 +1,318 @@
+package prober
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// Latency sampling methods for bufferbloat tests
+const (
+	BloatMethodICMP = "icmp"
+	BloatMethodTCP  = "tcp" // TCP connect time, for hosts or setups without ICMP
+)
+
+// BufferbloatIdleWindow is how long the sampler runs before the transfer
+// starts to establish the idle baseline
+const BufferbloatIdleWindow = 3 * time.Second
+
+// LatencySample is one RTT measurement; Lost samples timed out
+type LatencySample struct {
+	Time time.Time
+	RTT  time.Duration
+	Lost bool
+}
+
+// BufferbloatResult compares latency while idle and while each direction of
+// a speed test saturated the path. Latencies are medians.
+type BufferbloatResult struct {
+	Method       string
+	Idle         time.Duration
+	LoadedDown   time.Duration
+	LoadedUp     time.Duration
+	DownIncrease time.Duration
+	UpIncrease   time.Duration
+	IdleSamples  int
+	DownSamples  int
+	UpSamples    int
+	LoadedLoss   float64 // Percent of samples lost during transfers
+	Grade        string  // A+ to F
+}
+
+// bloatSamplers hands each ICMP sampler its own echo ID, so samplers for
+// different targets running side by side never match each other's replies
+var bloatSamplers atomic.Uint32
+
+// bloatEchoID returns the next sampler ID, skipping the one ICMPPinger and
+// the traceroute use
+func bloatEchoID() int {
+	pingID := os.Getpid() & 0xffff
+	for {
+		id := (pingID + int(bloatSamplers.Add(1))) & 0xffff
+		if id != pingID {
+			return id
+		}
+	}
+}
+
+// LatencySampler measures RTT to a host at a fixed rate in the background
+type LatencySampler struct {
+	Target   string
+	Method   string
+	Port     int // TCP method only
+	Interval time.Duration
+	Timeout  time.Duration
+
+	mu       sync.Mutex
+	samples  []LatencySample
+	stop     chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+func NewLatencySampler(target, method string, port int) *LatencySampler {
+	if method == "" {
+		method = BloatMethodICMP
+	}
+	return &LatencySampler{
+		Target:   target,
+		Method:   method,
+		Port:     port,
+		Interval: 100 * time.Millisecond,
+		Timeout:  time.Second,
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+}
+
+// Start begins sampling. Setup errors (no ICMP permission, bad method) are
+// returned right away rather than producing an all-lost series.
+func (s *LatencySampler) Start() error {
+	var measure func(seq int) (time.Duration, error)
+	var cleanup func()
+	switch s.Method {
+	case BloatMethodICMP:
+		m, closeFn, err := s.icmpMeasure()
+		if err != nil {
+			return err
+		}
+		measure, cleanup = m, closeFn
+	case BloatMethodTCP:
+		if s.Port <= 0 {
+			return fmt.Errorf("tcp latency sampling needs a port")
+		}
+		addr := net.JoinHostPort(s.Target, fmt.Sprint(s.Port))
+		measure = func(int) (time.Duration, error) {
+			start := time.Now()
+			conn, err := net.DialTimeout("tcp", addr, s.Timeout)
+			if err != nil {
+				return 0, err
+			}
+			rtt := time.Since(start)
+			conn.Close()
+			return rtt, nil
+		}
+		cleanup = func() {}
+	default:
+		return fmt.Errorf("unknown latency sampling method %q", s.Method)
+	}
+
+	go func() {
+		defer close(s.done)
+		defer cleanup()
+		ticker := time.NewTicker(s.Interval)
+		defer ticker.Stop()
+		for seq := 1; ; seq++ {
+			now := time.Now()
+			rtt, err := measure(seq)
+			s.mu.Lock()
+			s.samples = append(s.samples, LatencySample{Time: now, RTT: rtt, Lost: err != nil})
+			s.mu.Unlock()
+			select {
+			case <-s.stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop ends sampling and returns all samples. Safe to call more than once.
+func (s *LatencySampler) Stop() []LatencySample {
+	s.stopOnce.Do(func() { close(s.stop) })
+	select {
+	case <-s.done:
+	case <-time.After(s.Timeout + time.Second):
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]LatencySample(nil), s.samples...)
+}
+
+func (s *LatencySampler) icmpMeasure() (func(int) (time.Duration, error), func(), error) {
+	dst, err := net.ResolveIPAddr("ip4", s.Target)
+	if err != nil {
+		return nil, nil, err
+	}
+	privileged := os.Geteuid() == 0
+	network := "udp4"
+	var to net.Addr = &net.UDPAddr{IP: dst.IP, Zone: dst.Zone}
+	if privileged {
+		network = "ip4:icmp"
+		to = dst
+	}
+	c, err := icmp.ListenPacket(network, "0.0.0.0")
+	if err != nil {
+		return nil, nil, fmt.Errorf("listen packet failed (privileged=%v): %w", privileged, err)
+	}
+	// Raw sockets see every echo reply on the host, so both the ID and the
+	// source must match; unprivileged sockets get a kernel-assigned ID anyway
+	id := bloatEchoID()
+
+	measure := func(seq int) (time.Duration, error) {
+		wm := icmp.Message{
+			Type: ipv4.ICMPTypeEcho,
+			Body: &icmp.Echo{ID: id, Seq: seq & 0xffff, Data: []byte("RouteLens-Bloat")},
+		}
+		wb, err := wm.Marshal(nil)
+		if err != nil {
+			return 0, err
+		}
+		start := time.Now()
+		if _, err := c.WriteTo(wb, to); err != nil {
+			return 0, err
+		}
+		c.SetReadDeadline(start.Add(s.Timeout))
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := c.ReadFrom(buf)
+			if err != nil {
+				return 0, err
+			}
+			if !fromHost(from, dst.IP) {
+				continue
+			}
+			rm, err := icmp.ParseMessage(ipv4.ICMPTypeEchoReply.Protocol(), buf[:n])
+			if err != nil || rm.Type != ipv4.ICMPTypeEchoReply {
+				continue
+			}
+			echo, ok := rm.Body.(*icmp.Echo)
+			if !ok || echo.Seq != seq&0xffff || (privileged && echo.ID != id) {
+				continue
+			}
+			return time.Since(start), nil
+		}
+	}
+	return measure, func() { c.Close() }, nil
+}
+
+// fromHost reports whether a reply came from ip. Raw sockets report an
+// *net.IPAddr, unprivileged ICMP sockets a *net.UDPAddr.
+func fromHost(addr net.Addr, ip net.IP) bool {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.Equal(ip)
+	case *net.UDPAddr:
+		return a.IP.Equal(ip)
+	}
+	return false
+}
+
+// AnalyzeBufferbloat splits samples into idle (before the first transfer)
+// and loaded per direction, and grades the worst latency increase. Returns
+// nil without an idle baseline or any loaded samples.
+func AnalyzeBufferbloat(method string, samples []LatencySample, down, up Phase) *BufferbloatResult {
+	first := down.Start
+	if first.IsZero() || (!up.Start.IsZero() && up.Start.Before(first)) {
+		first = up.Start
+	}
+	if first.IsZero() {
+		return nil
+	}
+
+	var idle, loadedDown, loadedUp []time.Duration
+	loaded, lost := 0, 0
+	for _, smp := range samples {
+		inDown, inUp := down.Contains(smp.Time), up.Contains(smp.Time)
+		if inDown || inUp {
+			loaded++
+			if smp.Lost {
+				lost++
+				continue
+			}
+		}
+		switch {
+		case smp.Lost:
+		case smp.Time.Before(first):
+			idle = append(idle, smp.RTT)
+		case inDown:
+			loadedDown = append(loadedDown, smp.RTT)
+		case inUp:
+			loadedUp = append(loadedUp, smp.RTT)
+		}
+	}
+	if len(idle) == 0 || loaded == 0 {
+		return nil
+	}
+
+	res := &BufferbloatResult{
+		Method:      method,
+		Idle:        medianDuration(idle),
+		IdleSamples: len(idle),
+		DownSamples: len(loadedDown),
+		UpSamples:   len(loadedUp),
+		LoadedLoss:  float64(lost) / float64(loaded) * 100,
+	}
+	if len(loadedDown) > 0 {
+		res.LoadedDown = medianDuration(loadedDown)
+		res.DownIncrease = max(0, res.LoadedDown-res.Idle)
+	}
+	if len(loadedUp) > 0 {
+		res.LoadedUp = medianDuration(loadedUp)
+		res.UpIncrease = max(0, res.LoadedUp-res.Idle)
+	}
+	if len(loadedDown) == 0 && len(loadedUp) == 0 {
+		// Every probe under load timed out: as bad as it gets
+		res.Grade = "F"
+	} else {
+		res.Grade = BufferbloatGrade(max(res.DownIncrease, res.UpIncrease))
+	}
+	return res
+}
+
+// BufferbloatGrade maps the latency increase under load to a letter grade,
+// using the same thresholds as the common web bufferbloat tests
+func BufferbloatGrade(increase time.Duration) string {
+	switch {
+	case increase < 5*time.Millisecond:
+		return "A+"
+	case increase < 30*time.Millisecond:
+		return "A"
+	case increase < 60*time.Millisecond:
+		return "B"
+	case increase < 200*time.Millisecond:
+		return "C"
+	case increase < 400*time.Millisecond:
+		return "D"
+	default:
+		return "F"
+	}
+}
+
+func medianDuration(ds []time.Duration) time.Duration {
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
@@ -0,0 +1,269 @@
+package prober
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"golang.org/x/net/http2"
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// grpc.health.v1.HealthCheckResponse.ServingStatus
+const (
+	GRPCHealthUnknown        = "UNKNOWN"
+	GRPCHealthServing        = "SERVING"
+	GRPCHealthNotServing     = "NOT_SERVING"
+	GRPCHealthServiceUnknown = "SERVICE_UNKNOWN"
+)
+
+var grpcServingStatuses = []string{GRPCHealthUnknown, GRPCHealthServing, GRPCHealthNotServing, GRPCHealthServiceUnknown}
+
+// gRPC status code names, indexed by code
+var grpcCodeNames = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND",
+	"ALREADY_EXISTS", "PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION",
+	"ABORTED", "OUT_OF_RANGE", "UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS",
+	"UNAUTHENTICATED",
+}
+
+const DefaultGRPCTimeout = 5 * time.Second
+
+// GRPCStatusError is a failed RPC (non-zero grpc-status), e.g. UNIMPLEMENTED
+// when the server doesn't register the health service
+type GRPCStatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *GRPCStatusError) Error() string {
+	name := fmt.Sprintf("code %d", e.Code)
+	if e.Code >= 0 && e.Code < len(grpcCodeNames) {
+		name = grpcCodeNames[e.Code]
+	}
+	if e.Message == "" {
+		return "grpc status " + name
+	}
+	return fmt.Sprintf("grpc status %s: %s", name, e.Message)
+}
+
+type GRPCHealthConfig struct {
+	Address            string // host:port
+	Service            string // Empty = overall server health
+	TLS                bool
+	InsecureSkipVerify bool
+	ServerName         string // TLS SNI/verification name, defaults to the host
+	CAFile             string // PEM bundle for private CAs, defaults to system roots
+	Timeout            time.Duration
+}
+
+type GRPCHealthResult struct {
+	Status      string        // One of the GRPCHealth* constants
+	ConnectTime time.Duration // TCP (+ TLS) setup
+	RPCLatency  time.Duration // Check call on the established connection
+}
+
+// GRPCHealthProber calls grpc.health.v1.Health/Check. The protocol is small
+// enough to speak directly over HTTP/2, which avoids pulling in grpc-go.
+type GRPCHealthProber struct {
+	config GRPCHealthConfig
+}
+
+func NewGRPCHealthProber(cfg GRPCHealthConfig) *GRPCHealthProber {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultGRPCTimeout
+	}
+	return &GRPCHealthProber{config: cfg}
+}
+
+// Check performs one health check. Whatever status the server reports is
+// returned without error; err is for transport and RPC failures.
+func (p *GRPCHealthProber) Check() (*GRPCHealthResult, error) {
+	cfg := p.config
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Timeout)
+	defer cancel()
+
+	start := time.Now()
+	conn, err := p.dial(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	res := &GRPCHealthResult{ConnectTime: time.Since(start)}
+
+	tr := &http2.Transport{AllowHTTP: true}
+	cc, err := tr.NewClientConn(conn)
+	if err != nil {
+		return nil, fmt.Errorf("http2 setup failed: %w", err)
+	}
+	defer cc.Close()
+
+	scheme := "http"
+	if cfg.TLS {
+		scheme = "https"
+	}
+	u := &url.URL{Scheme: scheme, Host: cfg.Address, Path: "/grpc.health.v1.Health/Check"}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(grpcFrame(encodeHealthCheckRequest(cfg.Service))))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/grpc")
+	req.Header.Set("TE", "trailers")
+	req.Header.Set("User-Agent", "RouteLens")
+	req.Header.Set("grpc-timeout", fmt.Sprintf("%dm", cfg.Timeout.Milliseconds()))
+
+	rpcStart := time.Now()
+	resp, err := cc.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("health check rpc failed: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	res.RPCLatency = time.Since(rpcStart)
+	if err != nil {
+		return nil, fmt.Errorf("health check rpc failed: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("health check rpc failed: http status %d", resp.StatusCode)
+	}
+
+	// Trailers-only responses carry grpc-status in the headers
+	code := resp.Trailer.Get("grpc-status")
+	msg := resp.Trailer.Get("grpc-message")
+	if code == "" {
+		code = resp.Header.Get("grpc-status")
+		msg = resp.Header.Get("grpc-message")
+	}
+	if code != "0" {
+		var n int
+		if _, err := fmt.Sscanf(code, "%d", &n); err != nil {
+			return nil, fmt.Errorf("health check rpc failed: missing grpc-status")
+		}
+		// grpc-message is percent-encoded
+		if decoded, err := url.PathUnescape(msg); err == nil {
+			msg = decoded
+		}
+		return nil, &GRPCStatusError{Code: n, Message: msg}
+	}
+
+	res.Status, err = decodeHealthCheckResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	logging.Debug("grpc", "[gRPC] %s service=%q: %s (connect %s, rpc %s)",
+		cfg.Address, cfg.Service, res.Status, res.ConnectTime, res.RPCLatency)
+	return res, nil
+}
+
+func (p *GRPCHealthProber) dial(ctx context.Context) (net.Conn, error) {
+	cfg := p.config
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.TLS {
+		// Plaintext gRPC is HTTP/2 with prior knowledge (h2c)
+		return conn, nil
+	}
+
+	tlsCfg := &tls.Config{
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify,
+		NextProtos:         []string{"h2"},
+	}
+	if tlsCfg.ServerName == "" {
+		tlsCfg.ServerName, _, _ = net.SplitHostPort(cfg.Address)
+	}
+	if cfg.CAFile != "" {
+		pem, err := os.ReadFile(cfg.CAFile)
+		if err != nil {
+			conn.Close()
+			return nil, fmt.Errorf("read CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			conn.Close()
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CAFile)
+		}
+		tlsCfg.RootCAs = pool
+	}
+
+	tlsConn := tls.Client(conn, tlsCfg)
+	if err := tlsConn.HandshakeContext(ctx); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("tls handshake failed: %w", err)
+	}
+	if proto := tlsConn.ConnectionState().NegotiatedProtocol; proto != "h2" {
+		tlsConn.Close()
+		return nil, fmt.Errorf("server did not negotiate HTTP/2 (ALPN %q)", proto)
+	}
+	return tlsConn, nil
+}
+
+// grpcFrame adds the length-prefixed message header (uncompressed)
+func grpcFrame(msg []byte) []byte {
+	frame := make([]byte, 5, 5+len(msg))
+	binary.BigEndian.PutUint32(frame[1:], uint32(len(msg)))
+	return append(frame, msg...)
+}
+
+// encodeHealthCheckRequest marshals HealthCheckRequest{service = 1}
+func encodeHealthCheckRequest(service string) []byte {
+	if service == "" {
+		return nil
+	}
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	return protowire.AppendString(b, service)
+}
+
+// decodeHealthCheckResponse reads HealthCheckResponse{status = 1} from a framed body
+func decodeHealthCheckResponse(body []byte) (string, error) {
+	if len(body) < 5 {
+		return "", fmt.Errorf("health check response is empty")
+	}
+	if body[0] != 0 {
+		return "", fmt.Errorf("compressed health check response is not supported")
+	}
+	n := binary.BigEndian.Uint32(body[1:5])
+	if uint32(len(body)-5) < n {
+		return "", fmt.Errorf("health check response is truncated")
+	}
+	msg := body[5 : 5+n]
+
+	status := uint64(0) // proto3 default: UNKNOWN
+	for len(msg) > 0 {
+		num, typ, l := protowire.ConsumeTag(msg)
+		if l < 0 {
+			return "", fmt.Errorf("invalid health check response: %w", protowire.ParseError(l))
+		}
+		msg = msg[l:]
+		if num == 1 && typ == protowire.VarintType {
+			v, l := protowire.ConsumeVarint(msg)
+			if l < 0 {
+				return "", fmt.Errorf("invalid health check response: %w", protowire.ParseError(l))
+			}
+			status, msg = v, msg[l:]
+			continue
+		}
+		l = protowire.ConsumeFieldValue(num, typ, msg)
+		if l < 0 {
+			return "", fmt.Errorf("invalid health check response: %w", protowire.ParseError(l))
+		}
+		msg = msg[l:]
+	}
+	if status >= uint64(len(grpcServingStatuses)) {
+		return GRPCHealthUnknown, nil
+	}
+	return grpcServingStatuses[status], nil
+}
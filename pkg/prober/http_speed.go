@@ -49,5 +49,6 @@ func (h *HTTPSpeedTester) Run() (*SpeedResult, error) {
 		DownloadSpeed: speedMbps,
 		UploadSpeed:   0, // HTTP download doesn't measure upload speed easily
 		Timestamp:     time.Now(),
+		DownloadPhase: Phase{Start: start, End: start.Add(duration)},
 	}, nil
 }
@@ -1,62 +1,696 @@
 package prober
 
 import (
+	"crypto/rand"
+	"encoding/binary"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"os/exec"
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
+// iperf3 control protocol states (iperf_api.h)
+const (
+	iperfTestStart       = 1
+	iperfTestRunning     = 2
+	iperfTestEnd         = 4
+	iperfParamExchange   = 9
+	iperfCreateStreams   = 10
+	iperfServerTerminate = 11
+	iperfClientTerminate = 12
+	iperfExchangeResults = 13
+	iperfDisplayResults  = 14
+	iperfStart           = 15
+	iperfDone            = 16
+	iperfAccessDenied    = -1
+	iperfServerError     = -2
+)
+
+const (
+	iperfCookieSize      = 37 // 36 chars + NUL
+	iperfCookieAlphabet  = "abcdefghijklmnopqrstuvwxyz234567"
+	iperfDefaultTCPBlock = 128 * 1024
+	iperfDefaultUDPBlock = 1400
+	iperfDefaultUDPRate  = 1000000 // iperf3 default UDP bitrate: 1 Mbit/s per stream
+	iperfUDPConnectMsg   = 0x36373839
+	iperfUDPHeaderSize   = 12 // sec, usec, 32-bit packet count
+	iperfMaxJSONSize     = 1024 * 1024
+)
+
+// Largest -l values: a UDP datagram's payload limit, and iperf3's own cap
+const (
+	IperfMaxUDPBlock = 65507
+	IperfMaxTCPBlock = 1024 * 1024
+)
+
+// IperfOptions mirrors the iperf3 client flags RouteLens supports
+type IperfOptions struct {
+	Port       int
+	UDP        bool          // -u
+	Bitrate    int64         // -b, bits/sec per stream (0 = unlimited TCP, 1 Mbit/s UDP)
+	Reverse    bool          // -R, only measure server -> client
+	Parallel   int           // -P
+	Duration   time.Duration // -t
+	Congestion string        // -C, TCP congestion control algorithm (Linux)
+	BlockSize  int           // -l
+}
+
+// IperfProber is a pure-Go client for the iperf3 control protocol.
+// Unless Reverse is set, it measures upload (client -> server) and then
+// download (server -> client) against a stock iperf3 server.
 type IperfProber struct {
 	Target string
-	Port   int
+	Opts   IperfOptions
 }
 
-func NewIperfProber(target string, port int) *IperfProber {
-	if port == 0 {
-		port = 5201
+func NewIperfProber(target string, opts IperfOptions) *IperfProber {
+	if opts.Port == 0 {
+		opts.Port = 5201
+	}
+	if opts.Parallel <= 0 {
+		opts.Parallel = 1
 	}
-	return &IperfProber{Target: target, Port: port}
+	if opts.Duration <= 0 {
+		opts.Duration = 5 * time.Second
+	}
+	if opts.BlockSize <= 0 {
+		opts.BlockSize = iperfDefaultTCPBlock
+		if opts.UDP {
+			opts.BlockSize = iperfDefaultUDPBlock
+		}
+	}
+	if opts.UDP && opts.Bitrate <= 0 {
+		opts.Bitrate = iperfDefaultUDPRate
+	}
+	return &IperfProber{Target: target, Opts: opts}
 }
 
 func (p *IperfProber) Run() (*SpeedResult, error) {
-	// SECURITY: Validate target before passing to exec.Command
 	if err := ValidateTarget(p.Target); err != nil {
 		return nil, fmt.Errorf("invalid target: %w", err)
 	}
-
-	// Validate port range
-	if p.Port < 1 || p.Port > 65535 {
+	if p.Opts.Port < 1 || p.Opts.Port > 65535 {
 		return nil, fmt.Errorf("invalid port: must be between 1 and 65535")
 	}
+	if p.Opts.UDP && p.Opts.BlockSize < iperfUDPHeaderSize {
+		return nil, fmt.Errorf("invalid block size: udp needs at least %d bytes", iperfUDPHeaderSize)
+	}
+	if err := ValidateIperfBlockSize(p.Opts.BlockSize, p.Opts.UDP); err != nil {
+		return nil, err
+	}
+
+	result := &SpeedResult{Timestamp: time.Now()}
+
+	if !p.Opts.Reverse {
+		up, err := p.runTest(false)
+		if err != nil {
+			return nil, fmt.Errorf("iperf3 upload test failed: %w", err)
+		}
+		result.Upload = up
+		result.UploadSpeed = up.Mbps()
+	}
 
-	// SECURITY: Using argument separation (not shell string concatenation)
-	// Execute: iperf3 -c <target> -p <port> -J -t 5
-	// -J is for JSON output
-	cmd := exec.Command("iperf3", "-c", p.Target, "-p", fmt.Sprintf("%d", p.Port), "-J", "-t", "5")
-	output, err := cmd.Output()
+	down, err := p.runTest(true)
 	if err != nil {
-		return nil, fmt.Errorf("iperf3 execution failed: %w", err)
+		return nil, fmt.Errorf("iperf3 download test failed: %w", err)
 	}
+	result.Download = down
+	result.DownloadSpeed = down.Mbps()
+
+	return result, nil
+}
+
+// iperfStreamResult is one entry of the "streams" array in EXCHANGE_RESULTS
+type iperfStreamResult struct {
+	ID          int     `json:"id"`
+	Bytes       int64   `json:"bytes"`
+	Retransmits int64   `json:"retransmits"`
+	Jitter      float64 `json:"jitter"` // seconds
+	Errors      int64   `json:"errors"`
+	Packets     int64   `json:"packets"`
+	StartTime   float64 `json:"start_time"`
+	EndTime     float64 `json:"end_time"`
+}
+
+type iperfResults struct {
+	CPUUtilTotal         float64             `json:"cpu_util_total"`
+	CPUUtilUser          float64             `json:"cpu_util_user"`
+	CPUUtilSystem        float64             `json:"cpu_util_system"`
+	SenderHasRetransmits int                 `json:"sender_has_retransmits"`
+	CongestionUsed       string              `json:"congestion_used,omitempty"`
+	Streams              []iperfStreamResult `json:"streams"`
+}
+
+// iperfStream is the client side of one data connection
+type iperfStream struct {
+	id   int
+	conn net.Conn
+
+	bytes       atomic.Int64
+	packets     atomic.Int64
+	retransmits int64
 
-	var data struct {
-		End struct {
-			SumReceived struct {
-				BitsPerSecond float64 `json:"bits_per_second"`
-			} `json:"sum_received"`
-			SumSent struct {
-				BitsPerSecond float64 `json:"bits_per_second"`
-			} `json:"sum_sent"`
-		} `json:"end"`
+	// UDP receiver state
+	mu          sync.Mutex
+	lastSeq     int64
+	lost        int64
+	outOfOrder  int64
+	jitter      float64 // seconds
+	prevTransit float64
+	haveTransit bool
+}
+
+// iperfSession drives one iperf3 test over a control connection
+type iperfSession struct {
+	prober  *IperfProber
+	reverse bool
+	ctrl    net.Conn
+	cookie  []byte
+	streams []*iperfStream
+	started time.Time
+	elapsed time.Duration
+	stop    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func (p *IperfProber) runTest(reverse bool) (*TransferStats, error) {
+	addr := net.JoinHostPort(p.Target, strconv.Itoa(p.Opts.Port))
+	ctrl, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("control connection failed: %w", err)
+	}
+	defer ctrl.Close()
+
+	sess := &iperfSession{
+		prober:  p,
+		reverse: reverse,
+		ctrl:    ctrl,
+		cookie:  newIperfCookie(),
+		stop:    make(chan struct{}),
 	}
+	defer sess.closeStreams()
 
-	if err := json.Unmarshal(output, &data); err != nil {
-		return nil, fmt.Errorf("failed to parse iperf3 output: %w", err)
+	if _, err := ctrl.Write(sess.cookie); err != nil {
+		return nil, fmt.Errorf("send cookie: %w", err)
 	}
 
-	return &SpeedResult{
-		DownloadSpeed: data.End.SumReceived.BitsPerSecond / 1000000,
-		UploadSpeed:   data.End.SumSent.BitsPerSecond / 1000000,
-		Timestamp:     time.Now(),
-	}, nil
+	var serverResults *iperfResults
+	for {
+		// Generous deadline: the only long wait is while streams run
+		ctrl.SetReadDeadline(time.Now().Add(p.Opts.Duration + 30*time.Second))
+		state, err := sess.readState()
+		if err != nil {
+			return nil, fmt.Errorf("read control state: %w", err)
+		}
+
+		switch state {
+		case iperfParamExchange:
+			if err := sess.sendParams(); err != nil {
+				return nil, fmt.Errorf("param exchange: %w", err)
+			}
+		case iperfCreateStreams:
+			if err := sess.createStreams(addr); err != nil {
+				return nil, fmt.Errorf("create streams: %w", err)
+			}
+		case iperfTestStart:
+			// Nothing to prepare; streams are already connected
+		case iperfTestRunning:
+			sess.runStreams()
+			if err := sess.writeState(iperfTestEnd); err != nil {
+				return nil, fmt.Errorf("send test end: %w", err)
+			}
+		case iperfExchangeResults:
+			serverResults, err = sess.exchangeResults()
+			if err != nil {
+				return nil, fmt.Errorf("exchange results: %w", err)
+			}
+		case iperfDisplayResults:
+			if err := sess.writeState(iperfDone); err != nil {
+				return nil, fmt.Errorf("send done: %w", err)
+			}
+			return sess.buildStats(serverResults), nil
+		case iperfDone:
+			return sess.buildStats(serverResults), nil
+		case iperfAccessDenied:
+			return nil, fmt.Errorf("server is busy running a test (access denied)")
+		case iperfServerError:
+			return nil, sess.readServerError()
+		case iperfServerTerminate:
+			return nil, fmt.Errorf("server terminated the test")
+		case iperfStart, iperfClientTerminate:
+			// Informational only
+		default:
+			return nil, fmt.Errorf("unexpected control state %d", state)
+		}
+	}
+}
+
+func newIperfCookie() []byte {
+	cookie := make([]byte, iperfCookieSize)
+	rand.Read(cookie[:iperfCookieSize-1])
+	for i := 0; i < iperfCookieSize-1; i++ {
+		cookie[i] = iperfCookieAlphabet[int(cookie[i])%len(iperfCookieAlphabet)]
+	}
+	cookie[iperfCookieSize-1] = 0
+	return cookie
+}
+
+func (s *iperfSession) readState() (int8, error) {
+	var buf [1]byte
+	if _, err := io.ReadFull(s.ctrl, buf[:]); err != nil {
+		return 0, err
+	}
+	return int8(buf[0]), nil
+}
+
+func (s *iperfSession) writeState(state int8) error {
+	_, err := s.ctrl.Write([]byte{byte(state)})
+	return err
+}
+
+// writeJSON sends a JSON object framed by a 4-byte big-endian length (Jwrite)
+func (s *iperfSession) writeJSON(v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var size [4]byte
+	binary.BigEndian.PutUint32(size[:], uint32(len(data)))
+	if _, err := s.ctrl.Write(size[:]); err != nil {
+		return err
+	}
+	_, err = s.ctrl.Write(data)
+	return err
+}
+
+// readJSON reads a length-prefixed JSON object (Jread)
+func (s *iperfSession) readJSON(v interface{}) error {
+	var size [4]byte
+	if _, err := io.ReadFull(s.ctrl, size[:]); err != nil {
+		return err
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > iperfMaxJSONSize {
+		return fmt.Errorf("json message too large (%d bytes)", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(s.ctrl, data); err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func (s *iperfSession) readServerError() error {
+	var codes [8]byte
+	if _, err := io.ReadFull(s.ctrl, codes[:]); err != nil {
+		return fmt.Errorf("server error (details unavailable)")
+	}
+	ierr := int32(binary.BigEndian.Uint32(codes[0:4]))
+	errno := int32(binary.BigEndian.Uint32(codes[4:8]))
+	return fmt.Errorf("server error: i_errno=%d errno=%d", ierr, errno)
+}
+
+func (s *iperfSession) sendParams() error {
+	opts := s.prober.Opts
+	params := map[string]interface{}{
+		"omit":           0,
+		"time":           int(math.Ceil(opts.Duration.Seconds())),
+		"num":            0,
+		"blockcount":     0,
+		"parallel":       opts.Parallel,
+		"len":            opts.BlockSize,
+		"pacing_timer":   1000,
+		"client_version": "3.9",
+	}
+	if opts.UDP {
+		params["udp"] = true
+	} else {
+		params["tcp"] = true
+	}
+	if s.reverse {
+		params["reverse"] = true
+	}
+	if opts.Bitrate > 0 {
+		params["bandwidth"] = opts.Bitrate
+	}
+	if opts.Congestion != "" && !opts.UDP {
+		params["congestion"] = opts.Congestion
+	}
+	return s.writeJSON(params)
+}
+
+func (s *iperfSession) createStreams(addr string) error {
+	opts := s.prober.Opts
+	for i := 0; i < opts.Parallel; i++ {
+		// iperf3 numbers streams 1, 3, 4, 5... and matches results by id
+		id := 1
+		if i > 0 {
+			id = i + 2
+		}
+
+		var conn net.Conn
+		var err error
+		if opts.UDP {
+			conn, err = s.connectUDP(addr)
+		} else {
+			conn, err = s.connectTCP(addr)
+		}
+		if err != nil {
+			return err
+		}
+		s.streams = append(s.streams, &iperfStream{id: id, conn: conn})
+	}
+	return nil
 }
+
+func (s *iperfSession) connectTCP(addr string) (net.Conn, error) {
+	conn, err := net.DialTimeout("tcp", addr, 10*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if cc := s.prober.Opts.Congestion; cc != "" {
+		if tcpConn, ok := conn.(*net.TCPConn); ok {
+			if err := setTCPCongestion(tcpConn, cc); err != nil {
+				conn.Close()
+				return nil, fmt.Errorf("set congestion control %q: %w", cc, err)
+			}
+		}
+	}
+	if _, err := conn.Write(s.cookie); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// connectUDP performs the iperf3 UDP handshake: send a 4-byte hello, await a 4-byte reply
+func (s *iperfSession) connectUDP(addr string) (net.Conn, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	var hello [4]byte
+	binary.LittleEndian.PutUint32(hello[:], iperfUDPConnectMsg)
+
+	reply := make([]byte, 64)
+	for attempt := 0; attempt < 3; attempt++ {
+		if _, err = conn.Write(hello[:]); err != nil {
+			break
+		}
+		conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+		if _, err = conn.Read(reply); err == nil {
+			conn.SetReadDeadline(time.Time{})
+			return conn, nil
+		}
+	}
+	conn.Close()
+	return nil, fmt.Errorf("udp stream handshake failed: %w", err)
+}
+
+// runStreams moves data for the configured duration and returns once the
+// test window has elapsed. Receivers keep draining until closeStreams.
+func (s *iperfSession) runStreams() {
+	s.started = time.Now()
+	deadline := s.started.Add(s.prober.Opts.Duration)
+
+	for _, st := range s.streams {
+		s.wg.Add(1)
+		if s.reverse {
+			go s.receive(st)
+		} else {
+			go s.send(st, deadline)
+		}
+	}
+
+	if s.reverse {
+		time.Sleep(time.Until(deadline))
+	} else {
+		s.wg.Wait()
+	}
+	s.elapsed = time.Since(s.started)
+
+	for _, st := range s.streams {
+		if tcpConn, ok := st.conn.(*net.TCPConn); ok && !s.reverse {
+			st.retransmits = tcpRetransmits(tcpConn)
+		}
+	}
+}
+
+// ValidateIperfBlockSize checks a -l value; 0 means the default
+func ValidateIperfBlockSize(size int, udp bool) error {
+	switch {
+	case size < 0:
+		return fmt.Errorf("invalid block size: must not be negative")
+	case udp && size > IperfMaxUDPBlock:
+		return fmt.Errorf("invalid block size: udp allows at most %d bytes", IperfMaxUDPBlock)
+	case !udp && size > IperfMaxTCPBlock:
+		return fmt.Errorf("invalid block size: tcp allows at most %d bytes", IperfMaxTCPBlock)
+	}
+	return nil
+}
+
+func (s *iperfSession) send(st *iperfStream, deadline time.Time) {
+	defer s.wg.Done()
+	opts := s.prober.Opts
+
+	buf := make([]byte, opts.BlockSize)
+	rand.Read(buf)
+	st.conn.SetWriteDeadline(deadline.Add(time.Second))
+
+	start := time.Now()
+	var backoff time.Duration
+	for time.Now().Before(deadline) {
+		// Pace to the target bitrate when one is set
+		if opts.Bitrate > 0 {
+			allowed := int64(float64(opts.Bitrate) / 8 * time.Since(start).Seconds())
+			if st.bytes.Load() >= allowed {
+				time.Sleep(time.Millisecond)
+				continue
+			}
+		}
+
+		if opts.UDP {
+			now := time.Now()
+			seq := st.packets.Add(1)
+			binary.BigEndian.PutUint32(buf[0:4], uint32(now.Unix()))
+			binary.BigEndian.PutUint32(buf[4:8], uint32(now.Nanosecond()/1000))
+			binary.BigEndian.PutUint32(buf[8:12], uint32(seq))
+		}
+
+		n, err := st.conn.Write(buf)
+		st.bytes.Add(int64(n))
+		if err == nil {
+			backoff = 0
+			continue
+		}
+		if !opts.UDP {
+			return
+		}
+		// The datagram never left: reuse its sequence number rather than
+		// have it counted as loss
+		st.packets.Add(-1)
+		// One the path can never carry ends the stream. Other errors are ICMP
+		// reports such as a refused port; back off instead of spinning.
+		if errors.Is(err, syscall.EMSGSIZE) {
+			return
+		}
+		backoff = min(max(2*backoff, time.Millisecond), 100*time.Millisecond)
+		time.Sleep(backoff)
+	}
+}
+
+func (s *iperfSession) receive(st *iperfStream) {
+	defer s.wg.Done()
+	buf := make([]byte, 64*1024)
+	for {
+		n, err := st.conn.Read(buf)
+		if n > 0 {
+			select {
+			case <-s.stop:
+				// Drain only; the test window is over
+			default:
+				st.bytes.Add(int64(n))
+				if s.prober.Opts.UDP && n >= iperfUDPHeaderSize {
+					st.recordDatagram(buf[:n], time.Now())
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// recordDatagram updates loss and RFC 1889 jitter the same way iperf_udp_recv does
+func (st *iperfStream) recordDatagram(pkt []byte, arrival time.Time) {
+	sec := binary.BigEndian.Uint32(pkt[0:4])
+	usec := binary.BigEndian.Uint32(pkt[4:8])
+	seq := int64(binary.BigEndian.Uint32(pkt[8:12]))
+
+	st.mu.Lock()
+	defer st.mu.Unlock()
+
+	if seq >= st.lastSeq+1 {
+		if seq > st.lastSeq+1 {
+			st.lost += seq - 1 - st.lastSeq
+		}
+		st.lastSeq = seq
+	} else {
+		st.outOfOrder++
+		if st.lost > 0 {
+			st.lost--
+		}
+	}
+	st.packets.Store(st.lastSeq)
+
+	sentNs := int64(sec)*int64(time.Second) + int64(usec)*int64(time.Microsecond)
+	transit := float64(arrival.UnixNano()-sentNs) / 1e9
+	if st.haveTransit {
+		d := math.Abs(transit - st.prevTransit)
+		st.jitter += (d - st.jitter) / 16.0
+	}
+	st.prevTransit = transit
+	st.haveTransit = true
+}
+
+func (s *iperfSession) exchangeResults() (*iperfResults, error) {
+	// Freeze receive counters at the end of the test window
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+
+	local := iperfResults{SenderHasRetransmits: 0}
+	for _, st := range s.streams {
+		st.mu.Lock()
+		res := iperfStreamResult{
+			ID:          st.id,
+			Bytes:       st.bytes.Load(),
+			Retransmits: -1,
+			Jitter:      st.jitter,
+			Errors:      st.lost,
+			Packets:     st.packets.Load(),
+			StartTime:   0,
+			EndTime:     s.elapsed.Seconds(),
+		}
+		st.mu.Unlock()
+		if !s.reverse && st.retransmits >= 0 && !s.prober.Opts.UDP {
+			res.Retransmits = st.retransmits
+			local.SenderHasRetransmits = 1
+		}
+		local.Streams = append(local.Streams, res)
+	}
+
+	if err := s.writeJSON(local); err != nil {
+		return nil, err
+	}
+	var remote iperfResults
+	if err := s.readJSON(&remote); err != nil {
+		return nil, err
+	}
+	return &remote, nil
+}
+
+func (s *iperfSession) closeStreams() {
+	select {
+	case <-s.stop:
+	default:
+		close(s.stop)
+	}
+	for _, st := range s.streams {
+		st.conn.Close()
+	}
+	s.wg.Wait()
+}
+
+// buildStats combines local counters with the server's view of the test.
+// Throughput and UDP quality are taken from whichever side received the data.
+func (s *iperfSession) buildStats(server *iperfResults) *TransferStats {
+	opts := s.prober.Opts
+	stats := &TransferStats{
+		Protocol:    "tcp",
+		Duration:    s.elapsed,
+		Retransmits: -1,
+		Congestion:  opts.Congestion,
+		Start:       s.started,
+		End:         s.started.Add(s.elapsed),
+	}
+	if opts.UDP {
+		stats.Protocol = "udp"
+		stats.Congestion = ""
+	}
+	if server != nil && server.CongestionUsed != "" && !opts.UDP {
+		stats.Congestion = server.CongestionUsed
+	}
+
+	serverByID := map[int]iperfStreamResult{}
+	if server != nil {
+		for _, r := range server.Streams {
+			serverByID[r.ID] = r
+		}
+	}
+
+	var jitterSum float64
+	for _, st := range s.streams {
+		remote, hasRemote := serverByID[st.id]
+		var bytes, lost, packets, retrans int64
+		var jitter float64
+		retrans = -1
+
+		if s.reverse {
+			// We received: local counters are authoritative
+			st.mu.Lock()
+			bytes, lost, packets, jitter = st.bytes.Load(), st.lost, st.packets.Load(), st.jitter
+			st.mu.Unlock()
+			if hasRemote && server.SenderHasRetransmits == 1 {
+				retrans = remote.Retransmits
+			}
+		} else {
+			// Server received: use its counters, fall back to ours
+			bytes, packets = st.bytes.Load(), st.packets.Load()
+			if hasRemote {
+				bytes = remote.Bytes
+				lost, jitter = remote.Errors, remote.Jitter
+			}
+			retrans = st.retransmits
+		}
+
+		stats.Bytes += bytes
+		stats.Packets += packets
+		stats.LostPackets += lost
+		jitterSum += jitter
+		if retrans >= 0 {
+			if stats.Retransmits < 0 {
+				stats.Retransmits = 0
+			}
+			stats.Retransmits += retrans
+		}
+		stats.Streams = append(stats.Streams, StreamStats{
+			Index: len(stats.Streams),
+			Bytes: bytes,
+			Mbps:  mbps(bytes, s.elapsed),
+		})
+	}
+
+	if opts.UDP {
+		stats.Retransmits = -1
+		if len(s.streams) > 0 {
+			stats.Jitter = time.Duration(jitterSum / float64(len(s.streams)) * float64(time.Second))
+		}
+		if stats.Packets > 0 {
+			stats.LossPercent = float64(stats.LostPackets) / float64(stats.Packets) * 100.0
+		}
+	}
+	return stats
+}
+
+// errIperfUnsupported is returned by platform helpers that have no implementation
+var errIperfUnsupported = errors.New("not supported on this platform")
@@ -0,0 +1,39 @@
+//go:build linux
+
+package prober
+
+import (
+	"net"
+
+	"golang.org/x/sys/unix"
+)
+
+// setTCPCongestion selects the congestion control algorithm for a socket (TCP_CONGESTION)
+func setTCPCongestion(conn *net.TCPConn, algo string) error {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return err
+	}
+	var sockErr error
+	if err := raw.Control(func(fd uintptr) {
+		sockErr = unix.SetsockoptString(int(fd), unix.IPPROTO_TCP, unix.TCP_CONGESTION, algo)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// tcpRetransmits reads the total retransmitted segments from TCP_INFO, or -1 if unavailable
+func tcpRetransmits(conn *net.TCPConn) int64 {
+	raw, err := conn.SyscallConn()
+	if err != nil {
+		return -1
+	}
+	retrans := int64(-1)
+	raw.Control(func(fd uintptr) {
+		if info, err := unix.GetsockoptTCPInfo(int(fd), unix.IPPROTO_TCP, unix.TCP_INFO); err == nil {
+			retrans = int64(info.Total_retrans)
+		}
+	})
+	return retrans
+}
@@ -0,0 +1,15 @@
+//go:build !linux
+
+package prober
+
+import "net"
+
+// setTCPCongestion is only implemented on Linux
+func setTCPCongestion(conn *net.TCPConn, algo string) error {
+	return errIperfUnsupported
+}
+
+// tcpRetransmits is only implemented on Linux
+func tcpRetransmits(conn *net.TCPConn) int64 {
+	return -1
+}
@@ -0,0 +1,278 @@
+package prober
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// LibreSpeedTester speaks the LibreSpeed backend protocol:
+// empty.php (ping + upload sink), garbage.php (download source) and getIP.php.
+type LibreSpeedTester struct {
+	ServerURL string // Base URL, e.g. https://speed.example.com/
+	Streams   int
+	Duration  time.Duration // Per direction
+	Grace     time.Duration // Discarded warm-up to skip TCP slow start
+	PingCount int
+	Timeout   time.Duration
+}
+
+// Default LibreSpeed paths relative to the server base URL
+const (
+	libreSpeedDownloadPath = "backend/garbage.php"
+	libreSpeedUploadPath   = "backend/empty.php"
+	libreSpeedPingPath     = "backend/empty.php"
+	libreSpeedIPPath       = "backend/getIP.php"
+	libreSpeedChunkCount   = 100              // ckSize in MiB per download request
+	libreSpeedUploadBlob   = 20 * 1024 * 1024 // Bytes per upload request
+)
+
+func NewLibreSpeedTester(serverURL string) *LibreSpeedTester {
+	if !strings.HasSuffix(serverURL, "/") {
+		serverURL += "/"
+	}
+	return &LibreSpeedTester{
+		ServerURL: serverURL,
+		Streams:   4,
+		Duration:  10 * time.Second,
+		Grace:     1500 * time.Millisecond,
+		PingCount: 10,
+		Timeout:   10 * time.Second,
+	}
+}
+
+func (l *LibreSpeedTester) Run() (*SpeedResult, error) {
+	client := &http.Client{}
+
+	if ip, err := l.fetchIP(client); err == nil && ip != "" {
+		logging.Info("speedtest", "[LibreSpeed] %s sees client as %s", l.ServerURL, ip)
+	}
+
+	latency, jitter, err := l.measurePing(client)
+	if err != nil {
+		return nil, fmt.Errorf("ping test failed: %w", err)
+	}
+
+	down := Phase{Start: time.Now()}
+	downSpeed, err := l.measureTransfer(client, l.downloadOnce)
+	if err != nil {
+		return nil, fmt.Errorf("download test failed: %w", err)
+	}
+	down.End = time.Now()
+
+	up := Phase{Start: time.Now()}
+	upSpeed, err := l.measureTransfer(client, l.uploadOnce)
+	if err != nil {
+		return nil, fmt.Errorf("upload test failed: %w", err)
+	}
+	up.End = time.Now()
+
+	return &SpeedResult{
+		DownloadSpeed: downSpeed,
+		UploadSpeed:   upSpeed,
+		Latency:       latency,
+		Jitter:        jitter,
+		Timestamp:     time.Now(),
+		DownloadPhase: down,
+		UploadPhase:   up,
+	}, nil
+}
+
+// cacheBust appends the random "r" parameter LibreSpeed clients use to defeat caches
+func (l *LibreSpeedTester) cacheBust(path string, extra string) string {
+	url := fmt.Sprintf("%s%s?r=%f", l.ServerURL, path, rand.Float64())
+	if extra != "" {
+		url += "&" + extra
+	}
+	return url
+}
+
+func (l *LibreSpeedTester) fetchIP(client *http.Client) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.cacheBust(libreSpeedIPPath, "isp=false"), nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 4096))
+	if err != nil {
+		return "", err
+	}
+	// Newer backends answer JSON, older ones plain text
+	var data struct {
+		ProcessedString string `json:"processedString"`
+	}
+	if json.Unmarshal(body, &data) == nil && data.ProcessedString != "" {
+		return data.ProcessedString, nil
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+// measurePing times sequential empty.php requests over a warm connection.
+// Jitter is the mean absolute difference between consecutive samples.
+func (l *LibreSpeedTester) measurePing(client *http.Client) (time.Duration, time.Duration, error) {
+	count := l.PingCount
+	if count < 1 {
+		count = 1
+	}
+	var samples []time.Duration
+	// One extra request first to establish the keep-alive connection
+	for i := 0; i <= count; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), l.Timeout)
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, l.cacheBust(libreSpeedPingPath, ""), nil)
+		if err != nil {
+			cancel()
+			return 0, 0, err
+		}
+		start := time.Now()
+		resp, err := client.Do(req)
+		if err != nil {
+			cancel()
+			return 0, 0, err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		cancel()
+		if resp.StatusCode != http.StatusOK {
+			return 0, 0, fmt.Errorf("server returned status: %s", resp.Status)
+		}
+		if i > 0 {
+			samples = append(samples, time.Since(start))
+		}
+	}
+
+	var total, jitterTotal time.Duration
+	for i, rtt := range samples {
+		total += rtt
+		if i > 0 {
+			jitterTotal += time.Duration(math.Abs(float64(rtt - samples[i-1])))
+		}
+	}
+	latency := total / time.Duration(len(samples))
+	var jitter time.Duration
+	if len(samples) > 1 {
+		jitter = jitterTotal / time.Duration(len(samples)-1)
+	}
+	return latency, jitter, nil
+}
+
+// measureTransfer runs Streams concurrent request loops for Duration and
+// returns the throughput counted after the grace period.
+func (l *LibreSpeedTester) measureTransfer(client *http.Client, once func(context.Context, *http.Client, *atomic.Int64) error) (float64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), l.Duration)
+	defer cancel()
+
+	var counter atomic.Int64
+	var wg sync.WaitGroup
+	var firstErr error
+	var errOnce sync.Once
+
+	for i := 0; i < l.Streams; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if err := once(ctx, client, &counter); err != nil && ctx.Err() == nil {
+					errOnce.Do(func() { firstErr = err })
+					return
+				}
+			}
+		}()
+	}
+
+	// Discard bytes moved during slow start
+	var baseline int64
+	start := time.Now()
+	select {
+	case <-time.After(l.Grace):
+		baseline = counter.Load()
+		start = time.Now()
+	case <-ctx.Done():
+	}
+
+	wg.Wait()
+	elapsed := time.Since(start)
+	transferred := counter.Load() - baseline
+
+	if firstErr != nil && transferred == 0 {
+		return 0, firstErr
+	}
+	return mbps(transferred, elapsed), nil
+}
+
+func (l *LibreSpeedTester) downloadOnce(ctx context.Context, client *http.Client, counter *atomic.Int64) error {
+	url := l.cacheBust(libreSpeedDownloadPath, fmt.Sprintf("ckSize=%d", libreSpeedChunkCount))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status: %s", resp.Status)
+	}
+	_, err = io.Copy(&countingWriter{counter: counter}, resp.Body)
+	return err
+}
+
+func (l *LibreSpeedTester) uploadOnce(ctx context.Context, client *http.Client, counter *atomic.Int64) error {
+	body := &countingReader{r: &randomReader{remaining: libreSpeedUploadBlob}, counter: counter}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, l.cacheBust(libreSpeedUploadPath, ""), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = libreSpeedUploadBlob
+	req.Header.Set("Content-Type", "application/octet-stream")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("server returned status: %s", resp.Status)
+	}
+	return nil
+}
+
+// countingWriter tallies bytes written into a shared counter
+type countingWriter struct {
+	counter *atomic.Int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	w.counter.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// countingReader tallies bytes read from r into a shared counter
+type countingReader struct {
+	r       io.Reader
+	counter *atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.counter.Add(int64(n))
+	return n, err
+}
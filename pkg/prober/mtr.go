@@ -74,6 +74,11 @@ func (r *MTRRunner) Run() (*MTRResult, error) {
 		return nil, fmt.Errorf("mtr execution failed: %w", err)
 	}
 
+	return parseMTRJSON(output, r.Target)
+}
+
+// parseMTRJSON converts `mtr --json` output; target fills in a missing destination
+func parseMTRJSON(output []byte, target string) (*MTRResult, error) {
 	var data mtrReport
 	if err := json.Unmarshal(output, &data); err != nil {
 		return nil, fmt.Errorf("parse mtr json failed: %w", err)
@@ -99,7 +104,7 @@ func (r *MTRRunner) Run() (*MTRResult, error) {
 	}
 
 	if res.Target == "" {
-		res.Target = r.Target
+		res.Target = target
 	}
 
 	return res, nil
@@ -0,0 +1,180 @@
+package prober
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+const (
+	DefaultNTPPort    = 123
+	DefaultNTPTimeout = 5 * time.Second
+
+	ntpPacketSize = 48
+	// Seconds between the NTP epoch (1900) and the Unix epoch (1970)
+	ntpEpochOffset = 2208988800
+)
+
+// NTP leap indicator values
+const (
+	NTPLeapNone           = 0
+	NTPLeapInsert         = 1
+	NTPLeapDelete         = 2
+	NTPLeapUnsynchronized = 3
+)
+
+// NTPResult is one SNTP exchange with a server. Offset is how far the
+// server's clock is ahead of ours (negative = server behind).
+type NTPResult struct {
+	Offset         time.Duration
+	Delay          time.Duration // Round trip minus server processing time
+	Stratum        int
+	ReferenceID    string // Refclock name for stratum 1, upstream address otherwise
+	RefIDRaw       uint32 // The reference ID field as sent, for storing as a number
+	Leap           int
+	RootDelay      time.Duration
+	RootDispersion time.Duration
+}
+
+// Synchronized reports whether the server claims a usable time source
+func (r *NTPResult) Synchronized() bool {
+	return r.Leap != NTPLeapUnsynchronized && r.Stratum > 0 && r.Stratum < 16
+}
+
+// LeapString describes the leap indicator
+func (r *NTPResult) LeapString() string {
+	switch r.Leap {
+	case NTPLeapNone:
+		return "none"
+	case NTPLeapInsert:
+		return "insert"
+	case NTPLeapDelete:
+		return "delete"
+	default:
+		return "unsynchronized"
+	}
+}
+
+// KissOfDeathError is a stratum 0 reply telling the client to back off or go away
+type KissOfDeathError struct {
+	Code string // e.g. RATE, DENY, RSTR
+}
+
+func (e *KissOfDeathError) Error() string {
+	return fmt.Sprintf("ntp server sent kiss-o'-death %s", e.Code)
+}
+
+// NTPProber queries one NTP server using SNTPv4 (RFC 4330)
+type NTPProber struct {
+	Address string // host or host:port
+	Timeout time.Duration
+}
+
+func NewNTPProber(address string) *NTPProber {
+	return &NTPProber{Address: address, Timeout: DefaultNTPTimeout}
+}
+
+func (p *NTPProber) Query() (*NTPResult, error) {
+	addr := p.Address
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(strings.Trim(addr, "[]"), fmt.Sprint(DefaultNTPPort))
+	}
+	conn, err := net.DialTimeout("udp", addr, p.Timeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(p.Timeout))
+
+	// Transmit timestamp is a random nonce (as chrony does) rather than our
+	// clock; the server echoes it back as the origin timestamp
+	req := make([]byte, ntpPacketSize)
+	req[0] = 0<<6 | 4<<3 | 3 // LI none, version 4, mode client
+	if _, err := rand.Read(req[40:48]); err != nil {
+		return nil, err
+	}
+
+	t1 := time.Now()
+	if _, err := conn.Write(req); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, 512)
+	var n int
+	for {
+		n, err = conn.Read(resp)
+		if err != nil {
+			return nil, fmt.Errorf("no reply from %s: %w", addr, err)
+		}
+		// Ignore stale or spoofed replies
+		if n >= ntpPacketSize && string(resp[24:32]) == string(req[40:48]) {
+			break
+		}
+	}
+	// Monotonic elapsed keeps Delay correct even if our clock steps meanwhile
+	t4 := t1.Add(time.Since(t1))
+
+	if mode := resp[0] & 0x07; mode != 4 {
+		return nil, fmt.Errorf("unexpected ntp mode %d in reply", mode)
+	}
+	res := &NTPResult{
+		Leap:           int(resp[0] >> 6),
+		Stratum:        int(resp[1]),
+		RootDelay:      ntpShortDuration(binary.BigEndian.Uint32(resp[4:8])),
+		RootDispersion: ntpShortDuration(binary.BigEndian.Uint32(resp[8:12])),
+	}
+	refID := resp[12:16]
+	res.RefIDRaw = binary.BigEndian.Uint32(refID)
+	switch {
+	case res.Stratum == 0:
+		return nil, &KissOfDeathError{Code: strings.TrimRight(string(refID), "\x00")}
+	case res.Stratum == 1:
+		res.ReferenceID = strings.TrimRight(string(refID), "\x00")
+	default:
+		res.ReferenceID = net.IP(refID).String()
+	}
+
+	if binary.BigEndian.Uint64(resp[40:48]) == 0 {
+		return nil, fmt.Errorf("ntp reply has no transmit timestamp")
+	}
+	t2 := ntpTime(binary.BigEndian.Uint64(resp[32:40]), t1)
+	t3 := ntpTime(binary.BigEndian.Uint64(resp[40:48]), t1)
+	res.Offset = (t2.Sub(t1) + t3.Sub(t4)) / 2
+	res.Delay = t4.Sub(t1) - t3.Sub(t2)
+	if res.Delay < 0 {
+		res.Delay = 0
+	}
+
+	logging.Debug("ntp", "[NTP] %s: offset=%s delay=%s stratum=%d refid=%s leap=%s",
+		addr, res.Offset, res.Delay, res.Stratum, res.ReferenceID, res.LeapString())
+	return res, nil
+}
+
+// ntpTime converts a 32.32 NTP timestamp, picking the era nearest to ref so
+// the 2036 rollover is handled
+func ntpTime(ts uint64, ref time.Time) time.Time {
+	secs := int64(ts >> 32)
+	frac := int64(ts & 0xffffffff)
+	unix := secs - ntpEpochOffset
+	const era = int64(1) << 32
+	for unix < ref.Unix()-era/2 {
+		unix += era
+	}
+	return time.Unix(unix, frac*1e9>>32)
+}
+
+// ntpTimestamp converts t to the 32.32 NTP timestamp format
+func ntpTimestamp(t time.Time) uint64 {
+	secs := uint64(t.Unix() + ntpEpochOffset)
+	frac := uint64(t.Nanosecond()) << 32 / 1e9
+	return secs<<32 | frac
+}
+
+// ntpShortDuration converts a 16.16 fixed point seconds value
+func ntpShortDuration(v uint32) time.Duration {
+	return time.Duration(int64(v) * int64(time.Second) >> 16)
+}
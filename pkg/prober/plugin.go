@@ -0,0 +1,312 @@
+package prober
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// PluginStatus is the Nagios plugin exit code
+type PluginStatus int
+
+const (
+	PluginOK       PluginStatus = 0
+	PluginWarning  PluginStatus = 1
+	PluginCritical PluginStatus = 2
+	PluginUnknown  PluginStatus = 3
+)
+
+func (s PluginStatus) String() string {
+	switch s {
+	case PluginOK:
+		return "OK"
+	case PluginWarning:
+		return "WARNING"
+	case PluginCritical:
+		return "CRITICAL"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	DefaultPluginTimeout = 10 * time.Second
+	MaxPluginTimeout     = 60 * time.Second
+
+	// Plugins are not expected to print more than a screenful
+	maxPluginOutput = 64 * 1024
+)
+
+// PluginHostMacro in an argument is replaced with the target address
+const PluginHostMacro = "$HOSTADDRESS$"
+
+// PerfData is one `'label'=value[UOM];[warn];[crit];[min];[max]` item
+type PerfData struct {
+	Label string
+	Value float64
+	Unit  string
+	Warn  string // Threshold ranges are kept as written
+	Crit  string
+	Min   string
+	Max   string
+}
+
+// PluginResult is the outcome of one plugin run
+type PluginResult struct {
+	Status   PluginStatus
+	Message  string // Output without perfdata, long output included
+	PerfData []PerfData
+	Duration time.Duration
+}
+
+// PluginRunner executes a Nagios/Icinga compatible check plugin.
+// The command is run directly (no shell) with a minimal environment.
+type PluginRunner struct {
+	Command string
+	Args    []string
+	Timeout time.Duration
+}
+
+func NewPluginRunner(command string, args []string, timeout time.Duration) *PluginRunner {
+	if timeout <= 0 {
+		timeout = DefaultPluginTimeout
+	}
+	if timeout > MaxPluginTimeout {
+		timeout = MaxPluginTimeout
+	}
+	return &PluginRunner{Command: command, Args: args, Timeout: timeout}
+}
+
+// Run executes the plugin. A plugin that runs but exits non-zero is not an
+// error; err is only set when it could not be started at all. A timeout is
+// reported as PluginUnknown, like Nagios does for service checks by default.
+func (p *PluginRunner) Run() (*PluginResult, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), p.Timeout)
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, p.Command, p.Args...)
+	// Don't leak RS_JWT_SECRET and friends into third-party scripts
+	cmd.Env = []string{"PATH=/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin", "LC_ALL=C"}
+	// Plugins that fork children holding stdout open must not block Wait forever
+	cmd.WaitDelay = time.Second
+	var stdout limitedBuffer
+	stdout.limit = maxPluginOutput
+	cmd.Stdout = &stdout
+
+	start := time.Now()
+	err := cmd.Run()
+	res := &PluginResult{Duration: time.Since(start)}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		res.Status = PluginUnknown
+		res.Message = fmt.Sprintf("plugin timed out after %s", p.Timeout)
+		return res, nil
+	}
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		res.Status = PluginOK
+	case errors.As(err, &exitErr) && exitErr.Exited():
+		res.Status = PluginStatus(exitErr.ExitCode())
+		if res.Status < PluginOK || res.Status > PluginUnknown {
+			res.Status = PluginUnknown
+		}
+	case errors.As(err, &exitErr):
+		// Killed by a signal
+		res.Status = PluginUnknown
+		res.Message = fmt.Sprintf("plugin terminated: %v", err)
+		return res, nil
+	default:
+		return nil, fmt.Errorf("plugin %s failed to start: %w", filepath.Base(p.Command), err)
+	}
+
+	res.Message, res.PerfData = ParsePluginOutput(stdout.String())
+	logging.Debug("plugin", "[Plugin] %s exited %s in %s with %d perfdata items",
+		filepath.Base(p.Command), res.Status, res.Duration, len(res.PerfData))
+	return res, nil
+}
+
+// ParsePluginOutput splits plugin output into text and performance data:
+//
+//	TEXT OUTPUT | OPTIONAL PERFDATA
+//	LONG TEXT LINE 1
+//	LONG TEXT LINE 2 | PERFDATA
+//	MORE PERFDATA
+func ParsePluginOutput(output string) (string, []PerfData) {
+	lines := strings.Split(strings.TrimRight(output, "\n"), "\n")
+	var text []string
+	var perf []string
+
+	first, firstPerf, _ := strings.Cut(lines[0], "|")
+	text = append(text, strings.TrimSpace(first))
+	perf = append(perf, firstPerf)
+
+	inPerf := false
+	for _, line := range lines[1:] {
+		if inPerf {
+			perf = append(perf, line)
+			continue
+		}
+		if before, after, found := strings.Cut(line, "|"); found {
+			text = append(text, strings.TrimRight(before, " \t"))
+			perf = append(perf, after)
+			inPerf = true
+			continue
+		}
+		text = append(text, line)
+	}
+
+	var items []PerfData
+	for _, p := range perf {
+		items = append(items, parsePerfData(p)...)
+	}
+	return strings.TrimSpace(strings.Join(text, "\n")), items
+}
+
+// parsePerfData reads space separated perfdata items. Labels with spaces are
+// single-quoted, with quotes inside the label doubled.
+func parsePerfData(s string) []PerfData {
+	var items []PerfData
+	s = strings.TrimSpace(s)
+	for s != "" {
+		var label string
+		if s[0] == '\'' {
+			end := 1
+			for end < len(s) {
+				if s[end] == '\'' {
+					if end+1 < len(s) && s[end+1] == '\'' {
+						end += 2
+						continue
+					}
+					break
+				}
+				end++
+			}
+			if end >= len(s) {
+				break // Unterminated quote
+			}
+			label = strings.ReplaceAll(s[1:end], "''", "'")
+			s = s[end+1:]
+		} else {
+			eq := strings.IndexByte(s, '=')
+			if eq < 0 {
+				break
+			}
+			label = s[:eq]
+			s = s[eq:]
+		}
+		if !strings.HasPrefix(s, "=") {
+			break
+		}
+		s = s[1:]
+
+		field := s
+		if sp := strings.IndexAny(s, " \t"); sp >= 0 {
+			field, s = s[:sp], strings.TrimSpace(s[sp:])
+		} else {
+			s = ""
+		}
+
+		if item, ok := parsePerfValue(label, field); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+func parsePerfValue(label, field string) (PerfData, bool) {
+	parts := strings.Split(field, ";")
+	raw := parts[0]
+	numEnd := 0
+	for numEnd < len(raw) && strings.ContainsRune("0123456789.-+eE", rune(raw[numEnd])) {
+		numEnd++
+	}
+	// "U" means the plugin could not determine the value
+	value, err := strconv.ParseFloat(raw[:numEnd], 64)
+	if label == "" || err != nil {
+		return PerfData{}, false
+	}
+	item := PerfData{Label: label, Value: value, Unit: raw[numEnd:]}
+	thresholds := []*string{&item.Warn, &item.Crit, &item.Min, &item.Max}
+	for i, t := range parts[1:] {
+		if i < len(thresholds) {
+			*thresholds[i] = t
+		}
+	}
+	return item, true
+}
+
+// ParsePluginAllowList splits RS_PLUGIN_PATHS style lists (comma separated)
+func ParsePluginAllowList(s string) []string {
+	var out []string
+	for _, p := range strings.Split(s, ",") {
+		if p = strings.TrimSpace(p); p != "" {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// ResolvePlugin checks command against the allow list and returns the real
+// path to execute. Allow list entries are executables, or directories whose
+// direct children may be run. Symlinks are resolved on both sides so a link
+// can't escape the allowed directories.
+func ResolvePlugin(command string, allow []string) (string, error) {
+	if len(allow) == 0 {
+		return "", fmt.Errorf("plugin probes are disabled, set RS_PLUGIN_PATHS to allow executables")
+	}
+	if !filepath.IsAbs(command) {
+		return "", fmt.Errorf("plugin command must be an absolute path")
+	}
+	real, err := filepath.EvalSymlinks(filepath.Clean(command))
+	if err != nil {
+		return "", fmt.Errorf("plugin %s: %w", command, err)
+	}
+	info, err := os.Stat(real)
+	if err != nil {
+		return "", fmt.Errorf("plugin %s: %w", command, err)
+	}
+	if !info.Mode().IsRegular() || info.Mode().Perm()&0111 == 0 {
+		return "", fmt.Errorf("plugin %s is not an executable file", command)
+	}
+
+	for _, entry := range allow {
+		allowed, err := filepath.EvalSymlinks(filepath.Clean(entry))
+		if err != nil {
+			continue
+		}
+		if allowed == real {
+			return real, nil
+		}
+		if st, err := os.Stat(allowed); err == nil && st.IsDir() && filepath.Dir(real) == allowed {
+			return real, nil
+		}
+	}
+	return "", fmt.Errorf("plugin %s is not in RS_PLUGIN_PATHS", command)
+}
+
+// limitedBuffer drops writes beyond limit instead of failing the plugin
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.limit - b.Len(); room > 0 {
+		if len(p) > room {
+			b.Buffer.Write(p[:room])
+		} else {
+			b.Buffer.Write(p)
+		}
+	}
+	return len(p), nil
+}
@@ -40,12 +40,87 @@ func ValidateTarget(target string) error {
 	return nil
 }
 
+// mbps converts a byte count transferred over a duration to megabits per second
+func mbps(bytes int64, duration time.Duration) float64 {
+	if duration <= 0 {
+		duration = time.Millisecond
+	}
+	return (float64(bytes) * 8) / (duration.Seconds() * 1000000)
+}
+
 // SpeedResult holds the result of a bandwidth test
 type SpeedResult struct {
 	UploadSpeed   float64 // Mbps
 	DownloadSpeed float64 // Mbps
 	Latency       time.Duration
+	Jitter        time.Duration
 	Timestamp     time.Time
+
+	// Per-direction detail, filled by testers that can report more than throughput
+	Upload   *TransferStats
+	Download *TransferStats
+
+	// JumpHosts holds per-bastion timings when the test went through SSH jump hosts
+	JumpHosts []JumpHostStats
+
+	// When each direction was transferring, so latency sampled during the
+	// test can be attributed. See Phases for testers that only fill TransferStats.
+	DownloadPhase Phase
+	UploadPhase   Phase
+
+	// Latency under load, when sampled alongside the test
+	Bufferbloat *BufferbloatResult
+}
+
+// Phase is a time window of a speed test
+type Phase struct {
+	Start time.Time
+	End   time.Time
+}
+
+func (p Phase) Contains(t time.Time) bool {
+	return !p.Start.IsZero() && !t.Before(p.Start) && !t.After(p.End)
+}
+
+// Phases returns the download and upload windows, falling back to the
+// TransferStats timestamps
+func (r *SpeedResult) Phases() (down, up Phase) {
+	down, up = r.DownloadPhase, r.UploadPhase
+	if down.Start.IsZero() && r.Download != nil {
+		down = Phase{Start: r.Download.Start, End: r.Download.End}
+	}
+	if up.Start.IsZero() && r.Upload != nil {
+		up = Phase{Start: r.Upload.Start, End: r.Upload.End}
+	}
+	return down, up
+}
+
+// TransferStats describes one direction of a speed test
+type TransferStats struct {
+	Protocol    string // tcp, udp or ssh
+	Bytes       int64
+	Duration    time.Duration
+	Start       time.Time
+	End         time.Time
+	Retransmits int64         // TCP sender retransmits, -1 if unknown
+	Jitter      time.Duration // UDP receiver jitter
+	Packets     int64         // UDP datagrams sent
+	LostPackets int64
+	LossPercent float64
+	Congestion  string
+	Streams     []StreamStats
+}
+
+// Mbps returns the aggregate throughput of the transfer
+func (t *TransferStats) Mbps() float64 {
+	return mbps(t.Bytes, t.Duration)
+}
+
+// StreamStats is the share of a transfer carried by one parallel stream
+type StreamStats struct {
+	Index int
+	Bytes int64
+	Mbps  float64
 }
 
 // PingResult holds the result of an ICMP ping series
@@ -0,0 +1,150 @@
+package prober
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultPeerTestSize is the default payload per direction for peer speed tests (25MB)
+const DefaultPeerTestSize = 25 * 1024 * 1024
+
+// RouteLensSpeedTester measures throughput against another RouteLens instance
+// using its built-in /api/v1/speedtest endpoints.
+type RouteLensSpeedTester struct {
+	BaseURL   string // e.g. http://peer.example.com:8080
+	Token     string // Shared RS_SPEEDTEST_TOKEN of the peer
+	TestBytes int64
+	Timeout   time.Duration
+}
+
+func NewRouteLensSpeedTester(baseURL, token string, testBytes int64) *RouteLensSpeedTester {
+	if testBytes <= 0 {
+		testBytes = DefaultPeerTestSize
+	}
+	return &RouteLensSpeedTester{
+		BaseURL:   strings.TrimRight(baseURL, "/"),
+		Token:     token,
+		TestBytes: testBytes,
+		Timeout:   2 * time.Minute,
+	}
+}
+
+func (r *RouteLensSpeedTester) Run() (*SpeedResult, error) {
+	client := &http.Client{Timeout: r.Timeout}
+
+	down := Phase{Start: time.Now()}
+	downSpeed, err := r.measureDownload(client)
+	if err != nil {
+		return nil, fmt.Errorf("download test failed: %w", err)
+	}
+	down.End = time.Now()
+
+	up := Phase{Start: time.Now()}
+	upSpeed, err := r.measureUpload(client)
+	if err != nil {
+		return nil, fmt.Errorf("upload test failed: %w", err)
+	}
+	up.End = time.Now()
+
+	return &SpeedResult{
+		DownloadSpeed: downSpeed,
+		UploadSpeed:   upSpeed,
+		Timestamp:     time.Now(),
+		DownloadPhase: down,
+		UploadPhase:   up,
+	}, nil
+}
+
+func (r *RouteLensSpeedTester) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, r.BaseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-RouteLens-Token", r.Token)
+	return req, nil
+}
+
+func (r *RouteLensSpeedTester) measureDownload(client *http.Client) (float64, error) {
+	req, err := r.newRequest(http.MethodGet, fmt.Sprintf("/api/v1/speedtest/download?bytes=%d", r.TestBytes), nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("peer returned status: %s", resp.Status)
+	}
+
+	n, err := io.Copy(io.Discard, resp.Body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read body: %w", err)
+	}
+
+	return mbps(n, time.Since(start)), nil
+}
+
+func (r *RouteLensSpeedTester) measureUpload(client *http.Client) (float64, error) {
+	body := &randomReader{remaining: r.TestBytes}
+	req, err := r.newRequest(http.MethodPost, "/api/v1/speedtest/upload", body)
+	if err != nil {
+		return 0, err
+	}
+	req.ContentLength = r.TestBytes
+	req.Header.Set("Content-Type", "application/octet-stream")
+
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("peer returned status: %s", resp.Status)
+	}
+
+	return mbps(r.TestBytes, time.Since(start)), nil
+}
+
+// uploadChunk is a block of random bytes repeated for every upload, as the
+// peer does for downloads, so compression on the path can't inflate results
+var uploadChunk = func() []byte {
+	buf := make([]byte, 64*1024)
+	_, _ = rand.Read(buf)
+	return buf
+}()
+
+// randomReader yields a fixed number of bytes from uploadChunk without
+// allocating them up front
+type randomReader struct {
+	remaining int64
+	offset    int
+}
+
+func (r *randomReader) Read(p []byte) (int, error) {
+	if r.remaining <= 0 {
+		return 0, io.EOF
+	}
+	if int64(len(p)) > r.remaining {
+		p = p[:r.remaining]
+	}
+	n := 0
+	for n < len(p) {
+		c := copy(p[n:], uploadChunk[r.offset:])
+		n += c
+		r.offset = (r.offset + c) % len(uploadChunk)
+	}
+	r.remaining -= int64(n)
+	return n, nil
+}
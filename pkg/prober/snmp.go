@@ -0,0 +1,282 @@
+package prober
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// IF-MIB columns, suffixed with .ifIndex
+const (
+	oidIfDescr      = "1.3.6.1.2.1.2.2.1.2"
+	oidIfInDiscards = "1.3.6.1.2.1.2.2.1.13"
+	oidIfInErrors   = "1.3.6.1.2.1.2.2.1.14"
+	oidIfOutDiscard = "1.3.6.1.2.1.2.2.1.19"
+	oidIfOutErrors  = "1.3.6.1.2.1.2.2.1.20"
+	oidIfName       = "1.3.6.1.2.1.31.1.1.1.1"
+	oidIfHCIn       = "1.3.6.1.2.1.31.1.1.1.6"
+	oidIfHCOut      = "1.3.6.1.2.1.31.1.1.1.10"
+	oidIfHighSpeed  = "1.3.6.1.2.1.31.1.1.1.15" // Mbit/s
+)
+
+// SNMP versions accepted in SNMPConfig.Version
+const (
+	SNMPVersion2c = "2c"
+	SNMPVersion3  = "3"
+)
+
+type SNMPConfig struct {
+	Host      string
+	Port      int // Default 161
+	Version   string
+	Community string // v2c
+	Timeout   time.Duration
+
+	// v3 USM. Security level follows from which passphrases are set.
+	User         string
+	AuthProtocol string // MD5, SHA, SHA224, SHA256, SHA384, SHA512
+	AuthPassword string
+	PrivProtocol string // DES, AES, AES192, AES256, AES192C, AES256C
+	PrivPassword string
+}
+
+// InterfaceCounters is one reading of an interface's IF-MIB counters
+type InterfaceCounters struct {
+	Index       int
+	Name        string
+	SpeedMbps   uint64 // ifHighSpeed, 0 if unknown
+	InOctets    uint64
+	OutOctets   uint64
+	InErrors    uint64
+	OutErrors   uint64
+	InDiscards  uint64
+	OutDiscards uint64
+	Time        time.Time
+}
+
+// InterfaceRates is the per-second change between two InterfaceCounters
+type InterfaceRates struct {
+	Index       int
+	Name        string
+	InBps       float64 // bits/s
+	OutBps      float64
+	InUtil      float64 // Percent of ifHighSpeed, 0 if speed unknown
+	OutUtil     float64
+	InErrors    float64 // per second
+	OutErrors   float64
+	InDiscards  float64
+	OutDiscards float64
+}
+
+var snmpAuthProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5": gosnmp.MD5, "SHA": gosnmp.SHA, "SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256, "SHA384": gosnmp.SHA384, "SHA512": gosnmp.SHA512,
+}
+
+var snmpPrivProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES": gosnmp.DES, "AES": gosnmp.AES, "AES192": gosnmp.AES192,
+	"AES256": gosnmp.AES256, "AES192C": gosnmp.AES192C, "AES256C": gosnmp.AES256C,
+}
+
+// SNMPPoller reads interface counters from a device
+type SNMPPoller struct {
+	config SNMPConfig
+}
+
+func NewSNMPPoller(cfg SNMPConfig) *SNMPPoller {
+	if cfg.Port == 0 {
+		cfg.Port = 161
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 5 * time.Second
+	}
+	return &SNMPPoller{config: cfg}
+}
+
+// ValidateSNMPConfig checks version and v3 protocol names
+func ValidateSNMPConfig(cfg SNMPConfig) error {
+	switch cfg.Version {
+	case "", SNMPVersion2c:
+		return nil
+	case SNMPVersion3:
+	default:
+		return fmt.Errorf("unsupported snmp version %q", cfg.Version)
+	}
+	if cfg.User == "" {
+		return fmt.Errorf("snmp v3 user is required")
+	}
+	if _, ok := snmpAuthProtocols[strings.ToUpper(cfg.AuthProtocol)]; cfg.AuthPassword != "" && !ok {
+		return fmt.Errorf("unknown snmp auth protocol %q", cfg.AuthProtocol)
+	}
+	if _, ok := snmpPrivProtocols[strings.ToUpper(cfg.PrivProtocol)]; cfg.PrivPassword != "" && !ok {
+		return fmt.Errorf("unknown snmp privacy protocol %q", cfg.PrivProtocol)
+	}
+	if cfg.PrivPassword != "" && cfg.AuthPassword == "" {
+		return fmt.Errorf("snmp v3 privacy requires authentication")
+	}
+	return nil
+}
+
+func (p *SNMPPoller) client() (*gosnmp.GoSNMP, error) {
+	cfg := p.config
+	if err := ValidateSNMPConfig(cfg); err != nil {
+		return nil, err
+	}
+	g := &gosnmp.GoSNMP{
+		Target:         cfg.Host,
+		Port:           uint16(cfg.Port),
+		Community:      cfg.Community,
+		Version:        gosnmp.Version2c,
+		Timeout:        cfg.Timeout,
+		Retries:        1,
+		MaxOids:        gosnmp.MaxOids,
+		MaxRepetitions: 25,
+	}
+	if g.Community == "" {
+		g.Community = "public"
+	}
+	if cfg.Version == SNMPVersion3 {
+		usm := &gosnmp.UsmSecurityParameters{UserName: cfg.User}
+		g.Version = gosnmp.Version3
+		g.SecurityModel = gosnmp.UserSecurityModel
+		g.MsgFlags = gosnmp.NoAuthNoPriv
+		if cfg.AuthPassword != "" {
+			usm.AuthenticationProtocol = snmpAuthProtocols[strings.ToUpper(cfg.AuthProtocol)]
+			usm.AuthenticationPassphrase = cfg.AuthPassword
+			g.MsgFlags = gosnmp.AuthNoPriv
+		}
+		if cfg.PrivPassword != "" {
+			usm.PrivacyProtocol = snmpPrivProtocols[strings.ToUpper(cfg.PrivProtocol)]
+			usm.PrivacyPassphrase = cfg.PrivPassword
+			g.MsgFlags = gosnmp.AuthPriv
+		}
+		g.SecurityParameters = usm
+	}
+	if err := g.Connect(); err != nil {
+		return nil, fmt.Errorf("snmp connect: %w", err)
+	}
+	return g, nil
+}
+
+// PollInterfaces reads counters for the given interfaces, each an ifIndex
+// or an ifName/ifDescr (e.g. "ge-0/0/1", "GigabitEthernet0/1")
+func (p *SNMPPoller) PollInterfaces(interfaces []string) ([]InterfaceCounters, error) {
+	g, err := p.client()
+	if err != nil {
+		return nil, err
+	}
+	defer g.Conn.Close()
+
+	indexes, err := p.resolveInterfaces(g, interfaces)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []InterfaceCounters
+	for _, iface := range indexes {
+		suffix := "." + strconv.Itoa(iface.Index)
+		oids := []string{
+			oidIfHCIn + suffix, oidIfHCOut + suffix, oidIfInErrors + suffix, oidIfOutErrors + suffix,
+			oidIfInDiscards + suffix, oidIfOutDiscard + suffix, oidIfHighSpeed + suffix,
+		}
+		pkt, err := g.Get(oids)
+		if err != nil {
+			return nil, fmt.Errorf("snmp get %s: %w", iface.Name, err)
+		}
+		if pkt.Error != gosnmp.NoError {
+			return nil, fmt.Errorf("snmp get %s: %s", iface.Name, pkt.Error)
+		}
+		c := InterfaceCounters{Index: iface.Index, Name: iface.Name, Time: time.Now()}
+		fields := []*uint64{&c.InOctets, &c.OutOctets, &c.InErrors, &c.OutErrors, &c.InDiscards, &c.OutDiscards, &c.SpeedMbps}
+		for i, v := range pkt.Variables {
+			if i >= len(fields) {
+				break
+			}
+			switch v.Type {
+			case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+				if i < 2 {
+					return nil, fmt.Errorf("interface %s has no 64-bit octet counters (IF-MIB ifXTable)", iface.Name)
+				}
+				continue
+			}
+			*fields[i] = gosnmp.ToBigInt(v.Value).Uint64()
+		}
+		out = append(out, c)
+	}
+
+	logging.Debug("snmp", "[SNMP] %s: polled %d interfaces", p.config.Host, len(out))
+	return out, nil
+}
+
+// resolveInterfaces maps configured names to ifIndex, walking ifName and
+// then ifDescr only when a name isn't numeric
+func (p *SNMPPoller) resolveInterfaces(g *gosnmp.GoSNMP, interfaces []string) ([]InterfaceCounters, error) {
+	var out []InterfaceCounters
+	var names map[string]int
+	for _, want := range interfaces {
+		if idx, err := strconv.Atoi(want); err == nil {
+			out = append(out, InterfaceCounters{Index: idx, Name: want})
+			continue
+		}
+		if names == nil {
+			names = map[string]int{}
+			for _, col := range []string{oidIfDescr, oidIfName} { // ifName wins on conflicts
+				pdus, err := g.BulkWalkAll(col)
+				if err != nil {
+					return nil, fmt.Errorf("snmp walk interface names: %w", err)
+				}
+				for _, pdu := range pdus {
+					b, ok := pdu.Value.([]byte)
+					if !ok {
+						continue
+					}
+					idx, err := strconv.Atoi(pdu.Name[strings.LastIndex(pdu.Name, ".")+1:])
+					if err == nil {
+						names[string(b)] = idx
+					}
+				}
+			}
+		}
+		idx, ok := names[want]
+		if !ok {
+			return nil, fmt.Errorf("interface %q not found on %s", want, p.config.Host)
+		}
+		out = append(out, InterfaceCounters{Index: idx, Name: want})
+	}
+	return out, nil
+}
+
+// CounterRates computes per-second rates from two readings of the same
+// interface. ok is false when the counters went backwards (device reboot or
+// counter reset), since no meaningful rate exists for that interval.
+func CounterRates(prev, cur InterfaceCounters) (InterfaceRates, bool) {
+	secs := cur.Time.Sub(prev.Time).Seconds()
+	if secs <= 0 || cur.InOctets < prev.InOctets || cur.OutOctets < prev.OutOctets {
+		return InterfaceRates{}, false
+	}
+	delta := func(a, b uint64) float64 {
+		if b < a {
+			return 0
+		}
+		return float64(b-a) / secs
+	}
+	r := InterfaceRates{
+		Index:       cur.Index,
+		Name:        cur.Name,
+		InBps:       delta(prev.InOctets, cur.InOctets) * 8,
+		OutBps:      delta(prev.OutOctets, cur.OutOctets) * 8,
+		InErrors:    delta(prev.InErrors, cur.InErrors),
+		OutErrors:   delta(prev.OutErrors, cur.OutErrors),
+		InDiscards:  delta(prev.InDiscards, cur.InDiscards),
+		OutDiscards: delta(prev.OutDiscards, cur.OutDiscards),
+	}
+	if cur.SpeedMbps > 0 {
+		r.InUtil = r.InBps / (float64(cur.SpeedMbps) * 1e6) * 100
+		r.OutUtil = r.OutBps / (float64(cur.SpeedMbps) * 1e6) * 100
+	}
+	return r, true
+}
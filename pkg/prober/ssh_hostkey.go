@@ -0,0 +1,71 @@
+package prober
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// HostKeyMismatchError is returned when a target presents a different host key
+// than the one pinned for it. Either the key was rotated or someone is in the middle.
+type HostKeyMismatchError struct {
+	Host     string
+	Expected string // SHA256 fingerprint of the pinned key
+	Got      string // SHA256 fingerprint of the offered key
+	Key      string // Offered key in authorized_keys format, for an admin to accept
+	JumpHost bool   // The mismatch is on a bastion, not the target itself
+}
+
+func (e *HostKeyMismatchError) Error() string {
+	return fmt.Sprintf("host key mismatch for %s: expected %s, got %s", e.Host, e.Expected, e.Got)
+}
+
+// ParseHostKey accepts an authorized_keys line ("ssh-ed25519 AAAA... comment")
+// or a known_hosts line ("host ssh-ed25519 AAAA...") and returns the key.
+func ParseHostKey(line string) (ssh.PublicKey, error) {
+	line = strings.TrimSpace(line)
+	if key, _, _, _, err := ssh.ParseAuthorizedKey([]byte(line)); err == nil {
+		return key, nil
+	}
+	_, _, key, _, _, err := ssh.ParseKnownHosts([]byte(line))
+	if err != nil {
+		return nil, fmt.Errorf("invalid host key: %w", err)
+	}
+	return key, nil
+}
+
+// FormatHostKey renders key in authorized_keys format without a trailing newline
+func FormatHostKey(key ssh.PublicKey) string {
+	return strings.TrimSpace(string(ssh.MarshalAuthorizedKey(key)))
+}
+
+// pinnedHostKeyCallback verifies against the pinned key, or accepts any key when
+// nothing is pinned yet (trust on first use). The offered key is kept in *seen.
+func pinnedHostKeyCallback(pinned string, seen *ssh.PublicKey) (ssh.HostKeyCallback, error) {
+	var want ssh.PublicKey
+	if pinned != "" {
+		key, err := ParseHostKey(pinned)
+		if err != nil {
+			return nil, err
+		}
+		want = key
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		*seen = key
+		if want == nil {
+			return nil
+		}
+		if key.Type() != want.Type() || !bytes.Equal(key.Marshal(), want.Marshal()) {
+			return &HostKeyMismatchError{
+				Host:     hostname,
+				Expected: ssh.FingerprintSHA256(want),
+				Got:      ssh.FingerprintSHA256(key),
+				Key:      FormatHostKey(key),
+			}
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,177 @@
+package prober
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"golang.org/x/crypto/ssh"
+)
+
+// SSHJumpHost is one bastion in a ProxyJump chain, with its own credentials
+type SSHJumpHost struct {
+	Host     string
+	Port     int
+	User     string // Defaults to the target user
+	Password string
+	KeyPath  string
+	KeyText  string
+	HostKey  string // Pinned key (authorized_keys format). Empty = trust on first use
+}
+
+// JumpHostStats tells whether a bastion is the slow part of the path
+type JumpHostStats struct {
+	Host        string
+	ConnectTime time.Duration // TCP + key exchange + auth, through the previous hops
+	RTT         time.Duration // SSH keepalive round trip, through the previous hops
+}
+
+// jumpChain connects through every configured bastion once and returns the
+// last one, or nil when the target is reached directly
+func (s *SSHSpeedTester) jumpChain() (*ssh.Client, error) {
+	if len(s.config.JumpHosts) == 0 {
+		return nil, nil
+	}
+	if len(s.jumpClients) == len(s.config.JumpHosts) {
+		return s.jumpClients[len(s.jumpClients)-1], nil
+	}
+
+	var via *ssh.Client
+	for i, jump := range s.config.JumpHosts {
+		port := jump.Port
+		if port == 0 {
+			port = 22
+		}
+		user := jump.User
+		if user == "" {
+			user = s.config.User
+		}
+		addr := fmt.Sprintf("%s:%d", jump.Host, port)
+
+		var seen ssh.PublicKey
+		hostKeyCallback, err := pinnedHostKeyCallback(jump.HostKey, &seen)
+		if err != nil {
+			s.closeJumpChain()
+			return nil, fmt.Errorf("jump host %s: %w", addr, err)
+		}
+		config := &ssh.ClientConfig{
+			User:            user,
+			Auth:            sshAuthMethods(jump.Password, jump.KeyText, jump.KeyPath),
+			HostKeyCallback: hostKeyCallback,
+			Timeout:         s.config.Timeout,
+		}
+
+		start := time.Now()
+		client, err := dialSSH(via, addr, config, nil)
+		if err != nil {
+			s.closeJumpChain()
+			var mismatch *HostKeyMismatchError
+			if errors.As(err, &mismatch) {
+				mismatch.JumpHost = true
+			}
+			return nil, fmt.Errorf("jump host %s: %w", addr, err)
+		}
+		stats := JumpHostStats{Host: addr, ConnectTime: time.Since(start), RTT: sshRTT(client)}
+		logging.Info("ssh", "[SSH] Jump host %s connected in %s, rtt %s", addr, stats.ConnectTime, stats.RTT)
+
+		if jump.HostKey == "" && seen != nil {
+			if s.learnedJumpKey == nil {
+				s.learnedJumpKey = make(map[int]string)
+			}
+			s.learnedJumpKey[i] = FormatHostKey(seen)
+		}
+		s.jumpClients = append(s.jumpClients, client)
+		s.jumpStats = append(s.jumpStats, stats)
+		via = client
+	}
+	return via, nil
+}
+
+// closeJumpChain tears the bastion connections down, innermost first
+func (s *SSHSpeedTester) closeJumpChain() {
+	for i := len(s.jumpClients) - 1; i >= 0; i-- {
+		s.jumpClients[i].Close()
+	}
+	s.jumpClients = nil
+}
+
+// LearnedJumpHostKeys returns host keys accepted on first use, by jump host index
+func (s *SSHSpeedTester) LearnedJumpHostKeys() map[int]string {
+	return s.learnedJumpKey
+}
+
+// dialSSH connects to addr directly, or tunnelled through via when it is set.
+// When timing is non-nil the TCP connect, key exchange and auth phases are
+// recorded, including those reached before a failed handshake.
+func dialSSH(via *ssh.Client, addr string, config *ssh.ClientConfig, timing *SSHSessionTiming) (*ssh.Client, error) {
+	start := time.Now()
+	var conn net.Conn
+	var err error
+	if via == nil {
+		conn, err = net.DialTimeout("tcp", addr, config.Timeout)
+	} else {
+		conn, err = via.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	connected := time.Now()
+	if timing != nil {
+		timing.TCPConnect = connected.Sub(start)
+	}
+
+	// The host key arrives with the server's key exchange reply; what follows is auth
+	var kexDone time.Time
+	keyAccepted := false
+	cfg := *config
+	cfg.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		kexDone = time.Now()
+		err := config.HostKeyCallback(hostname, remote, key)
+		keyAccepted = err == nil
+		return err
+	}
+	// A hung PAM/LDAP backend shows up as auth running until the failure
+	recordHandshake := func(done time.Time) {
+		if timing == nil || kexDone.IsZero() {
+			return
+		}
+		timing.KeyExchange = kexDone.Sub(connected)
+		if keyAccepted {
+			timing.Auth = done.Sub(kexDone)
+		}
+	}
+
+	// Tunnelled channels ignore deadlines, so bound the handshake with a timer
+	timer := time.AfterFunc(config.Timeout, func() { conn.Close() })
+	c, chans, reqs, err := ssh.NewClientConn(conn, addr, &cfg)
+	recordHandshake(time.Now())
+	if !timer.Stop() {
+		if err == nil {
+			c.Close()
+		}
+		return nil, fmt.Errorf("ssh handshake with %s: i/o timeout", addr)
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return ssh.NewClient(c, chans, reqs), nil
+}
+
+// sshRTT times the fastest of three keepalive requests. Servers answer
+// unknown global requests with a failure reply, which is all we need.
+func sshRTT(client *ssh.Client) time.Duration {
+	var best time.Duration
+	for i := 0; i < 3; i++ {
+		start := time.Now()
+		if _, _, err := client.SendRequest("keepalive@openssh.com", true, nil); err != nil {
+			break
+		}
+		if rtt := time.Since(start); best == 0 || rtt < best {
+			best = rtt
+		}
+	}
+	return best
+}
@@ -0,0 +1,167 @@
+package prober
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"golang.org/x/crypto/ssh"
+)
+
+// DefaultSSHParallelDuration bounds each direction of a parallel SSH test
+const DefaultSSHParallelDuration = 10 * time.Second
+
+// runParallel spreads the test over several channels and TCP connections.
+// A single channel is capped by its window size, which leaves long fat
+// pipes badly under-reported; N channels give N windows in flight.
+func (s *SSHSpeedTester) runParallel(first *ssh.Client, target string) (*SpeedResult, error) {
+	clients := []*ssh.Client{first}
+	defer func() {
+		// first is closed by Run
+		for _, c := range clients[1:] {
+			c.Close()
+		}
+	}()
+	for i := 1; i < s.config.Connections; i++ {
+		c, err := s.connect()
+		if err != nil {
+			return nil, fmt.Errorf("ssh connection %d failed: %w", i+1, err)
+		}
+		clients = append(clients, c)
+	}
+
+	logging.Info("ssh", "[SSH] Parallel test for %s: %d connection(s) x %d channel(s), %s per direction",
+		target, len(clients), s.config.Streams, s.config.Duration)
+
+	result := &SpeedResult{
+		Timestamp: time.Now(),
+	}
+
+	down, err := s.parallelTransfer(clients, s.streamDownload)
+	if err != nil {
+		logging.Error("ssh", "[SSH] Download test failed for %s: %v", target, err)
+		return nil, fmt.Errorf("download test failed: %w", err)
+	}
+	result.Download = down
+	result.DownloadSpeed = down.Mbps()
+	logging.Info("ssh", "[SSH] Download test for %s: %.2f Mbps over %d streams", target, result.DownloadSpeed, len(down.Streams))
+
+	up, err := s.parallelTransfer(clients, s.streamUpload)
+	if err != nil {
+		logging.Error("ssh", "[SSH] Upload test failed for %s: %v", target, err)
+		return nil, fmt.Errorf("upload test failed: %w", err)
+	}
+	result.Upload = up
+	result.UploadSpeed = up.Mbps()
+	logging.Info("ssh", "[SSH] Upload test for %s: %.2f Mbps over %d streams", target, result.UploadSpeed, len(up.Streams))
+
+	return result, nil
+}
+
+// parallelTransfer runs one stream per channel until Duration elapses and
+// aggregates the bytes moved. Streams that fail early keep what they moved.
+func (s *SSHSpeedTester) parallelTransfer(clients []*ssh.Client, stream func(context.Context, *ssh.Client, *atomic.Int64) error) (*TransferStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.config.Duration)
+	defer cancel()
+
+	total := len(clients) * s.config.Streams
+	counters := make([]atomic.Int64, total)
+	var wg sync.WaitGroup
+	var firstErr error
+	var errOnce sync.Once
+
+	start := time.Now()
+	for i := 0; i < total; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := stream(ctx, clients[i%len(clients)], &counters[i]); err != nil && ctx.Err() == nil {
+				errOnce.Do(func() { firstErr = err })
+			}
+		}(i)
+	}
+	wg.Wait()
+	end := time.Now()
+	elapsed := end.Sub(start)
+
+	stats := &TransferStats{
+		Protocol:    "ssh",
+		Duration:    elapsed,
+		Start:       start,
+		End:         end,
+		Retransmits: -1,
+	}
+	for i := range counters {
+		n := counters[i].Load()
+		stats.Bytes += n
+		stats.Streams = append(stats.Streams, StreamStats{Index: i, Bytes: n, Mbps: mbps(n, elapsed)})
+	}
+	if stats.Bytes == 0 {
+		if firstErr == nil {
+			firstErr = fmt.Errorf("no data transferred")
+		}
+		return nil, firstErr
+	}
+	if firstErr != nil {
+		logging.Warn("ssh", "[SSH] Some streams to %s:%d failed: %v", s.config.Host, s.config.Port, firstErr)
+	}
+	return stats, nil
+}
+
+// streamDownload reads an endless remote stream until ctx ends
+func (s *SSHSpeedTester) streamDownload(ctx context.Context, client *ssh.Client, counter *atomic.Int64) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	reader, err := session.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Start("cat /dev/zero"); err != nil {
+		return err
+	}
+
+	// Closing the channel is the only portable way to stop the remote cat
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	_, err = io.Copy(&countingWriter{counter: counter}, reader)
+	return err
+}
+
+// streamUpload writes zeroes into a remote sink until ctx ends
+func (s *SSHSpeedTester) streamUpload(ctx context.Context, client *ssh.Client, counter *atomic.Int64) error {
+	session, err := client.NewSession()
+	if err != nil {
+		return err
+	}
+	defer session.Close()
+
+	stdin, err := session.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := session.Start("cat > /dev/null"); err != nil {
+		return err
+	}
+
+	stop := context.AfterFunc(ctx, func() { session.Close() })
+	defer stop()
+
+	buf := make([]byte, 32*1024)
+	for ctx.Err() == nil {
+		n, err := stdin.Write(buf)
+		counter.Add(int64(n))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,216 @@
+package prober
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"golang.org/x/crypto/ssh"
+)
+
+// Remote probe tools, in order of preference
+const (
+	RemoteToolMTR  = "mtr"
+	RemoteToolPing = "ping"
+)
+
+// RemoteTraceResult is the path from an SSH host back toward a destination
+type RemoteTraceResult struct {
+	Destination string
+	Tool        string     // RemoteToolMTR or RemoteToolPing
+	Trace       *MTRResult // For ping, a single hop for the destination
+	LatencyMs   float64    // Average RTT to the destination
+	Loss        float64    // Loss percentage to the destination
+}
+
+// SSHRemoteProber uses an SSH target as a vantage point: it runs mtr (or ping
+// when mtr is missing) on the remote host and brings the result back.
+// Connection handling, host key pinning and jump hosts are shared with SSHSpeedTester.
+type SSHRemoteProber struct {
+	*SSHSpeedTester
+	Destination string // Empty = our own address as the remote sees it ($SSH_CLIENT); required with jump hosts
+	Count       int
+	Timeout     time.Duration
+}
+
+func NewSSHRemoteProber(cfg SSHConfig, destination string) *SSHRemoteProber {
+	return &SSHRemoteProber{
+		SSHSpeedTester: NewSSHSpeedTester(cfg),
+		Destination:    destination,
+		Count:          10,
+		Timeout:        60 * time.Second,
+	}
+}
+
+func (p *SSHRemoteProber) Run() (*RemoteTraceResult, error) {
+	// Behind jump hosts $SSH_CLIENT is the last bastion, not us
+	if p.Destination == "" && len(p.config.JumpHosts) > 0 {
+		return nil, fmt.Errorf("reverse path through jump hosts needs a destination: set one in the target or RS_PUBLIC_ADDRESS")
+	}
+	defer p.closeJumpChain()
+	client, err := p.connect()
+	if err != nil {
+		return nil, fmt.Errorf("ssh connection failed: %w", err)
+	}
+	defer client.Close()
+
+	dest := p.Destination
+	if dest == "" {
+		dest, err = p.clientAddress(client)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// SECURITY: dest ends up in a remote shell command line
+	if err := ValidateTarget(dest); err != nil {
+		return nil, fmt.Errorf("invalid destination: %w", err)
+	}
+
+	count := p.Count
+	if count <= 0 {
+		count = 10
+	}
+	remote := fmt.Sprintf("%s:%d", p.config.Host, p.config.Port)
+
+	out, mtrErr := p.exec(client, fmt.Sprintf("mtr --json -n -c %d %s", count, dest))
+	if mtrErr == nil {
+		trace, err := parseMTRJSON(out, dest)
+		if err == nil && len(trace.Hops) > 0 {
+			logging.Info("ssh", "[Remote] mtr from %s to %s: %d hops", remote, dest, len(trace.Hops))
+			return newRemoteTraceResult(dest, RemoteToolMTR, trace), nil
+		}
+		mtrErr = err
+	}
+
+	// Older mtr builds lack --json but still have the plain report
+	out, err = p.exec(client, fmt.Sprintf("mtr -n -r -w -c %d %s", count, dest))
+	if err == nil {
+		if trace := parseMTRReport(out, dest); len(trace.Hops) > 0 {
+			logging.Info("ssh", "[Remote] mtr report from %s to %s: %d hops", remote, dest, len(trace.Hops))
+			return newRemoteTraceResult(dest, RemoteToolMTR, trace), nil
+		}
+	}
+
+	logging.Debug("ssh", "[Remote] mtr unavailable on %s (%v), falling back to ping", remote, mtrErr)
+	out, err = p.exec(client, fmt.Sprintf("ping -n -c %d %s", count, dest))
+	// ping exits non-zero on partial loss; judge by the summary instead
+	trace, parseErr := parsePingSummary(out, dest)
+	if parseErr != nil {
+		if err != nil {
+			return nil, fmt.Errorf("remote ping failed: %w", err)
+		}
+		return nil, parseErr
+	}
+	logging.Info("ssh", "[Remote] ping from %s to %s: %.1fms, %.0f%% loss", remote, dest, trace.Hops[0].Avg, trace.Hops[0].Loss)
+	return newRemoteTraceResult(dest, RemoteToolPing, trace), nil
+}
+
+// clientAddress asks the remote shell which address our connection came from
+func (p *SSHRemoteProber) clientAddress(client *ssh.Client) (string, error) {
+	out, err := p.exec(client, "echo $SSH_CLIENT")
+	if err != nil {
+		return "", fmt.Errorf("detect own address: %w", err)
+	}
+	fields := strings.Fields(string(out))
+	if len(fields) == 0 {
+		return "", fmt.Errorf("detect own address: SSH_CLIENT is not set on the remote host, configure a destination")
+	}
+	return fields[0], nil
+}
+
+// exec runs cmd and returns stdout, giving up after Timeout
+func (p *SSHRemoteProber) exec(client *ssh.Client, cmd string) ([]byte, error) {
+	session, err := client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	var stdout, stderr bytes.Buffer
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Start(cmd); err != nil {
+		return nil, err
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- session.Wait() }()
+	select {
+	case err = <-done:
+	case <-time.After(p.Timeout):
+		session.Close()
+		return nil, fmt.Errorf("remote command timed out after %s", p.Timeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return stdout.Bytes(), fmt.Errorf("%w: %s", err, msg)
+		}
+	}
+	return stdout.Bytes(), err
+}
+
+func newRemoteTraceResult(dest, tool string, trace *MTRResult) *RemoteTraceResult {
+	res := &RemoteTraceResult{Destination: dest, Tool: tool, Trace: trace}
+	if n := len(trace.Hops); n > 0 {
+		last := trace.Hops[n-1]
+		res.LatencyMs = last.Avg
+		res.Loss = last.Loss
+	}
+	return res
+}
+
+// parseMTRReport reads `mtr -r -w` output:
+//
+//	HOST: host          Loss%   Snt   Last   Avg  Best  Wrst StDev
+//	  1.|-- 10.0.0.1     0.0%    10    0.3   0.3   0.2   0.4   0.1
+func parseMTRReport(output []byte, target string) *MTRResult {
+	res := &MTRResult{Target: target, Timestamp: time.Now()}
+	for _, line := range strings.Split(string(output), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 8 || !strings.Contains(fields[0], ".|") && !strings.Contains(fields[0], ".`") {
+			continue
+		}
+		hopNum, err := strconv.Atoi(fields[0][:strings.Index(fields[0], ".")])
+		if err != nil {
+			continue
+		}
+		hop := MTRHop{Hop: hopNum, Host: fields[1]}
+		hop.Loss, _ = strconv.ParseFloat(strings.TrimSuffix(fields[2], "%"), 64)
+		hop.Last, _ = strconv.ParseFloat(fields[4], 64)
+		hop.Avg, _ = strconv.ParseFloat(fields[5], 64)
+		hop.Best, _ = strconv.ParseFloat(fields[6], 64)
+		hop.Worst, _ = strconv.ParseFloat(fields[7], 64)
+		res.Hops = append(res.Hops, hop)
+	}
+	return res
+}
+
+var (
+	pingLossPattern = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+	pingRTTPattern  = regexp.MustCompile(`= ([\d.]+)/([\d.]+)/([\d.]+)`)
+)
+
+// parsePingSummary turns the iputils/BSD/busybox ping summary into a one-hop trace
+func parsePingSummary(output []byte, target string) (*MTRResult, error) {
+	m := pingLossPattern.FindSubmatch(output)
+	if m == nil {
+		return nil, fmt.Errorf("remote ping produced no summary")
+	}
+	sent, _ := strconv.Atoi(string(m[1]))
+	received, _ := strconv.Atoi(string(m[2]))
+	if sent == 0 {
+		return nil, fmt.Errorf("remote ping sent no packets")
+	}
+	hop := MTRHop{Hop: 1, Host: target, Loss: float64(sent-received) / float64(sent) * 100}
+	if rtt := pingRTTPattern.FindSubmatch(output); rtt != nil {
+		hop.Best, _ = strconv.ParseFloat(string(rtt[1]), 64)
+		hop.Avg, _ = strconv.ParseFloat(string(rtt[2]), 64)
+		hop.Worst, _ = strconv.ParseFloat(string(rtt[3]), 64)
+		hop.Last = hop.Avg
+	}
+	return &MTRResult{Target: target, Hops: []MTRHop{hop}, Timestamp: time.Now()}, nil
+}
@@ -0,0 +1,66 @@
+package prober
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// DefaultSSHSessionCommand is cheap on every POSIX shell and on Windows cmd
+const DefaultSSHSessionCommand = "echo routelens"
+
+// SSHSessionTiming breaks an SSH login down into phases, so a slow PAM/LDAP
+// backend (auth) can be told apart from a slow network (connect, keepalive)
+type SSHSessionTiming struct {
+	TCPConnect  time.Duration
+	KeyExchange time.Duration // Until the server's host key arrived
+	Auth        time.Duration // From host key to login complete, or to the failure
+	ChannelOpen time.Duration
+	Command     time.Duration // exec request, remote process and exit status
+	Keepalive   time.Duration // Bare SSH round trip, for comparison with Command
+	JumpHosts   []JumpHostStats
+}
+
+// MeasureSession logs in once and times each phase instead of moving data.
+// Timings gathered before a failure are returned along with the error.
+func (s *SSHSpeedTester) MeasureSession(command string) (*SSHSessionTiming, error) {
+	if command == "" {
+		command = DefaultSSHSessionCommand
+	}
+	target := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+
+	timing := &SSHSessionTiming{}
+	s.timing = timing
+	defer func() { s.timing = nil }()
+	defer s.closeJumpChain()
+
+	client, err := s.connect()
+	timing.JumpHosts = s.jumpStats
+	if err != nil {
+		return timing, fmt.Errorf("ssh connection failed: %w", err)
+	}
+	defer client.Close()
+
+	start := time.Now()
+	session, err := client.NewSession()
+	if err != nil {
+		return timing, fmt.Errorf("channel open failed: %w", err)
+	}
+	timing.ChannelOpen = time.Since(start)
+
+	start = time.Now()
+	err = session.Run(command)
+	timing.Command = time.Since(start)
+	session.Close()
+	if err != nil {
+		timing.Command = 0
+		return timing, fmt.Errorf("command %q failed: %w", command, err)
+	}
+
+	timing.Keepalive = sshRTT(client)
+
+	logging.Debug("ssh", "[SSH] Session timing for %s: tcp=%s kex=%s auth=%s channel=%s cmd=%s keepalive=%s",
+		target, timing.TCPConnect, timing.KeyExchange, timing.Auth, timing.ChannelOpen, timing.Command, timing.Keepalive)
+	return timing, nil
+}
@@ -0,0 +1,116 @@
+package prober
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"path"
+	"time"
+
+	"github.com/pkg/sftp"
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"golang.org/x/crypto/ssh"
+)
+
+// runSFTP measures throughput through the SFTP subsystem instead of an exec session.
+// Works on SFTP-only, chrooted and ForceCommand accounts as well as Windows OpenSSH.
+// A temporary file is uploaded, read back for the download figure and then removed.
+func (s *SSHSpeedTester) runSFTP(client *ssh.Client, target string) (*SpeedResult, error) {
+	sc, err := sftp.NewClient(client, sftp.UseConcurrentWrites(true))
+	if err != nil {
+		logging.Error("ssh", "[SFTP] Subsystem unavailable on %s: %v", target, err)
+		return nil, fmt.Errorf("sftp subsystem failed: %w", err)
+	}
+	defer sc.Close()
+
+	remotePath, err := sftpTempPath(s.config.RemoteDir)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := sc.Remove(remotePath); err != nil {
+			logging.Warn("ssh", "[SFTP] Failed to remove temporary file %s on %s: %v", remotePath, target, err)
+		}
+	}()
+
+	result := &SpeedResult{
+		Timestamp: time.Now(),
+	}
+
+	// 1. Upload first so the download has a file of the right size to read
+	logging.Debug("ssh", "[SFTP] Starting upload test for %s (%d bytes to %s)", target, s.config.TestBytes, remotePath)
+	result.UploadPhase.Start = time.Now()
+	upSpeed, err := s.sftpUpload(sc, remotePath)
+	if err != nil {
+		logging.Error("ssh", "[SFTP] Upload test failed for %s: %v", target, err)
+		return nil, fmt.Errorf("upload test failed: %w", err)
+	}
+	result.UploadPhase.End = time.Now()
+	result.UploadSpeed = upSpeed
+	logging.Info("ssh", "[SFTP] Upload test for %s: %.2f Mbps", target, upSpeed)
+
+	// 2. Read the same file back
+	logging.Debug("ssh", "[SFTP] Starting download test for %s", target)
+	result.DownloadPhase.Start = time.Now()
+	downSpeed, err := s.sftpDownload(sc, remotePath)
+	if err != nil {
+		logging.Error("ssh", "[SFTP] Download test failed for %s: %v", target, err)
+		return nil, fmt.Errorf("download test failed: %w", err)
+	}
+	result.DownloadPhase.End = time.Now()
+	result.DownloadSpeed = downSpeed
+	logging.Info("ssh", "[SFTP] Download test for %s: %.2f Mbps", target, downSpeed)
+
+	return result, nil
+}
+
+func (s *SSHSpeedTester) sftpUpload(sc *sftp.Client, remotePath string) (float64, error) {
+	f, err := sc.Create(remotePath)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	n, err := f.ReadFrom(&randomReader{remaining: s.config.TestBytes})
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	duration := time.Since(start)
+	if err != nil {
+		return 0, err
+	}
+	return mbps(n, duration), nil
+}
+
+func (s *SSHSpeedTester) sftpDownload(sc *sftp.Client, remotePath string) (float64, error) {
+	f, err := sc.Open(remotePath)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	start := time.Now()
+	n, err := f.WriteTo(io.Discard)
+	duration := time.Since(start)
+	if err != nil {
+		return 0, err
+	}
+	if n == 0 {
+		return 0, fmt.Errorf("remote file is empty")
+	}
+	return mbps(n, duration), nil
+}
+
+// sftpTempPath returns a unique file name inside dir (the login directory when empty)
+func sftpTempPath(dir string) (string, error) {
+	var suffix [8]byte
+	if _, err := rand.Read(suffix[:]); err != nil {
+		return "", err
+	}
+	name := ".routelens-speedtest-" + hex.EncodeToString(suffix[:])
+	if dir == "" {
+		return name, nil
+	}
+	return path.Join(dir, name), nil
+}
@@ -1,6 +1,7 @@
 package prober
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -14,6 +15,12 @@ import (
 // DefaultTestSize is the default amount of data to transfer (5MB)
 const DefaultTestSize = 5 * 1024 * 1024
 
+// SSH speed test methods
+const (
+	SSHMethodExec = "exec" // cat over an exec session (needs a POSIX shell)
+	SSHMethodSFTP = "sftp" // temporary file over the SFTP subsystem
+)
+
 type SSHConfig struct {
 	Host      string
 	Port      int
@@ -22,12 +29,35 @@ type SSHConfig struct {
 	KeyPath   string
 	KeyText   string
 	Timeout   time.Duration
-	TestBytes int64 // How many bytes to test. If 0, uses DefaultTestSize
+	TestBytes int64  // How many bytes to test. If 0, uses DefaultTestSize
+	Method    string // SSHMethodExec (default) or SSHMethodSFTP
+	HostKey   string // Pinned host key (authorized_keys format). Empty = trust on first use
+	RemoteDir string // SFTP only: where the temporary file goes. Empty = login directory
+
+	// Parallel exec mode: Streams channels on each of Connections TCP connections,
+	// every one pushing data until Duration elapses
+	Streams     int
+	Connections int
+	Duration    time.Duration
+
+	// JumpHosts are bastions to chain through in order (ProxyJump)
+	JumpHosts []SSHJumpHost
 }
 
 // SSHSpeedTester handles the SSH connection and speed measurement
 type SSHSpeedTester struct {
 	config SSHConfig
+
+	// learnedHostKey is the key accepted on first use, see LearnedHostKey
+	learnedHostKey string
+
+	// Jump host chain, built once and shared by every connection to the target
+	jumpClients    []*ssh.Client
+	jumpStats      []JumpHostStats
+	learnedJumpKey map[int]string
+
+	// timing, when set, receives connection phase timings from connect
+	timing *SSHSessionTiming
 }
 
 func NewSSHSpeedTester(cfg SSHConfig) *SSHSpeedTester {
@@ -37,18 +67,35 @@ func NewSSHSpeedTester(cfg SSHConfig) *SSHSpeedTester {
 	if cfg.TestBytes == 0 {
 		cfg.TestBytes = DefaultTestSize
 	}
+	if cfg.Method == "" {
+		cfg.Method = SSHMethodExec
+	}
+	if cfg.Streams < 1 {
+		cfg.Streams = 1
+	}
+	if cfg.Connections < 1 {
+		cfg.Connections = 1
+	}
+	if cfg.Duration == 0 && cfg.Streams*cfg.Connections > 1 {
+		cfg.Duration = DefaultSSHParallelDuration
+	}
 	return &SSHSpeedTester{config: cfg}
 }
 
 func (s *SSHSpeedTester) Run() (*SpeedResult, error) {
 	target := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
 	logging.Info("ssh", "[SSH] Starting speed test for %s@%s", s.config.User, target)
+	// Registered first so bastions close after the target connection
+	defer s.closeJumpChain()
 
 	client, err := s.connect()
 	if err != nil {
 		// Categorize SSH connection errors for better diagnostics
 		errMsg := err.Error()
-		if strings.Contains(errMsg, "unable to authenticate") || strings.Contains(errMsg, "no supported methods") {
+		var mismatch *HostKeyMismatchError
+		if errors.As(err, &mismatch) {
+			logging.Error("ssh", "[SSH] Host key for %s changed: expected %s, got %s", target, mismatch.Expected, mismatch.Got)
+		} else if strings.Contains(errMsg, "unable to authenticate") || strings.Contains(errMsg, "no supported methods") {
 			logging.Error("ssh", "[SSH] Authentication failed for %s: invalid credentials or key", target)
 		} else if strings.Contains(errMsg, "connection refused") {
 			logging.Error("ssh", "[SSH] Connection refused by %s: port closed or firewall blocking", target)
@@ -64,6 +111,30 @@ func (s *SSHSpeedTester) Run() (*SpeedResult, error) {
 	defer client.Close()
 	logging.Info("ssh", "[SSH] Connected to %s successfully", target)
 
+	var result *SpeedResult
+	switch {
+	case s.config.Method == SSHMethodSFTP:
+		result, err = s.runSFTP(client, target)
+	case s.config.Duration > 0:
+		result, err = s.runParallel(client, target)
+	default:
+		result, err = s.runExec(client, target)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Through a bastion, compare each hop's RTT with the end-to-end one
+	if len(s.jumpStats) > 0 {
+		result.JumpHosts = s.jumpStats
+		result.Latency = sshRTT(client)
+		logging.Info("ssh", "[SSH] End-to-end RTT to %s through %d jump host(s): %s", target, len(s.jumpStats), result.Latency)
+	}
+	return result, nil
+}
+
+// runExec measures one byte-bounded transfer per direction over exec sessions
+func (s *SSHSpeedTester) runExec(client *ssh.Client, target string) (*SpeedResult, error) {
 	result := &SpeedResult{
 		Timestamp: time.Now(),
 	}
@@ -71,22 +142,26 @@ func (s *SSHSpeedTester) Run() (*SpeedResult, error) {
 	// 1. Measure Download Speed (Remote -> Local)
 	// Command: cat /dev/zero | head -c <TestBytes>
 	logging.Debug("ssh", "[SSH] Starting download test for %s (%d bytes)", target, s.config.TestBytes)
+	result.DownloadPhase.Start = time.Now()
 	downSpeed, err := s.measureDownload(client)
 	if err != nil {
 		logging.Error("ssh", "[SSH] Download test failed for %s: %v", target, err)
 		return nil, fmt.Errorf("download test failed: %w", err)
 	}
+	result.DownloadPhase.End = time.Now()
 	result.DownloadSpeed = downSpeed
 	logging.Info("ssh", "[SSH] Download test for %s: %.2f Mbps", target, downSpeed)
 
 	// 2. Measure Upload Speed (Local -> Remote)
 	// Command: cat > /dev/null
 	logging.Debug("ssh", "[SSH] Starting upload test for %s (%d bytes)", target, s.config.TestBytes)
+	result.UploadPhase.Start = time.Now()
 	upSpeed, err := s.measureUpload(client)
 	if err != nil {
 		logging.Error("ssh", "[SSH] Upload test failed for %s: %v", target, err)
 		return nil, fmt.Errorf("upload test failed: %w", err)
 	}
+	result.UploadPhase.End = time.Now()
 	result.UploadSpeed = upSpeed
 	logging.Info("ssh", "[SSH] Upload test for %s: %.2f Mbps", target, upSpeed)
 
@@ -94,18 +169,55 @@ func (s *SSHSpeedTester) Run() (*SpeedResult, error) {
 }
 
 func (s *SSHSpeedTester) connect() (*ssh.Client, error) {
+	// Extra parallel connections must present the key the first one did
+	pinned := s.config.HostKey
+	if pinned == "" {
+		pinned = s.learnedHostKey
+	}
+	var seen ssh.PublicKey
+	hostKeyCallback, err := pinnedHostKeyCallback(pinned, &seen)
+	if err != nil {
+		return nil, err
+	}
+
+	config := &ssh.ClientConfig{
+		User:            s.config.User,
+		Auth:            sshAuthMethods(s.config.Password, s.config.KeyText, s.config.KeyPath),
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         s.config.Timeout,
+	}
+
+	via, err := s.jumpChain()
+	if err != nil {
+		return nil, err
+	}
+
+	target := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
+	client, err := dialSSH(via, target, config, s.timing)
+	if err != nil {
+		return nil, err
+	}
+	// Only a key that led to a successful login is worth pinning
+	if s.config.HostKey == "" && s.learnedHostKey == "" && seen != nil {
+		s.learnedHostKey = FormatHostKey(seen)
+	}
+	return client, nil
+}
+
+// sshAuthMethods offers every credential that is configured; unreadable keys are skipped
+func sshAuthMethods(password, keyText, keyPath string) []ssh.AuthMethod {
 	auths := []ssh.AuthMethod{}
-	if s.config.Password != "" {
-		auths = append(auths, ssh.Password(s.config.Password))
+	if password != "" {
+		auths = append(auths, ssh.Password(password))
 	}
-	if s.config.KeyText != "" {
-		signer, err := ssh.ParsePrivateKey([]byte(s.config.KeyText))
+	if keyText != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(keyText))
 		if err == nil {
 			auths = append(auths, ssh.PublicKeys(signer))
 		}
 	}
-	if s.config.KeyPath != "" {
-		key, err := os.ReadFile(s.config.KeyPath)
+	if keyPath != "" {
+		key, err := os.ReadFile(keyPath)
 		if err == nil {
 			signer, err := ssh.ParsePrivateKey(key)
 			if err == nil {
@@ -113,16 +225,13 @@ func (s *SSHSpeedTester) connect() (*ssh.Client, error) {
 			}
 		}
 	}
+	return auths
+}
 
-	config := &ssh.ClientConfig{
-		User:            s.config.User,
-		Auth:            auths,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(), // Note: In production, we might want to store host keys
-		Timeout:         s.config.Timeout,
-	}
-
-	target := fmt.Sprintf("%s:%d", s.config.Host, s.config.Port)
-	return ssh.Dial("tcp", target, config)
+// LearnedHostKey returns the host key accepted on first use when no key was
+// pinned, so the caller can store it. Empty if a key was pinned or login failed.
+func (s *SSHSpeedTester) LearnedHostKey() string {
+	return s.learnedHostKey
 }
 
 func (s *SSHSpeedTester) measureDownload(client *ssh.Client) (float64, error) {
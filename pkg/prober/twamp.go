@@ -0,0 +1,387 @@
+package prober
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+	"golang.org/x/net/ipv4"
+)
+
+// TWAMP-Light (RFC 5357 unauthenticated mode) test packets.
+//
+//	Sender:    seq(4) timestamp(8) error-estimate(2) padding...
+//	Reflector: seq(4) timestamp(8) error-estimate(2) MBZ(2) receive-timestamp(8)
+//	           sender-seq(4) sender-timestamp(8) sender-error-estimate(2) MBZ(2)
+//	           sender-ttl(1) padding...
+const (
+	twampReflectorSize = 41
+	DefaultTWAMPPort   = 862
+
+	// Error estimate: S (synchronized) bit, scale 0, multiplier 1
+	twampErrorEstimate       = 0x0001
+	twampErrorEstimateSynced = 0x8001
+
+	// Reflector sessions are keyed by sender address; idle ones are swept
+	// on a timer. Senders beyond the cap are reflected statelessly.
+	twampSessionIdle = 30 * time.Second
+	twampMaxSessions = 1024
+)
+
+// --- Reflector ---
+
+// TWAMPReflector answers TWAMP-Light test packets. It keeps a sequence
+// counter per sender so senders can tell forward from reverse loss.
+type TWAMPReflector struct {
+	// Synced sets the S bit in replies, claiming this host's clock is
+	// synchronized to UTC (e.g. by NTP or PTP); set from RS_TWAMP_SYNCED
+	Synced bool
+
+	mu       sync.Mutex
+	sessions map[string]*twampSession
+	conn     net.PacketConn
+}
+
+type twampSession struct {
+	seq      uint32
+	lastSeen time.Time
+}
+
+func NewTWAMPReflector() *TWAMPReflector {
+	return &TWAMPReflector{sessions: make(map[string]*twampSession)}
+}
+
+// ListenAndServe reflects on addr (e.g. ":862") until Close is called
+func (r *TWAMPReflector) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(conn)
+}
+
+func (r *TWAMPReflector) Serve(conn net.PacketConn) error {
+	r.mu.Lock()
+	r.conn = conn
+	r.mu.Unlock()
+	logging.Info("twamp", "TWAMP-Light reflector listening on %s", conn.LocalAddr())
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go r.sweepSessions(stop)
+
+	// The received TTL goes back to the sender; not every socket can report it
+	pc := ipv4.NewPacketConn(conn)
+	withTTL := pc.SetControlMessage(ipv4.FlagTTL, true) == nil
+
+	buf := make([]byte, 65535)
+	for {
+		var n, ttl int
+		var from net.Addr
+		var err error
+		if withTTL {
+			var cm *ipv4.ControlMessage
+			n, cm, from, err = pc.ReadFrom(buf)
+			if cm != nil {
+				ttl = cm.TTL
+			}
+		} else {
+			n, from, err = conn.ReadFrom(buf)
+		}
+		received := time.Now()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		// Shorter requests would make us an amplifier; senders pad to the
+		// reply size so the reply is never larger than the request
+		if n < twampReflectorSize {
+			continue
+		}
+
+		reply := make([]byte, n)
+		binary.BigEndian.PutUint32(reply[0:4], r.nextSeq(from.String(), binary.BigEndian.Uint32(buf[0:4]), received))
+		binary.BigEndian.PutUint64(reply[16:24], ntpTimestamp(received))
+		copy(reply[24:38], buf[0:14]) // Sender seq, timestamp, error estimate
+		reply[40] = byte(ttl)
+		estimate := uint16(twampErrorEstimate)
+		if r.Synced {
+			estimate = twampErrorEstimateSynced
+		}
+		binary.BigEndian.PutUint16(reply[12:14], estimate)
+		binary.BigEndian.PutUint64(reply[4:12], ntpTimestamp(time.Now()))
+		if _, err := conn.WriteTo(reply, from); err != nil {
+			logging.Debug("twamp", "reflect to %s failed: %v", from, err)
+		}
+	}
+}
+
+// nextSeq returns the reflector's sequence number for a sender's packet.
+// With the session table full, new senders get their own number back,
+// as from a stateless reflector.
+func (r *TWAMPReflector) nextSeq(sender string, senderSeq uint32, now time.Time) uint32 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sender]
+	switch {
+	case ok && now.Sub(s.lastSeen) <= twampSessionIdle:
+		s.seq++
+	case ok || len(r.sessions) < twampMaxSessions:
+		s = &twampSession{}
+		r.sessions[sender] = s
+	default:
+		return senderSeq
+	}
+	s.lastSeen = now
+	return s.seq
+}
+
+// sweepSessions drops idle sessions until stop is closed
+func (r *TWAMPReflector) sweepSessions(stop <-chan struct{}) {
+	ticker := time.NewTicker(twampSessionIdle)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case now := <-ticker.C:
+			r.mu.Lock()
+			for k, v := range r.sessions {
+				if now.Sub(v.lastSeen) > twampSessionIdle {
+					delete(r.sessions, k)
+				}
+			}
+			r.mu.Unlock()
+		}
+	}
+}
+
+func (r *TWAMPReflector) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.Close()
+}
+
+// --- Sender ---
+
+type TWAMPConfig struct {
+	Address     string // host:port of the reflector
+	Count       int
+	Interval    time.Duration
+	PacketSize  int           // Sender packet size incl. padding, at least 41 so replies match
+	Wait        time.Duration // How long to wait for stragglers after the last packet
+	ClockSynced bool          // Both ends are synchronized, so one-way delays are meaningful
+}
+
+// TWAMPResult summarizes one test session. Loss is in percent. Forward is
+// sender -> reflector, Reverse is reflector -> sender.
+type TWAMPResult struct {
+	Sent     int
+	Received int
+
+	RTTAvg time.Duration // Excludes reflector processing time
+	RTTMin time.Duration
+	RTTMax time.Duration
+
+	Loss float64
+	// Directional loss needs a stateful reflector (its own sequence numbers).
+	// Stateless reflectors echo ours, and only Loss is known.
+	Directional bool
+	ForwardLoss float64
+	ReverseLoss float64
+
+	// Delay variation needs no clock sync: a constant offset cancels out
+	ForwardJitter time.Duration
+	ReverseJitter time.Duration
+
+	// One-way delays, only set when ClockSynced and every reply carried the
+	// reflector's S bit; without it the delays include the clock offset
+	ReflectorSynced bool
+	Synced          bool
+	ForwardDelay    time.Duration
+	ReverseDelay    time.Duration
+}
+
+type TWAMPSender struct {
+	config TWAMPConfig
+}
+
+func NewTWAMPSender(cfg TWAMPConfig) *TWAMPSender {
+	if cfg.Count <= 0 {
+		cfg.Count = 100
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 20 * time.Millisecond
+	}
+	if cfg.PacketSize < twampReflectorSize {
+		cfg.PacketSize = twampReflectorSize
+	}
+	if cfg.Wait <= 0 {
+		cfg.Wait = 2 * time.Second
+	}
+	return &TWAMPSender{config: cfg}
+}
+
+type twampSample struct {
+	seq     uint32
+	reflSeq uint32
+	rtt     time.Duration
+	fwd     time.Duration // T2 - T1, includes clock offset
+	rev     time.Duration // T4 - T3, includes clock offset
+	synced  bool          // The reflector set the S bit in its error estimate
+}
+
+func (s *TWAMPSender) Run() (*TWAMPResult, error) {
+	cfg := s.config
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	sendTimes := make([]time.Time, cfg.Count)
+	var mu sync.Mutex
+	samples := make(map[uint32]twampSample)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 65535)
+		for {
+			n, err := conn.Read(buf)
+			t4 := time.Now()
+			if err != nil {
+				return
+			}
+			if n < twampReflectorSize {
+				continue
+			}
+			seq := binary.BigEndian.Uint32(buf[24:28])
+			if int(seq) >= cfg.Count {
+				continue
+			}
+			mu.Lock()
+			t1 := sendTimes[seq]
+			_, dup := samples[seq]
+			mu.Unlock()
+			if t1.IsZero() || dup {
+				continue
+			}
+			t2 := ntpTime(binary.BigEndian.Uint64(buf[16:24]), t1)
+			t3 := ntpTime(binary.BigEndian.Uint64(buf[4:12]), t1)
+			rtt := t4.Sub(t1) - t3.Sub(t2)
+			if rtt < 0 {
+				rtt = t4.Sub(t1)
+			}
+			mu.Lock()
+			samples[seq] = twampSample{
+				seq:     seq,
+				reflSeq: binary.BigEndian.Uint32(buf[0:4]),
+				rtt:     rtt,
+				fwd:     t2.Sub(t1),
+				rev:     t4.Sub(t3),
+				synced:  binary.BigEndian.Uint16(buf[12:14])&0x8000 != 0,
+			}
+			mu.Unlock()
+		}
+	}()
+
+	estimate := uint16(twampErrorEstimate)
+	if cfg.ClockSynced {
+		estimate = twampErrorEstimateSynced
+	}
+	pkt := make([]byte, cfg.PacketSize)
+	ticker := time.NewTicker(cfg.Interval)
+	for i := 0; i < cfg.Count; i++ {
+		if i > 0 {
+			<-ticker.C
+		}
+		now := time.Now()
+		binary.BigEndian.PutUint32(pkt[0:4], uint32(i))
+		binary.BigEndian.PutUint64(pkt[4:12], ntpTimestamp(now))
+		binary.BigEndian.PutUint16(pkt[12:14], estimate)
+		mu.Lock()
+		sendTimes[i] = now
+		mu.Unlock()
+		if _, err := conn.Write(pkt); err != nil {
+			ticker.Stop()
+			return nil, fmt.Errorf("twamp send failed: %w", err)
+		}
+	}
+	ticker.Stop()
+
+	time.Sleep(cfg.Wait)
+	conn.SetReadDeadline(time.Now())
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	return summarizeTWAMP(cfg, samples), nil
+}
+
+func summarizeTWAMP(cfg TWAMPConfig, samples map[uint32]twampSample) *TWAMPResult {
+	res := &TWAMPResult{Sent: cfg.Count, Received: len(samples)}
+	res.Loss = float64(res.Sent-res.Received) / float64(res.Sent) * 100
+	if res.Received == 0 {
+		return res
+	}
+
+	ordered := make([]twampSample, 0, len(samples))
+	for _, s := range samples {
+		ordered = append(ordered, s)
+	}
+	sort.Slice(ordered, func(i, j int) bool { return ordered[i].seq < ordered[j].seq })
+
+	var rttSum, fwdSum, revSum time.Duration
+	res.RTTMin = time.Duration(math.MaxInt64)
+	res.ReflectorSynced = true
+	stateless := true
+	var maxRefl uint32
+	for _, s := range ordered {
+		rttSum += s.rtt
+		fwdSum += s.fwd
+		revSum += s.rev
+		res.RTTMin = min(res.RTTMin, s.rtt)
+		res.RTTMax = max(res.RTTMax, s.rtt)
+		if s.reflSeq != s.seq {
+			stateless = false
+		}
+		maxRefl = max(maxRefl, s.reflSeq)
+		if !s.synced {
+			res.ReflectorSynced = false
+		}
+	}
+	n := time.Duration(len(ordered))
+	res.RTTAvg = rttSum / n
+	// Same estimator as the UDP echo probe, so fwd/rev jitter mean one thing
+	res.ForwardJitter = interarrivalJitter(ordered, func(s twampSample) int64 { return int64(s.fwd) })
+	res.ReverseJitter = interarrivalJitter(ordered, func(s twampSample) int64 { return int64(s.rev) })
+	if cfg.ClockSynced && res.ReflectorSynced {
+		res.Synced = true
+		res.ForwardDelay = fwdSum / n
+		res.ReverseDelay = revSum / n
+	}
+
+	// A stateful reflector numbers what it received from 0, so its highest
+	// sequence tells how many of ours got there. If every reply echoes our
+	// own numbers, either the reflector is stateless or only replies were
+	// lost; the two look the same, so directions are left unsplit.
+	if !stateless || res.Received == res.Sent {
+		res.Directional = true
+		reflected := min(int(maxRefl)+1, res.Sent)
+		reflected = max(reflected, res.Received)
+		res.ForwardLoss = float64(res.Sent-reflected) / float64(res.Sent) * 100
+		res.ReverseLoss = float64(reflected-res.Received) / float64(reflected) * 100
+	}
+	return res
+}
@@ -0,0 +1,374 @@
+package prober
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/logging"
+)
+
+// RouteLens UDP echo packets (big endian). Unlike TWAMP-Light the responder
+// reports how many packets of the session it has received, so forward and
+// reverse loss are known exactly.
+//
+//	0  magic "RLUE" (4)   4  type (1)        5  reserved (3)
+//	8  session id (8)     16 sender seq (4)  20 sender tx ns (8)
+//	28 reply seq (4)      32 rx count (4)    36 rx reordered (4)
+//	40 responder rx ns (8)                   48 responder tx ns (8)
+//	56 padding...
+const (
+	udpEchoMagic      = "RLUE"
+	udpEchoHeaderSize = 56
+
+	udpEchoRequest    = 1
+	udpEchoReply      = 2
+	udpEchoStats      = 3 // End of stream: ask for final counters
+	udpEchoStatsReply = 4
+
+	udpEchoSessionIdle = 60 * time.Second
+
+	DefaultUDPEchoRate       = 50 // pps, a G.711 VoIP stream
+	DefaultUDPEchoPacketSize = 200
+	MinUDPEchoPacketSize     = udpEchoHeaderSize
+)
+
+// --- Responder ---
+
+// UDPEchoResponder answers UDP echo streams from other RouteLens nodes
+type UDPEchoResponder struct {
+	mu       sync.Mutex
+	sessions map[uint64]*udpEchoSession
+	conn     net.PacketConn
+}
+
+type udpEchoSession struct {
+	replySeq  uint32
+	rxCount   uint32
+	reordered uint32
+	maxSeq    uint32
+	lastSeen  time.Time
+}
+
+func NewUDPEchoResponder() *UDPEchoResponder {
+	return &UDPEchoResponder{sessions: make(map[uint64]*udpEchoSession)}
+}
+
+// ListenAndServe answers on addr (e.g. ":7862") until Close is called
+func (r *UDPEchoResponder) ListenAndServe(addr string) error {
+	conn, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	return r.Serve(conn)
+}
+
+func (r *UDPEchoResponder) Serve(conn net.PacketConn) error {
+	r.mu.Lock()
+	r.conn = conn
+	r.mu.Unlock()
+	logging.Info("udpecho", "UDP echo responder listening on %s", conn.LocalAddr())
+
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := conn.ReadFrom(buf)
+		rx := time.Now()
+		if err != nil {
+			if ne, ok := err.(net.Error); ok && ne.Timeout() {
+				continue
+			}
+			return err
+		}
+		if n < udpEchoHeaderSize || string(buf[0:4]) != udpEchoMagic {
+			continue
+		}
+		typ := buf[4]
+		if typ != udpEchoRequest && typ != udpEchoStats {
+			continue
+		}
+
+		// Replies are the same size as requests so both directions carry equal load
+		reply := make([]byte, n)
+		copy(reply, buf[:udpEchoHeaderSize])
+		sess := r.update(binary.BigEndian.Uint64(buf[8:16]), binary.BigEndian.Uint32(buf[16:20]), typ, rx)
+		if typ == udpEchoStats {
+			reply[4] = udpEchoStatsReply
+		} else {
+			reply[4] = udpEchoReply
+		}
+		binary.BigEndian.PutUint32(reply[28:32], sess.replySeq)
+		binary.BigEndian.PutUint32(reply[32:36], sess.rxCount)
+		binary.BigEndian.PutUint32(reply[36:40], sess.reordered)
+		binary.BigEndian.PutUint64(reply[40:48], uint64(rx.UnixNano()))
+		binary.BigEndian.PutUint64(reply[48:56], uint64(time.Now().UnixNano()))
+		if _, err := conn.WriteTo(reply, from); err != nil {
+			logging.Debug("udpecho", "reply to %s failed: %v", from, err)
+		}
+	}
+}
+
+// update records a packet and returns a snapshot of its session counters
+func (r *UDPEchoResponder) update(id uint64, seq uint32, typ byte, now time.Time) udpEchoSession {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[id]
+	if !ok {
+		s = &udpEchoSession{}
+		r.sessions[id] = s
+		for k, v := range r.sessions {
+			if now.Sub(v.lastSeen) > udpEchoSessionIdle && k != id {
+				delete(r.sessions, k)
+			}
+		}
+	}
+	s.lastSeen = now
+	if typ == udpEchoRequest {
+		if s.rxCount > 0 && seq < s.maxSeq {
+			s.reordered++
+		}
+		s.maxSeq = max(s.maxSeq, seq)
+		s.rxCount++
+		s.replySeq++
+	}
+	return *s
+}
+
+func (r *UDPEchoResponder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.conn == nil {
+		return nil
+	}
+	return r.conn.Close()
+}
+
+// --- Sender ---
+
+type UDPEchoConfig struct {
+	Address    string // host:port of the responder
+	Rate       int    // Packets per second
+	PacketSize int    // Bytes of UDP payload incl. header
+	Duration   time.Duration
+	Wait       time.Duration // How long to wait for stragglers after the last packet
+}
+
+// UDPEchoResult summarizes one stream. Forward is sender -> responder,
+// Reverse is responder -> sender. Loss and reordering are in percent.
+type UDPEchoResult struct {
+	Sent     int
+	Received int
+	// Reflected is how many packets reached the responder. Exact when the
+	// end-of-stream stats reply arrived, otherwise the last count seen.
+	Reflected int
+
+	ForwardLoss    float64
+	ReverseLoss    float64
+	ForwardReorder float64
+	ReverseReorder float64
+
+	// RFC 3550 interarrival jitter, which needs no clock sync
+	ForwardJitter time.Duration
+	ReverseJitter time.Duration
+
+	RTTAvg time.Duration // Excludes responder processing time
+	RTTMin time.Duration
+	RTTMax time.Duration
+}
+
+type UDPEchoSender struct {
+	config UDPEchoConfig
+}
+
+func NewUDPEchoSender(cfg UDPEchoConfig) *UDPEchoSender {
+	if cfg.Rate <= 0 {
+		cfg.Rate = DefaultUDPEchoRate
+	}
+	if cfg.PacketSize <= 0 {
+		cfg.PacketSize = DefaultUDPEchoPacketSize
+	}
+	cfg.PacketSize = max(cfg.PacketSize, MinUDPEchoPacketSize)
+	if cfg.Duration <= 0 {
+		cfg.Duration = 10 * time.Second
+	}
+	if cfg.Wait <= 0 {
+		cfg.Wait = time.Second
+	}
+	return &UDPEchoSender{config: cfg}
+}
+
+type udpEchoSample struct {
+	replySeq uint32
+	rtt      time.Duration
+	fwd      int64 // Responder rx - sender tx, includes clock offset
+	rev      int64 // Our rx - responder tx, includes clock offset
+}
+
+func (s *UDPEchoSender) Run() (*UDPEchoResult, error) {
+	cfg := s.config
+	count := max(1, int(cfg.Duration.Seconds()*float64(cfg.Rate)))
+
+	conn, err := net.Dial("udp", cfg.Address)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	var idBytes [8]byte
+	if _, err := rand.Read(idBytes[:]); err != nil {
+		return nil, err
+	}
+	id := binary.BigEndian.Uint64(idBytes[:])
+
+	sendTimes := make([]time.Time, count)
+	var mu sync.Mutex
+	seen := make(map[uint32]bool)
+	var arrivals []udpEchoSample // In arrival order
+	var maxReplySeq, reverseReordered uint32
+	reflected, fwdReordered := 0, 0
+	statsReply := make(chan struct{}, 1)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		buf := make([]byte, 65535)
+		for {
+			n, err := conn.Read(buf)
+			rx := time.Now()
+			if err != nil {
+				return
+			}
+			if n < udpEchoHeaderSize || string(buf[0:4]) != udpEchoMagic || binary.BigEndian.Uint64(buf[8:16]) != id {
+				continue
+			}
+			mu.Lock()
+			reflected = max(reflected, int(binary.BigEndian.Uint32(buf[32:36])))
+			fwdReordered = max(fwdReordered, int(binary.BigEndian.Uint32(buf[36:40])))
+			if buf[4] == udpEchoStatsReply {
+				mu.Unlock()
+				select {
+				case statsReply <- struct{}{}:
+				default:
+				}
+				continue
+			}
+			seq := binary.BigEndian.Uint32(buf[16:20])
+			if buf[4] != udpEchoReply || int(seq) >= count || seen[seq] || sendTimes[seq].IsZero() {
+				mu.Unlock()
+				continue
+			}
+			seen[seq] = true
+			replySeq := binary.BigEndian.Uint32(buf[28:32])
+			if replySeq < maxReplySeq {
+				reverseReordered++
+			}
+			maxReplySeq = max(maxReplySeq, replySeq)
+			respRx := int64(binary.BigEndian.Uint64(buf[40:48]))
+			respTx := int64(binary.BigEndian.Uint64(buf[48:56]))
+			rtt := rx.Sub(sendTimes[seq]) - time.Duration(respTx-respRx)
+			if rtt < 0 {
+				rtt = rx.Sub(sendTimes[seq])
+			}
+			arrivals = append(arrivals, udpEchoSample{
+				replySeq: replySeq,
+				rtt:      rtt,
+				fwd:      respRx - int64(binary.BigEndian.Uint64(buf[20:28])),
+				rev:      rx.UnixNano() - respTx,
+			})
+			mu.Unlock()
+		}
+	}()
+
+	pkt := make([]byte, cfg.PacketSize)
+	copy(pkt[0:4], udpEchoMagic)
+	pkt[4] = udpEchoRequest
+	binary.BigEndian.PutUint64(pkt[8:16], id)
+	ticker := time.NewTicker(time.Second / time.Duration(cfg.Rate))
+	for i := 0; i < count; i++ {
+		if i > 0 {
+			<-ticker.C
+		}
+		now := time.Now()
+		binary.BigEndian.PutUint32(pkt[16:20], uint32(i))
+		binary.BigEndian.PutUint64(pkt[20:28], uint64(now.UnixNano()))
+		mu.Lock()
+		sendTimes[i] = now
+		mu.Unlock()
+		if _, err := conn.Write(pkt); err != nil {
+			ticker.Stop()
+			return nil, fmt.Errorf("udp echo send failed: %w", err)
+		}
+	}
+	ticker.Stop()
+	time.Sleep(cfg.Wait)
+
+	// Final counters, retried since the stats exchange can be lost too
+	exact := false
+	stats := make([]byte, udpEchoHeaderSize)
+	copy(stats, pkt[:udpEchoHeaderSize])
+	stats[4] = udpEchoStats
+	for i := 0; i < 3 && !exact; i++ {
+		conn.Write(stats)
+		select {
+		case <-statsReply:
+			exact = true
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+	conn.SetReadDeadline(time.Now())
+	<-done
+
+	mu.Lock()
+	defer mu.Unlock()
+	res := summarizeUDPEcho(count, reflected, fwdReordered, int(reverseReordered), arrivals)
+	if !exact {
+		logging.Debug("udpecho", "no stats reply from %s, forward loss is estimated", cfg.Address)
+	}
+	return res, nil
+}
+
+func summarizeUDPEcho(sent, reflected, fwdReordered, revReordered int, arrivals []udpEchoSample) *UDPEchoResult {
+	res := &UDPEchoResult{Sent: sent, Received: len(arrivals)}
+	res.Reflected = min(max(reflected, res.Received), sent)
+	res.ForwardLoss = float64(sent-res.Reflected) / float64(sent) * 100
+	if res.Reflected == 0 {
+		return res
+	}
+	res.ReverseLoss = float64(res.Reflected-res.Received) / float64(res.Reflected) * 100
+	res.ForwardReorder = float64(fwdReordered) / float64(res.Reflected) * 100
+	if res.Received == 0 {
+		return res
+	}
+	res.ReverseReorder = float64(revReordered) / float64(res.Received) * 100
+
+	var rttSum time.Duration
+	res.RTTMin = time.Duration(math.MaxInt64)
+	for _, a := range arrivals {
+		rttSum += a.rtt
+		res.RTTMin = min(res.RTTMin, a.rtt)
+		res.RTTMax = max(res.RTTMax, a.rtt)
+	}
+	res.RTTAvg = rttSum / time.Duration(len(arrivals))
+
+	// Forward jitter follows the order packets reached the responder (its
+	// reply seq), reverse jitter the order replies reached us
+	byResponder := append([]udpEchoSample(nil), arrivals...)
+	sort.Slice(byResponder, func(i, j int) bool { return byResponder[i].replySeq < byResponder[j].replySeq })
+	res.ForwardJitter = interarrivalJitter(byResponder, func(a udpEchoSample) int64 { return a.fwd })
+	res.ReverseJitter = interarrivalJitter(arrivals, func(a udpEchoSample) int64 { return a.rev })
+	return res
+}
+
+// interarrivalJitter is the RFC 3550 estimator J += (|D| - J) / 16
+func interarrivalJitter[S any](samples []S, transit func(S) int64) time.Duration {
+	var j float64
+	for i := 1; i < len(samples); i++ {
+		d := math.Abs(float64(transit(samples[i]) - transit(samples[i-1])))
+		j += (d - j) / 16
+	}
+	return time.Duration(j)
+}
@@ -5,6 +5,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"sync"
 
 	"github.com/glebarez/sqlite"
 	"gorm.io/gorm"
@@ -13,6 +14,11 @@ import (
 
 type DB struct {
 	conn *gorm.DB
+	dir  string // Holds the database and its secret key file
+
+	keyOnce sync.Once
+	key     []byte
+	keyErr  error
 }
 
 // NewDB initializes the SQLite database
@@ -41,11 +47,13 @@ func NewDB(dbPath string) (*DB, error) {
 	}
 
 	// Auto Migrate
-	if err := db.AutoMigrate(&MonitorRecord{}, &Target{}, &User{}); err != nil {
+	if err := db.AutoMigrate(&MonitorRecord{}, &Target{}, &User{}, &ReversePathRecord{}, &MetricRecord{}, &InterfaceRecord{}, &Incident{},
+		&AlertRule{}, &AlertState{}, &AlertEvent{}, &NotificationChannel{}, &MaintenanceWindow{},
+		&RoutePath{}, &RouteChange{}, &HopRecord{}); err != nil {
 		return nil, fmt.Errorf("migration failed: %w", err)
 	}
 
-	return &DB{conn: db}, nil
+	return &DB{conn: db, dir: dir}, nil
 }
 
 // Close closes the underlying db connection (optional, GORM manages pool)
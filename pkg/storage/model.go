@@ -1,7 +1,10 @@
 package storage
 
 import (
+	"strings"
 	"time"
+
+	"github.com/yuanweize/RouteLens/pkg/cron"
 )
 
 // Target represents a monitoring destination
@@ -13,19 +16,49 @@ type Target struct {
 	Address   string    `gorm:"type:varchar(128);uniqueIndex;not null" json:"address"` // IP or Domain
 	Desc      string    `gorm:"type:text" json:"desc"`
 	Enabled   bool      `gorm:"default:true" json:"enabled"`
+	// Group is a free-form label alert rules can be scoped to
+	Group string `gorm:"column:target_group;type:varchar(64);index" json:"group"`
+	// Tags is a comma-separated list maintenance windows can be scoped to
+	Tags string `gorm:"type:varchar(255)" json:"tags"`
+	// Parent is the address of a target this one depends on (a gateway or
+	// upstream hop); while it is down this target's incidents and alerts
+	// are put down to it
+	Parent string `gorm:"type:varchar(128);index" json:"parent"`
 
 	// --- Probing Configuration (Phase 13) ---
-	// ProbeMode: ICMP, SSH, HTTP, IPERF3
+	// ProbeMode: ICMP, SSH, HTTP, IPERF3, ROUTELENS, LIBRESPEED, SSH_SESSION, PLUGIN, GRPC, NTP, TWAMP, UDP_ECHO
 	ProbeType string `gorm:"column:probe_type;type:varchar(20);default:'MODE_ICMP'" json:"probe_type"`
 
 	// ProbeConfig (JSON stored as text for flexibility)
 	// Includes URL for HTTP, Port for Iperf, Credentials for SSH
 	ProbeConfig string `gorm:"column:probe_config;type:text" json:"probe_config"`
 
+	// SNMPConfig (JSON) polls the device's interface counters alongside
+	// whatever ProbeType does; empty = no SNMP polling
+	SNMPConfig string `gorm:"column:snmp_config;type:text" json:"snmp_config"`
+	// SNMPError is the last failed poll, kept apart from LastError so SNMP
+	// and the main probe don't overwrite each other; a good poll clears it
+	SNMPError   string     `gorm:"column:snmp_error;type:text" json:"snmp_error"`
+	SNMPErrorAt *time.Time `gorm:"column:snmp_error_at" json:"snmp_error_at"`
+
+	// --- SSH Host Key Pinning ---
+	// SSHHostKey is the pinned key (authorized_keys format), learned on first
+	// successful login or pre-seeded by an admin
+	SSHHostKey string `gorm:"column:ssh_host_key;type:text" json:"ssh_host_key"`
+	// SSHHostKeyPending holds a different key the target offered, awaiting admin acceptance
+	SSHHostKeyPending string `gorm:"column:ssh_host_key_pending;type:text" json:"ssh_host_key_pending"`
+
 	// --- Error Tracking (Phase Polish) ---
 	// LastError stores the most recent probe error message
 	LastError   string     `gorm:"column:last_error;type:text" json:"last_error"`
 	LastErrorAt *time.Time `gorm:"column:last_error_at" json:"last_error_at"`
+
+	// --- Check Probe Status ---
+	// LastStatus is the most recent check result: OK/WARNING/CRITICAL/UNKNOWN
+	// for plugins, SERVING/NOT_SERVING/... for gRPC, SYNCED/UNSYNCED for NTP
+	LastStatus string `gorm:"column:last_status;type:varchar(16)" json:"last_status"`
+	// LastMessage is the plugin's output text, perfdata stripped
+	LastMessage string `gorm:"column:last_message;type:text" json:"last_message"`
 }
 
 // User represents a system administrator
@@ -52,6 +85,217 @@ type MonitorRecord struct {
 	// Speed Test Metrics
 	SpeedUp   float64 `gorm:"default:0" json:"speed_up"`   // Mbps
 	SpeedDown float64 `gorm:"default:0" json:"speed_down"` // Mbps
+
+	// Speed Test Details (JSON Blob): latency/jitter and other probe-specific metrics
+	SpeedJson []byte `gorm:"type:text" json:"speed_json,omitempty"`
+
+	// Outcome of a ping cycle (ProbeStatus*) and why it failed. Empty on
+	// speed test rows, which aren't availability samples.
+	Status string `gorm:"type:varchar(24);index" json:"status,omitempty"`
+	Error  string `gorm:"type:text" json:"error,omitempty"`
+
+	// Bufferbloat grade (A+ to F) when latency under load was sampled;
+	// idle/loaded latencies are in SpeedJson
+	BloatGrade string `gorm:"type:varchar(4)" json:"bloat_grade,omitempty"`
+
+	// Maintenance marks samples taken during a maintenance window: kept,
+	// but left out of availability, incidents and alerts
+	Maintenance bool `gorm:"index;default:false" json:"maintenance,omitempty"`
+
+	// Adaptive marks the extra samples taken while a target was degraded.
+	// They add resolution to charts but are left out of aggregates, which
+	// would otherwise over-weight bad periods.
+	Adaptive bool `gorm:"index;default:false" json:"adaptive,omitempty"`
+}
+
+// Ping cycle outcomes stored in MonitorRecord.Status
+const (
+	ProbeStatusOK              = "ok"
+	ProbeStatusTimeout         = "timeout" // No replies, or the network refused to carry the probe
+	ProbeStatusResolveFailure  = "resolve-failure"
+	ProbeStatusPermissionError = "permission-error" // Can't open ICMP sockets
+	ProbeStatusConfigError     = "config-error"
+)
+
+// Incident is a period during which a target was failing or degraded. It
+// opens on the first bad ping cycle and closes on the first good one.
+type Incident struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Target    string     `gorm:"index:idx_incident_target,priority:1;type:varchar(128);not null" json:"target"`
+	StartedAt time.Time  `gorm:"index:idx_incident_target,priority:2;not null" json:"started_at"`
+	EndedAt   *time.Time `gorm:"index" json:"ended_at,omitempty"` // Nil while open
+	// Seconds, set on close
+	DurationSec float64 `json:"duration_sec"`
+	// A ProbeStatus for failures, IncidentPacketLoss or IncidentHighLatency
+	// for degradation. Fixed by the cycle that opened the incident.
+	Category      string  `gorm:"type:varchar(24)" json:"category"`
+	PeakLoss      float64 `json:"peak_loss"`
+	PeakLatencyMs float64 `json:"peak_latency_ms"`
+	Cycles        int     `json:"cycles"` // Bad cycles seen
+	LastError     string  `gorm:"type:text" json:"last_error,omitempty"`
+	// CausedBy is the address of the failing parent target the incident is
+	// put down to, empty when the target failed on its own
+	CausedBy string `gorm:"type:varchar(128)" json:"caused_by,omitempty"`
+}
+
+// Degradation categories of an Incident
+const (
+	IncidentPacketLoss  = "packet-loss"
+	IncidentHighLatency = "high-latency"
+)
+
+// AlertRule is a user-defined condition on ping or speed test records. A
+// rule with neither Target nor Group applies to every target.
+type AlertRule struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `gorm:"type:varchar(64);not null" json:"name"`
+	Enabled   bool      `json:"enabled"`
+
+	// Scope: a target address, or a target group
+	Target string `gorm:"type:varchar(128)" json:"target"`
+	Group  string `gorm:"column:target_group;type:varchar(64)" json:"group"`
+
+	Metric    string  `gorm:"type:varchar(24);not null" json:"metric"`  // AlertMetric*
+	Operator  string  `gorm:"type:varchar(2);not null" json:"operator"` // >, >=, < or <=
+	Threshold float64 `json:"threshold"`
+	// BaselineDays > 0 makes Threshold a multiple of the metric's average
+	// over that many days, e.g. 2 with 7 for "twice the weekly norm"
+	BaselineDays int `json:"baseline_days"`
+
+	// The condition must hold for Cycles consecutive records (0 = 1) and at
+	// least ForSec seconds before the alert fires
+	Cycles int `json:"cycles"`
+	ForSec int `json:"for_sec"`
+
+	// Hysteresis: a firing alert resolves after RecoverCycles (0 = 1)
+	// consecutive records past ResolveThreshold (nil = Threshold; same units, so also a
+	// multiple with a baseline)
+	RecoverCycles    int      `json:"recover_cycles"`
+	ResolveThreshold *float64 `json:"resolve_threshold"`
+}
+
+// Metrics an AlertRule can watch. Latency and loss come from ping cycles,
+// speeds from speed tests.
+const (
+	AlertMetricLatency   = "latency_ms"
+	AlertMetricLoss      = "packet_loss"
+	AlertMetricSpeedDown = "speed_down"
+	AlertMetricSpeedUp   = "speed_up"
+)
+
+// Alert states
+const (
+	AlertStateOK       = "ok"
+	AlertStatePending  = "pending" // Condition holds but hasn't lasted long enough
+	AlertStateFiring   = "firing"
+	AlertStateResolved = "resolved" // Only used on AlertEvents
+)
+
+// AlertState is where one rule stands for one target
+type AlertState struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+	RuleID      uint       `gorm:"uniqueIndex:idx_alert_state,priority:1;not null" json:"rule_id"`
+	Target      string     `gorm:"uniqueIndex:idx_alert_state,priority:2;type:varchar(128);not null" json:"target"`
+	State       string     `gorm:"type:varchar(16);index;not null" json:"state"`
+	Breaches    int        `json:"breaches"`   // Consecutive records meeting the condition
+	Recoveries  int        `json:"recoveries"` // Consecutive records past the resolve threshold while firing
+	BreachSince *time.Time `json:"breach_since,omitempty"`
+	FiredAt     *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+	LastValue   float64    `json:"last_value"`
+	Threshold   float64    `json:"threshold"` // Effective threshold, after any baseline
+	// Suppressed is set when the firing went unnotified (silenced, or put
+	// down to a parent), so its resolution goes unnotified too
+	Suppressed bool `json:"suppressed"`
+}
+
+// AlertEvent records an alert firing or resolving
+type AlertEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index;not null" json:"created_at"`
+	RuleID    uint      `gorm:"index" json:"rule_id"`
+	RuleName  string    `gorm:"type:varchar(64)" json:"rule_name"`
+	Target    string    `gorm:"index;type:varchar(128);not null" json:"target"`
+	State     string    `gorm:"type:varchar(16)" json:"state"` // AlertStateFiring or AlertStateResolved
+	Value     float64   `json:"value"`
+	Threshold float64   `json:"threshold"`
+	Message   string    `gorm:"type:text" json:"message"`
+	// Silenced events were recorded but not sent to notification channels
+	Silenced bool `gorm:"default:false" json:"silenced,omitempty"`
+	// CausedBy is the failing parent target the event was suppressed for
+	CausedBy string `gorm:"type:varchar(128)" json:"caused_by,omitempty"`
+}
+
+// NotificationChannel delivers AlertEvents. Config is the type's JSON
+// settings without secrets; passwords and tokens are sealed in Secrets.
+type NotificationChannel struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `gorm:"type:varchar(64);not null" json:"name"`
+	Type      string    `gorm:"type:varchar(16);not null" json:"type"` // webhook, smtp, telegram, ntfy, gotify
+	Enabled   bool      `json:"enabled"`
+	Config    string    `gorm:"type:text" json:"config"`
+	Secrets   string    `gorm:"type:text" json:"-"` // SealSecret of a JSON object
+	// RatePerHour caps deliveries in any sliding hour; 0 = unlimited
+	RatePerHour int `json:"rate_per_hour"`
+
+	LastSentAt  *time.Time `json:"last_sent_at,omitempty"`
+	LastError   string     `gorm:"type:text" json:"last_error"`
+	LastErrorAt *time.Time `json:"last_error_at,omitempty"`
+}
+
+// MetricRecord is one named sample from a check probe (SSH session timing,
+// plugin perfdata, NTP offset, ...). A series is (target, probe, name).
+type MetricRecord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index:idx_metric_series,priority:4;index;not null" json:"created_at"`
+	Target    string    `gorm:"index:idx_metric_series,priority:1;type:varchar(128);not null" json:"target"`
+	Probe     string    `gorm:"index:idx_metric_series,priority:2;type:varchar(32);not null" json:"probe"`
+	Name      string    `gorm:"index:idx_metric_series,priority:3;type:varchar(64);not null" json:"name"`
+	Value     float64   `json:"value"`
+	Unit      string    `gorm:"type:varchar(16)" json:"unit,omitempty"`
+	// Label is the readable form of a Value that is really an identifier,
+	// e.g. the NTP reference ID ("GPS", an upstream address)
+	Label string `gorm:"type:varchar(64)" json:"label,omitempty"`
+}
+
+// ReversePathRecord is a path measured from a remote vantage point (an SSH
+// target) back toward us or another host, for spotting asymmetric routing
+type ReversePathRecord struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt   time.Time `gorm:"index;not null" json:"created_at"`
+	Target      string    `gorm:"index;type:varchar(128);not null" json:"target"` // Vantage point
+	Destination string    `gorm:"type:varchar(128)" json:"destination"`
+	Tool        string    `gorm:"type:varchar(16)" json:"tool"` // mtr or ping
+
+	LatencyMs  float64 `json:"latency_ms"`
+	PacketLoss float64 `json:"packet_loss"`
+
+	// Same layout as MonitorRecord.TraceJson
+	TraceJson []byte `gorm:"type:text" json:"trace_json,omitempty"`
+}
+
+// InterfaceRecord is one interval of SNMP interface counter rates, stored
+// per poll next to the target's MonitorRecords
+type InterfaceRecord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index:idx_interface_series,priority:3;index;not null" json:"created_at"`
+	Target    string    `gorm:"index:idx_interface_series,priority:1;type:varchar(128);not null" json:"target"`
+	Interface string    `gorm:"index:idx_interface_series,priority:2;type:varchar(64);not null" json:"interface"` // As configured
+	IfIndex   int       `json:"if_index"`
+
+	InBps       float64 `json:"in_bps"`
+	OutBps      float64 `json:"out_bps"`
+	InUtil      float64 `json:"in_util"` // Percent of interface speed, 0 if unknown
+	OutUtil     float64 `json:"out_util"`
+	InErrors    float64 `json:"in_errors"` // per second
+	OutErrors   float64 `json:"out_errors"`
+	InDiscards  float64 `json:"in_discards"`
+	OutDiscards float64 `json:"out_discards"`
 }
 
 const (
@@ -59,4 +303,183 @@ const (
 	ProbeModeHTTP  = "MODE_HTTP"
 	ProbeModeSSH   = "MODE_SSH"
 	ProbeModeIPERF = "MODE_IPERF"
+	// ProbeModeRouteLens tests against another RouteLens instance's speed test endpoints
+	ProbeModeRouteLens = "MODE_ROUTELENS"
+	// ProbeModeLibreSpeed tests against any LibreSpeed-compatible backend
+	ProbeModeLibreSpeed = "MODE_LIBRESPEED"
+	// ProbeModeSSHSession times SSH login phases and a trivial command every ping cycle
+	ProbeModeSSHSession = "MODE_SSH_SESSION"
+	// ProbeModePlugin runs an allow-listed Nagios/Icinga compatible check plugin
+	ProbeModePlugin = "MODE_PLUGIN"
+	// ProbeModeGRPC calls the standard grpc.health.v1 Health/Check RPC
+	ProbeModeGRPC = "MODE_GRPC"
+	// ProbeModeNTP queries the target's NTP service for clock offset and stratum
+	ProbeModeNTP = "MODE_NTP"
+	// ProbeModeTWAMP runs a TWAMP-Light (RFC 5357) session against a reflector
+	ProbeModeTWAMP = "MODE_TWAMP"
+	// ProbeModeUDPEcho streams sequenced UDP to another RouteLens node's echo responder
+	ProbeModeUDPEcho = "MODE_UDP_ECHO"
+)
+
+// IsCheckProbe reports whether a probe mode runs every ping cycle and records
+// MetricRecords, rather than running a speed test on the speed schedule
+func IsCheckProbe(mode string) bool {
+	switch mode {
+	case ProbeModeSSHSession, ProbeModePlugin, ProbeModeGRPC, ProbeModeNTP, ProbeModeTWAMP, ProbeModeUDPEcho:
+		return true
+	}
+	return false
+}
+
+// MaintenanceWindow kinds
+const (
+	// Probes keep running; samples are marked and excluded from
+	// availability, incidents and alerts
+	WindowMaintenance = "maintenance"
+	// Alerts are still evaluated and recorded, just not sent
+	WindowSilence = "silence"
+)
+
+// MaintenanceWindow is a one-off (StartsAt to EndsAt) or recurring (Cron,
+// lasting DurationMin, in server local time) window of planned work or an
+// ad-hoc silence. Targets and Tags are comma-separated scopes; a window with
+// neither covers every target.
+type MaintenanceWindow struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"not null" json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Name      string    `gorm:"type:varchar(64);not null" json:"name"`
+	Kind      string    `gorm:"type:varchar(16);not null" json:"kind"` // WindowMaintenance or WindowSilence
+	Enabled   bool      `json:"enabled"`
+
+	Targets string `gorm:"type:text" json:"targets"` // Target addresses
+	Tags    string `gorm:"type:text" json:"tags"`    // Matches a target's group or tags
+
+	StartsAt    *time.Time `json:"starts_at,omitempty"`
+	EndsAt      *time.Time `gorm:"index" json:"ends_at,omitempty"`
+	Cron        string     `gorm:"type:varchar(128)" json:"cron"`
+	DurationMin int        `json:"duration_min"`
+
+	Comment string `gorm:"type:text" json:"comment"`
+}
+
+// SplitList splits a comma-separated list, dropping blanks
+func SplitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// Covers reports whether the window's scope includes the target
+func (w *MaintenanceWindow) Covers(t Target) bool {
+	targets, tags := SplitList(w.Targets), SplitList(w.Tags)
+	if len(targets) == 0 && len(tags) == 0 {
+		return true
+	}
+	for _, addr := range targets {
+		if addr == t.Address {
+			return true
+		}
+	}
+	targetTags := SplitList(t.Tags)
+	if t.Group != "" {
+		targetTags = append(targetTags, t.Group)
+	}
+	for _, tag := range tags {
+		for _, tt := range targetTags {
+			if strings.EqualFold(tag, tt) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ActiveAt reports whether the window is in effect at now, and if so when
+// the current occurrence ends
+func (w *MaintenanceWindow) ActiveAt(now time.Time) (bool, time.Time) {
+	if !w.Enabled {
+		return false, time.Time{}
+	}
+	if w.Cron == "" {
+		if w.StartsAt == nil || w.EndsAt == nil || now.Before(*w.StartsAt) || !now.Before(*w.EndsAt) {
+			return false, time.Time{}
+		}
+		return true, *w.EndsAt
+	}
+	sched, err := cron.Parse(w.Cron)
+	if err != nil || w.DurationMin <= 0 {
+		return false, time.Time{}
+	}
+	// A recurring window may still be bounded by StartsAt/EndsAt
+	if (w.StartsAt != nil && now.Before(*w.StartsAt)) || (w.EndsAt != nil && !now.Before(*w.EndsAt)) {
+		return false, time.Time{}
+	}
+	duration := time.Duration(w.DurationMin) * time.Minute
+	start, ok := sched.Prev(now.In(time.Local), duration-time.Minute)
+	if !ok {
+		return false, time.Time{}
+	}
+	return true, start.Add(duration)
+}
+
+// RoutePath is one version of a target's path: a stretch of time over
+// which its traces kept the same hop sequence. EndedAt is nil for the
+// current version.
+type RoutePath struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	Target      string     `gorm:"index:idx_route_path,priority:1;type:varchar(128);not null" json:"target"`
+	StartedAt   time.Time  `gorm:"index:idx_route_path,priority:2;not null" json:"started_at"`
+	EndedAt     *time.Time `gorm:"index" json:"ended_at,omitempty"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	Samples     int        `json:"samples"`                                   // Traces that matched
+	Fingerprint string     `gorm:"type:varchar(40);index" json:"fingerprint"` // Of the responding hop IPs
+	ASFinger    string     `gorm:"column:as_fingerprint;type:varchar(40)" json:"as_fingerprint"`
+	Hops        string     `gorm:"type:text" json:"hops"`    // Responding hop IPs, comma-separated
+	ASPath      string     `gorm:"type:text" json:"as_path"` // ASNs along the path, repeats collapsed
+}
+
+// Levels of a RouteChange
+const (
+	RouteChangeIP  = "ip"  // Different routers, same networks
+	RouteChangeASN = "asn" // The path crosses different networks
 )
+
+// RouteChange records a target's path moving from one version to another
+type RouteChange struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt  time.Time `gorm:"index;not null" json:"created_at"`
+	Target     string    `gorm:"index;type:varchar(128);not null" json:"target"`
+	FromPathID uint      `json:"from_path_id"`
+	ToPathID   uint      `json:"to_path_id"`
+	Level      string    `gorm:"type:varchar(8)" json:"level"` // RouteChangeIP or RouteChangeASN
+	FromHops   string    `gorm:"type:text" json:"from_hops"`
+	ToHops     string    `gorm:"type:text" json:"to_hops"`
+	FromASPath string    `gorm:"type:text" json:"from_as_path"`
+	ToASPath   string    `gorm:"type:text" json:"to_as_path"`
+	Diff       string    `gorm:"type:text" json:"-"` // JSON hop-by-hop diff, see monitor.diffHops
+}
+
+// HopRecord is one hop of a forward trace, split out of the cycle's
+// MonitorRecord.TraceJson so a hop position or a router can be charted
+// over time. Silent hops are kept with an empty IP and their loss.
+type HopRecord struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	CreatedAt time.Time `gorm:"index:idx_hop_series,priority:3;index:idx_hop_router,priority:2;index;not null" json:"created_at"`
+	Target    string    `gorm:"index:idx_hop_series,priority:1;type:varchar(128);not null" json:"target"`
+	Hop       int       `gorm:"index:idx_hop_series,priority:2;not null" json:"hop"`
+	IP        string    `gorm:"index:idx_hop_router,priority:1;type:varchar(64)" json:"ip"`
+	Host      string    `gorm:"type:varchar(255)" json:"host,omitempty"`
+	ASN       string    `gorm:"type:varchar(32)" json:"asn,omitempty"`
+	RecordID  uint      `gorm:"index" json:"record_id"` // The MonitorRecord it came from
+
+	Loss         float64 `json:"loss"` // Percent
+	LatencyLast  float64 `json:"latency_last_ms"`
+	LatencyAvg   float64 `json:"latency_avg_ms"`
+	LatencyBest  float64 `json:"latency_best_ms"`
+	LatencyWorst float64 `json:"latency_worst_ms"`
+}
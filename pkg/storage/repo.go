@@ -1,9 +1,12 @@
 package storage
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 // SaveRecord persists a monitoring record
@@ -17,7 +20,7 @@ func (d *DB) GetHistory(target string, start, end time.Time) ([]MonitorRecord, e
 	var records []MonitorRecord
 
 	err := d.conn.Model(&MonitorRecord{}).
-		Select("id, created_at, target, latency_ms, packet_loss, speed_up, speed_down"). // Exclude TraceJson
+		Select("id, created_at, target, latency_ms, packet_loss, speed_up, speed_down, speed_json, bloat_grade, status, error, maintenance, adaptive"). // Exclude TraceJson
 		Where("target = ? AND created_at BETWEEN ? AND ?", target, start, end).
 		Order("created_at asc").
 		Find(&records).Error
@@ -25,6 +28,464 @@ func (d *DB) GetHistory(target string, start, end time.Time) ([]MonitorRecord, e
 	return records, err
 }
 
+// Availability summarizes ping cycle outcomes over a time range
+type Availability struct {
+	Cycles       int64            `json:"cycles"`
+	OK           int64            `json:"ok"`
+	Availability float64          `json:"availability"` // Percent of cycles that were ok, 0 without cycles
+	ByStatus     map[string]int64 `json:"by_status"`
+	Maintenance  int64            `json:"maintenance"` // Cycles during maintenance windows, not in the above
+}
+
+// GetAvailability counts ping cycle outcomes. Rows without a status (speed
+// tests, or history recorded before outcomes were kept) and adaptive
+// samples are not counted, and cycles in maintenance windows only in
+// Maintenance.
+func (d *DB) GetAvailability(target string, start, end time.Time) (*Availability, error) {
+	var rows []struct {
+		Status      string
+		Maintenance bool
+		Count       int64
+	}
+	err := d.conn.Model(&MonitorRecord{}).
+		Select("status, maintenance, COUNT(*) AS count").
+		Where("target = ? AND created_at BETWEEN ? AND ? AND status != '' AND adaptive = ?", target, start, end, false).
+		Group("status, maintenance").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Availability{ByStatus: make(map[string]int64)}
+	for _, r := range rows {
+		if r.Maintenance {
+			a.Maintenance += r.Count
+			continue
+		}
+		a.ByStatus[r.Status] = r.Count
+		a.Cycles += r.Count
+		if r.Status == ProbeStatusOK {
+			a.OK = r.Count
+		}
+	}
+	if a.Cycles > 0 {
+		a.Availability = float64(a.OK) / float64(a.Cycles) * 100
+	}
+	return a, nil
+}
+
+// --- Incidents ---
+
+// GetOpenIncident returns the target's open incident, or nil
+func (d *DB) GetOpenIncident(target string) (*Incident, error) {
+	var open []Incident
+	err := d.conn.Where("target = ? AND ended_at IS NULL", target).
+		Order("started_at desc").
+		Limit(1).
+		Find(&open).Error
+	if err != nil || len(open) == 0 {
+		return nil, err
+	}
+	return &open[0], nil
+}
+
+// SaveIncident creates or updates an incident
+func (d *DB) SaveIncident(inc *Incident) error {
+	return d.conn.Save(inc).Error
+}
+
+// MarkIncidentsCausedBy puts the open incidents of targets that started
+// since a time down to a parent. Incidents with a cause of their own keep
+// it, unless that cause is one of the targets too.
+func (d *DB) MarkIncidentsCausedBy(targets []string, parent string, since time.Time) (int64, error) {
+	if len(targets) == 0 {
+		return 0, nil
+	}
+	res := d.conn.Model(&Incident{}).
+		Where("target IN ? AND ended_at IS NULL AND started_at >= ?", targets, since).
+		Where("caused_by = '' OR caused_by IS NULL OR caused_by IN ?", targets).
+		Update("caused_by", parent)
+	return res.RowsAffected, res.Error
+}
+
+// GetIncidents lists incidents overlapping a time range, newest first. An
+// empty target lists all targets.
+func (d *DB) GetIncidents(target string, start, end time.Time) ([]Incident, error) {
+	var incidents []Incident
+	query := d.conn.Where("started_at <= ? AND (ended_at IS NULL OR ended_at >= ?)", end, start)
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+	err := query.Order("started_at desc").Find(&incidents).Error
+	return incidents, err
+}
+
+// IncidentSummary is MTTR/MTBF over a time range. Times are in seconds.
+type IncidentSummary struct {
+	Count    int     `json:"count"`
+	Open     int     `json:"open"`
+	Downtime float64 `json:"downtime_sec"` // Clipped to the range
+	MTTR     float64 `json:"mttr_sec"`     // Mean duration of closed incidents, 0 without any
+	MTBF     float64 `json:"mtbf_sec"`     // Up time in range per incident, 0 without any
+}
+
+// SummarizeIncidents computes MTTR/MTBF for one target's incidents
+func SummarizeIncidents(incidents []Incident, start, end time.Time) IncidentSummary {
+	var s IncidentSummary
+	var repairSum float64
+	closed := 0
+	for _, inc := range incidents {
+		s.Count++
+		incEnd := end
+		if inc.EndedAt == nil {
+			s.Open++
+		} else {
+			closed++
+			repairSum += inc.DurationSec
+			if inc.EndedAt.Before(end) {
+				incEnd = *inc.EndedAt
+			}
+		}
+		incStart := inc.StartedAt
+		if incStart.Before(start) {
+			incStart = start
+		}
+		if incEnd.After(incStart) {
+			s.Downtime += incEnd.Sub(incStart).Seconds()
+		}
+	}
+	if closed > 0 {
+		s.MTTR = repairSum / float64(closed)
+	}
+	if s.Count > 0 {
+		s.MTBF = max(0, end.Sub(start).Seconds()-s.Downtime) / float64(s.Count)
+	}
+	return s
+}
+
+// --- Alerts ---
+
+// GetAlertRules lists rules, optionally only enabled ones
+func (d *DB) GetAlertRules(onlyEnabled bool) ([]AlertRule, error) {
+	var rules []AlertRule
+	query := d.conn.Model(&AlertRule{})
+	if onlyEnabled {
+		query = query.Where("enabled = ?", true)
+	}
+	err := query.Order("id asc").Find(&rules).Error
+	return rules, err
+}
+
+func (d *DB) GetAlertRuleByID(id uint) (*AlertRule, error) {
+	var r AlertRule
+	err := d.conn.First(&r, id).Error
+	return &r, err
+}
+
+// SaveAlertRule creates a rule (ID 0) or replaces every field of an existing
+// one, so disabling it or clearing its scope sticks
+func (d *DB) SaveAlertRule(r *AlertRule) error {
+	if r.ID == 0 {
+		return d.conn.Create(r).Error
+	}
+	return d.conn.Model(r).Select("*").Omit("created_at").Updates(r).Error
+}
+
+// DeleteAlertRule removes a rule and its per-target state. Its events stay
+// as history.
+func (d *DB) DeleteAlertRule(id uint) error {
+	if err := d.conn.Where("rule_id = ?", id).Delete(&AlertState{}).Error; err != nil {
+		return err
+	}
+	return d.conn.Delete(&AlertRule{}, id).Error
+}
+
+// ResetAlertStates drops a rule's per-target state, e.g. after its condition changed
+func (d *DB) ResetAlertStates(ruleID uint) error {
+	return d.conn.Where("rule_id = ?", ruleID).Delete(&AlertState{}).Error
+}
+
+// GetAlertState returns a rule's state for a target, or nil if it has none yet
+func (d *DB) GetAlertState(ruleID uint, target string) (*AlertState, error) {
+	var states []AlertState
+	err := d.conn.Where("rule_id = ? AND target = ?", ruleID, target).Limit(1).Find(&states).Error
+	if err != nil || len(states) == 0 {
+		return nil, err
+	}
+	return &states[0], nil
+}
+
+func (d *DB) SaveAlertState(st *AlertState) error {
+	return d.conn.Save(st).Error
+}
+
+// SuppressAlertEvent marks a firing event as put down to a parent after
+// the fact, along with its rule's state if still firing, so the
+// resolution isn't sent either
+func (d *DB) SuppressAlertEvent(e *AlertEvent, cause string) error {
+	err := d.conn.Model(&AlertEvent{}).Where("id = ?", e.ID).
+		Updates(map[string]interface{}{"silenced": true, "caused_by": cause}).Error
+	if err != nil {
+		return err
+	}
+	return d.conn.Model(&AlertState{}).
+		Where("rule_id = ? AND target = ? AND state = ?", e.RuleID, e.Target, AlertStateFiring).
+		Update("suppressed", true).Error
+}
+
+// GetActiveAlerts lists pending and firing states, firing first
+func (d *DB) GetActiveAlerts() ([]AlertState, error) {
+	var states []AlertState
+	err := d.conn.Where("state != ?", AlertStateOK).
+		Order("state asc, updated_at desc").
+		Find(&states).Error
+	return states, err
+}
+
+func (d *DB) SaveAlertEvent(e *AlertEvent) error {
+	return d.conn.Create(e).Error
+}
+
+// GetAlertEvents lists events in a time range, newest first. An empty
+// target lists all targets.
+func (d *DB) GetAlertEvents(target string, start, end time.Time) ([]AlertEvent, error) {
+	var events []AlertEvent
+	query := d.conn.Where("created_at BETWEEN ? AND ?", start, end)
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+	err := query.Order("created_at desc").Find(&events).Error
+	return events, err
+}
+
+// GetMetricBaseline averages an AlertMetric over a target's records since a
+// time. Failed ping cycles, maintenance and adaptive samples and speed rows
+// without the value are left out.
+// Returns the number of samples averaged alongside.
+func (d *DB) GetMetricBaseline(target, metric string, since time.Time) (float64, int64, error) {
+	var where string
+	switch metric {
+	case AlertMetricLatency, AlertMetricLoss:
+		where = "status = '" + ProbeStatusOK + "'"
+	case AlertMetricSpeedDown, AlertMetricSpeedUp:
+		where = metric + " > 0"
+	default:
+		return 0, 0, fmt.Errorf("unknown metric %q", metric)
+	}
+	var row struct {
+		Avg   float64
+		Count int64
+	}
+	err := d.conn.Model(&MonitorRecord{}).
+		Select("COALESCE(AVG("+metric+"), 0) AS avg, COUNT(*) AS count").
+		Where("target = ? AND created_at >= ? AND maintenance = ? AND adaptive = ? AND "+where, target, since, false, false).
+		Scan(&row).Error
+	return row.Avg, row.Count, err
+}
+
+// --- Notification Channels ---
+
+func (d *DB) GetChannels(onlyEnabled bool) ([]NotificationChannel, error) {
+	var channels []NotificationChannel
+	query := d.conn.Model(&NotificationChannel{})
+	if onlyEnabled {
+		query = query.Where("enabled = ?", true)
+	}
+	err := query.Order("id asc").Find(&channels).Error
+	return channels, err
+}
+
+func (d *DB) GetChannelByID(id uint) (*NotificationChannel, error) {
+	var ch NotificationChannel
+	err := d.conn.First(&ch, id).Error
+	return &ch, err
+}
+
+// SaveChannel seals secrets into the channel and creates (ID 0) or fully
+// replaces it. Delivery status fields are left alone on update.
+func (d *DB) SaveChannel(ch *NotificationChannel, secrets map[string]string) error {
+	raw, err := json.Marshal(secrets)
+	if err != nil {
+		return err
+	}
+	if ch.Secrets, err = d.SealSecret(raw); err != nil {
+		return err
+	}
+	if ch.ID == 0 {
+		return d.conn.Create(ch).Error
+	}
+	return d.conn.Model(ch).Select("*").
+		Omit("created_at", "last_sent_at", "last_error", "last_error_at").
+		Updates(ch).Error
+}
+
+// ChannelSecrets unseals a channel's secrets
+func (d *DB) ChannelSecrets(ch *NotificationChannel) (map[string]string, error) {
+	secrets := make(map[string]string)
+	if ch.Secrets == "" {
+		return secrets, nil
+	}
+	raw, err := d.OpenSecret(ch.Secrets)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &secrets)
+	return secrets, err
+}
+
+func (d *DB) DeleteChannel(id uint) error {
+	return d.conn.Delete(&NotificationChannel{}, id).Error
+}
+
+// --- Route history ---
+
+// GetCurrentRoutePath returns the target's current path version, or nil
+func (d *DB) GetCurrentRoutePath(target string) (*RoutePath, error) {
+	var paths []RoutePath
+	err := d.conn.Where("target = ? AND ended_at IS NULL", target).
+		Order("started_at desc").
+		Limit(1).
+		Find(&paths).Error
+	if err != nil || len(paths) == 0 {
+		return nil, err
+	}
+	return &paths[0], nil
+}
+
+// SaveRoutePath creates or updates a path version
+func (d *DB) SaveRoutePath(p *RoutePath) error {
+	return d.conn.Save(p).Error
+}
+
+// ChangeRoute closes the current path version, opens the next and records
+// the change between them, all or nothing
+func (d *DB) ChangeRoute(from, to *RoutePath, change *RouteChange) error {
+	return d.conn.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(from).Error; err != nil {
+			return err
+		}
+		if err := tx.Create(to).Error; err != nil {
+			return err
+		}
+		change.FromPathID, change.ToPathID = from.ID, to.ID
+		return tx.Create(change).Error
+	})
+}
+
+// GetRoutePaths lists a target's path versions overlapping a time range,
+// oldest first
+func (d *DB) GetRoutePaths(target string, start, end time.Time) ([]RoutePath, error) {
+	var paths []RoutePath
+	err := d.conn.Where("target = ? AND started_at <= ? AND (ended_at IS NULL OR ended_at >= ?)", target, end, start).
+		Order("started_at asc").
+		Find(&paths).Error
+	return paths, err
+}
+
+// GetRouteChanges lists route changes in a time range, newest first. An
+// empty target means all targets.
+func (d *DB) GetRouteChanges(target string, start, end time.Time) ([]RouteChange, error) {
+	var changes []RouteChange
+	query := d.conn.Where("created_at BETWEEN ? AND ?", start, end)
+	if target != "" {
+		query = query.Where("target = ?", target)
+	}
+	err := query.Order("created_at desc").Find(&changes).Error
+	return changes, err
+}
+
+// GetLatencyStats averages the latency of a target's regular, successful
+// ping cycles in a time range
+func (d *DB) GetLatencyStats(target string, start, end time.Time) (float64, int64, error) {
+	var row struct {
+		Avg   float64
+		Count int64
+	}
+	err := d.conn.Model(&MonitorRecord{}).
+		Select("COALESCE(AVG(latency_ms), 0) AS avg, COUNT(*) AS count").
+		Where("target = ? AND created_at BETWEEN ? AND ? AND status = ? AND adaptive = ? AND maintenance = ?",
+			target, start, end, ProbeStatusOK, false, false).
+		Scan(&row).Error
+	return row.Avg, row.Count, err
+}
+
+// --- Hops ---
+
+// SaveHops persists the hops of one trace
+func (d *DB) SaveHops(records []HopRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return d.conn.Create(&records).Error
+}
+
+// GetHopSeries returns a target's hop samples in a time range, oldest
+// first. hop > 0 narrows it to one hop position and ip to one router.
+func (d *DB) GetHopSeries(target string, hop int, ip string, start, end time.Time) ([]HopRecord, error) {
+	var records []HopRecord
+	query := d.conn.Where("target = ? AND created_at BETWEEN ? AND ?", target, start, end)
+	if hop > 0 {
+		query = query.Where("hop = ?", hop)
+	}
+	if ip != "" {
+		query = query.Where("ip = ?", ip)
+	}
+	err := query.Order("created_at asc").Find(&records).Error
+	return records, err
+}
+
+// GetRouterSeries returns a router's samples in a time range from every
+// target whose path crossed it, oldest first
+func (d *DB) GetRouterSeries(ip string, start, end time.Time) ([]HopRecord, error) {
+	var records []HopRecord
+	err := d.conn.Where("ip = ? AND created_at BETWEEN ? AND ?", ip, start, end).
+		Order("created_at asc").
+		Find(&records).Error
+	return records, err
+}
+
+// --- Maintenance windows ---
+
+// GetMaintenanceWindows lists windows in creation order
+func (d *DB) GetMaintenanceWindows(onlyEnabled bool) ([]MaintenanceWindow, error) {
+	var windows []MaintenanceWindow
+	query := d.conn.Model(&MaintenanceWindow{})
+	if onlyEnabled {
+		query = query.Where("enabled = ?", true)
+	}
+	err := query.Order("id asc").Find(&windows).Error
+	return windows, err
+}
+
+func (d *DB) GetMaintenanceWindowByID(id uint) (*MaintenanceWindow, error) {
+	var w MaintenanceWindow
+	err := d.conn.First(&w, id).Error
+	return &w, err
+}
+
+// SaveMaintenanceWindow creates (ID 0) or fully updates a window
+func (d *DB) SaveMaintenanceWindow(w *MaintenanceWindow) error {
+	if w.ID == 0 {
+		return d.conn.Create(w).Error
+	}
+	return d.conn.Model(w).Select("*").Omit("created_at").Updates(w).Error
+}
+
+func (d *DB) DeleteMaintenanceWindow(id uint) error {
+	return d.conn.Delete(&MaintenanceWindow{}, id).Error
+}
+
+// UpdateChannelDelivery records the outcome of a delivery; an empty errMsg
+// is a success and clears the last error
+func (d *DB) UpdateChannelDelivery(id uint, errMsg string) error {
+	now := time.Now()
+	updates := map[string]interface{}{"last_error": errMsg, "last_error_at": &now}
+	if errMsg == "" {
+		updates = map[string]interface{}{"last_sent_at": &now, "last_error": "", "last_error_at": nil}
+	}
+	return d.conn.Model(&NotificationChannel{}).Where("id = ?", id).Updates(updates).Error
+}
+
 // GetRecordDetail fetches the full record including TraceJson by ID
 func (d *DB) GetRecordDetail(id uint) (*MonitorRecord, error) {
 	var r MonitorRecord
@@ -54,6 +515,83 @@ func (d *DB) GetLatestTrace(target string) (*MonitorRecord, error) {
 	return &r, err
 }
 
+// --- Check Probe Metrics ---
+
+// SaveMetrics persists the samples of one probe cycle
+func (d *DB) SaveMetrics(records []MetricRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return d.conn.Create(&records).Error
+}
+
+// GetMetricHistory fetches samples for a target in a time range.
+// probe and name narrow the result when non-empty.
+func (d *DB) GetMetricHistory(target, probe, name string, start, end time.Time) ([]MetricRecord, error) {
+	var records []MetricRecord
+	query := d.conn.Model(&MetricRecord{}).
+		Where("target = ? AND created_at BETWEEN ? AND ?", target, start, end)
+	if probe != "" {
+		query = query.Where("probe = ?", probe)
+	}
+	if name != "" {
+		query = query.Where("name = ?", name)
+	}
+	err := query.Order("created_at asc").Find(&records).Error
+	return records, err
+}
+
+// --- Interfaces ---
+
+// SaveInterfaceRecords persists one SNMP poll's rates
+func (d *DB) SaveInterfaceRecords(records []InterfaceRecord) error {
+	if len(records) == 0 {
+		return nil
+	}
+	return d.conn.Create(&records).Error
+}
+
+// GetInterfaceHistory returns interface rates for a target, optionally one interface
+func (d *DB) GetInterfaceHistory(target, iface string, start, end time.Time) ([]InterfaceRecord, error) {
+	var records []InterfaceRecord
+	query := d.conn.Model(&InterfaceRecord{}).
+		Where("target = ? AND created_at BETWEEN ? AND ?", target, start, end)
+	if iface != "" {
+		query = query.Where("interface = ?", iface)
+	}
+	err := query.Order("created_at asc").Find(&records).Error
+	return records, err
+}
+
+// --- Reverse Paths ---
+
+// SaveReversePath persists a path measured from a remote vantage point
+func (d *DB) SaveReversePath(r *ReversePathRecord) error {
+	return d.conn.Create(r).Error
+}
+
+// GetLatestReversePath fetches the most recent reverse path measured from target
+func (d *DB) GetLatestReversePath(target string) (*ReversePathRecord, error) {
+	var r ReversePathRecord
+	err := d.conn.
+		Where("target = ?", target).
+		Order("created_at desc").
+		Limit(1).
+		First(&r).Error
+	return &r, err
+}
+
+// GetReversePathHistory fetches reverse path summaries (without TraceJson) in a time range
+func (d *DB) GetReversePathHistory(target string, start, end time.Time) ([]ReversePathRecord, error) {
+	var records []ReversePathRecord
+	err := d.conn.Model(&ReversePathRecord{}).
+		Select("id, created_at, target, destination, tool, latency_ms, packet_loss").
+		Where("target = ? AND created_at BETWEEN ? AND ?", target, start, end).
+		Order("created_at asc").
+		Find(&records).Error
+	return records, err
+}
+
 // --- Target Management ---
 
 // CreateTarget inserts a new target. Returns error if address already exists.
@@ -67,7 +605,20 @@ func (d *DB) UpdateTarget(t *Target) error {
 	if t.ID == 0 {
 		return fmt.Errorf("cannot update target without ID")
 	}
-	return d.conn.Model(t).Updates(t).Error
+	if err := d.conn.Model(t).Updates(t).Error; err != nil {
+		return err
+	}
+	// Updates skips zero values; labels and SNMP polling must be clearable
+	cols := map[string]interface{}{
+		"target_group": t.Group,
+		"tags":         t.Tags,
+		"parent":       t.Parent,
+		"snmp_config":  t.SNMPConfig,
+	}
+	if t.SNMPConfig == "" {
+		cols["snmp_error"], cols["snmp_error_at"] = "", nil
+	}
+	return d.conn.Model(t).Updates(cols).Error
 }
 
 // SaveTarget creates or updates a target based on whether ID is set.
@@ -121,6 +672,73 @@ func (d *DB) ClearTargetError(address string) error {
 		}).Error
 }
 
+// UpdateTargetSNMPError records a failed SNMP poll, or clears the error
+// when errMsg is empty
+func (d *DB) UpdateTargetSNMPError(address string, errMsg string) error {
+	var at *time.Time
+	if errMsg != "" {
+		now := time.Now()
+		at = &now
+	}
+	return d.conn.Model(&Target{}).
+		Where("address = ?", address).
+		Updates(map[string]interface{}{
+			"snmp_error":    errMsg,
+			"snmp_error_at": at,
+		}).Error
+}
+
+// UpdateTargetCheckResult stores the status and output of a check probe run
+func (d *DB) UpdateTargetCheckResult(address string, status string, message string) error {
+	return d.conn.Model(&Target{}).
+		Where("address = ?", address).
+		Updates(map[string]interface{}{
+			"last_status":  status,
+			"last_message": message,
+		}).Error
+}
+
+// PinTargetHostKey stores the trusted SSH host key and drops any pending one
+func (d *DB) PinTargetHostKey(address string, key string) error {
+	return d.conn.Model(&Target{}).
+		Where("address = ?", address).
+		Updates(map[string]interface{}{
+			"ssh_host_key":         key,
+			"ssh_host_key_pending": "",
+		}).Error
+}
+
+// UpdateTargetProbeConfig replaces a target's probe_config without touching other fields
+func (d *DB) UpdateTargetProbeConfig(id uint, cfg string) error {
+	return d.conn.Model(&Target{}).Where("id = ?", id).Update("probe_config", cfg).Error
+}
+
+// SetPendingHostKey records a mismatching key offered by the target for admin review
+func (d *DB) SetPendingHostKey(address string, key string) error {
+	return d.conn.Model(&Target{}).
+		Where("address = ?", address).
+		Update("ssh_host_key_pending", key).Error
+}
+
+// AcceptPendingHostKey promotes the pending key to pinned and clears the mismatch error
+func (d *DB) AcceptPendingHostKey(id uint) error {
+	t, err := d.GetTargetByID(id)
+	if err != nil {
+		return err
+	}
+	if t.SSHHostKeyPending == "" {
+		return fmt.Errorf("no pending host key")
+	}
+	return d.conn.Model(&Target{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"ssh_host_key":         t.SSHHostKeyPending,
+			"ssh_host_key_pending": "",
+			"last_error":           "",
+			"last_error_at":        nil,
+		}).Error
+}
+
 // --- User Management (Phase 13) ---
 
 func (d *DB) GetUser(username string) (*User, error) {
@@ -197,7 +815,36 @@ func (d *DB) GetDatabaseStats(dbPath string, retentionDays int) (*DatabaseStats,
 func (d *DB) CleanOldRecords(days int) (int64, error) {
 	cutoff := time.Now().AddDate(0, 0, -days)
 	result := d.conn.Where("created_at < ?", cutoff).Delete(&MonitorRecord{})
-	return result.RowsAffected, result.Error
+	if result.Error != nil {
+		return result.RowsAffected, result.Error
+	}
+	total := result.RowsAffected
+	// Closed incidents go with the samples they summarized; open ones stay
+	res := d.conn.Where("ended_at < ?", cutoff).Delete(&Incident{})
+	if res.Error != nil {
+		return total, res.Error
+	}
+	total += res.RowsAffected
+	// Windows that are over for good; recurring ones without an end stay
+	res = d.conn.Where("ends_at < ?", cutoff).Delete(&MaintenanceWindow{})
+	if res.Error != nil {
+		return total, res.Error
+	}
+	total += res.RowsAffected
+	// Past path versions; the current one stays however old it is
+	res = d.conn.Where("ended_at < ?", cutoff).Delete(&RoutePath{})
+	if res.Error != nil {
+		return total, res.Error
+	}
+	total += res.RowsAffected
+	for _, model := range []interface{}{&ReversePathRecord{}, &MetricRecord{}, &InterfaceRecord{}, &AlertEvent{}, &RouteChange{}, &HopRecord{}} {
+		res := d.conn.Where("created_at < ?", cutoff).Delete(model)
+		if res.Error != nil {
+			return total, res.Error
+		}
+		total += res.RowsAffected
+	}
+	return total, nil
 }
 
 // VacuumDatabase runs VACUUM to reclaim space
@@ -0,0 +1,115 @@
+package storage
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Secrets (notification channel passwords and tokens) are sealed with
+// AES-256-GCM. The key comes from RS_SECRET_KEY, or else a random key kept
+// in secret.key next to the database, created on first use. Losing the key
+// only loses the secrets: channels then need their credentials re-entered.
+const (
+	secretKeyFile = "secret.key"
+	sealedPrefix  = "v1:"
+)
+
+// secretKey loads or creates the sealing key once
+func (d *DB) secretKey() ([]byte, error) {
+	d.keyOnce.Do(func() {
+		if v := os.Getenv("RS_SECRET_KEY"); v != "" {
+			sum := sha256.Sum256([]byte(v))
+			d.key = sum[:]
+			return
+		}
+		path := filepath.Join(d.dir, secretKeyFile)
+		if data, err := os.ReadFile(path); err == nil {
+			key, err := hex.DecodeString(strings.TrimSpace(string(data)))
+			if err != nil || len(key) != 32 {
+				d.keyErr = fmt.Errorf("%s is not a 32-byte hex key", path)
+				return
+			}
+			d.key = key
+			return
+		} else if !errors.Is(err, os.ErrNotExist) {
+			d.keyErr = err
+			return
+		}
+		key := make([]byte, 32)
+		if _, err := rand.Read(key); err != nil {
+			d.keyErr = err
+			return
+		}
+		// O_EXCL: never overwrite a key another process just wrote
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			d.keyErr = fmt.Errorf("failed to create %s: %w", path, err)
+			return
+		}
+		defer f.Close()
+		if _, err := f.WriteString(hex.EncodeToString(key) + "\n"); err != nil {
+			d.keyErr = err
+			return
+		}
+		d.key = key
+	})
+	return d.key, d.keyErr
+}
+
+func (d *DB) secretCipher() (cipher.AEAD, error) {
+	key, err := d.secretKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// SealSecret encrypts plaintext for storage
+func (d *DB) SealSecret(plaintext []byte) (string, error) {
+	gcm, err := d.secretCipher()
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return sealedPrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenSecret decrypts a value from SealSecret
+func (d *DB) OpenSecret(sealed string) ([]byte, error) {
+	if !strings.HasPrefix(sealed, sealedPrefix) {
+		return nil, fmt.Errorf("unknown secret format")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(sealed, sealedPrefix))
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := d.secretCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) < gcm.NonceSize() {
+		return nil, fmt.Errorf("sealed secret is truncated")
+	}
+	plaintext, err := gcm.Open(nil, raw[:gcm.NonceSize()], raw[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("cannot decrypt secret (was RS_SECRET_KEY or %s changed?)", secretKeyFile)
+	}
+	return plaintext, nil
+}
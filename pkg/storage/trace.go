@@ -0,0 +1,34 @@
+package storage
+
+import "encoding/json"
+
+// PathHop is one hop of a stored trace (MonitorRecord.TraceJson), with the
+// fields path comparisons need
+type PathHop struct {
+	Hop          int     `json:"hop"`
+	Host         string  `json:"host,omitempty"`
+	IP           string  `json:"ip"`
+	ASN          string  `json:"asn,omitempty"`
+	Loss         float64 `json:"loss"`
+	LatencyLast  float64 `json:"latency_last_ms,omitempty"`
+	LatencyAvg   float64 `json:"latency_avg_ms,omitempty"`
+	LatencyBest  float64 `json:"latency_best_ms,omitempty"`
+	LatencyWorst float64 `json:"latency_worst_ms,omitempty"`
+}
+
+// Responded reports whether the hop answered; silent hops are "*" or blank
+func (h PathHop) Responded() bool {
+	return h.IP != "" && h.IP != "*"
+}
+
+// ParsePath extracts the hops of a stored trace. Unreadable or empty
+// traces (the "[]" a failed trace is stored as) have no hops.
+func ParsePath(raw []byte) []PathHop {
+	var payload struct {
+		Hops []PathHop `json:"hops"`
+	}
+	if len(raw) == 0 || json.Unmarshal(raw, &payload) != nil {
+		return nil
+	}
+	return payload.Hops
+}
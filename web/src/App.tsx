@@ -14,6 +14,7 @@ import Targets from './pages/Targets';
 import Settings from './pages/Settings';
 import About from './pages/About';
 import Logs from './pages/Logs';
+import Alerts from './pages/Alerts';
 
 const App: React.FC = () => {
   const navigate = useNavigate();
@@ -76,6 +77,7 @@ const App: React.FC = () => {
                 <Routes>
                   <Route path="dashboard" element={<Dashboard />} />
                   <Route path="targets" element={<Targets />} />
+                  <Route path="alerts" element={<Alerts />} />
                   <Route path="settings" element={<Settings />} />
                   <Route path="logs" element={<Logs />} />
                   <Route path="about" element={<About />} />
@@ -10,6 +10,198 @@ export interface Target {
   probe_config: string;
   last_error?: string;
   last_error_at?: string;
+  ssh_host_key?: string;
+  ssh_host_key_pending?: string;
+  last_status?: string;
+  last_message?: string;
+  snmp_config?: string;
+  snmp_error?: string;
+  snmp_error_at?: string;
+  group?: string;
+  tags?: string;
+  parent?: string;
+}
+
+export interface MetricRecord {
+  id: number;
+  created_at: string;
+  target: string;
+  probe: string;
+  name: string;
+  value: number;
+  unit?: string;
+  label?: string;
+}
+
+export interface Availability {
+  cycles: number;
+  ok: number;
+  availability: number;
+  by_status: Record<string, number>;
+  maintenance: number;
+}
+
+export interface Incident {
+  id: number;
+  target: string;
+  started_at: string;
+  ended_at?: string;
+  duration_sec: number;
+  category: string;
+  peak_loss: number;
+  peak_latency_ms: number;
+  cycles: number;
+  last_error?: string;
+  caused_by?: string;
+}
+
+export interface IncidentSummary {
+  count: number;
+  open: number;
+  downtime_sec: number;
+  mttr_sec: number;
+  mtbf_sec: number;
+}
+
+export interface AlertRule {
+  id?: number;
+  name: string;
+  enabled: boolean;
+  target: string;
+  group: string;
+  metric: string;
+  operator: string;
+  threshold: number;
+  baseline_days: number;
+  cycles: number;
+  for_sec: number;
+  recover_cycles: number;
+  resolve_threshold?: number | null;
+}
+
+export interface AlertState {
+  id: number;
+  updated_at: string;
+  rule_id: number;
+  target: string;
+  state: 'ok' | 'pending' | 'firing';
+  breaches: number;
+  recoveries: number;
+  breach_since?: string;
+  fired_at?: string;
+  resolved_at?: string;
+  last_value: number;
+  threshold: number;
+}
+
+export interface AlertEvent {
+  id: number;
+  created_at: string;
+  rule_id: number;
+  rule_name: string;
+  target: string;
+  state: 'firing' | 'resolved';
+  value: number;
+  threshold: number;
+  message: string;
+  silenced?: boolean;
+  caused_by?: string;
+}
+
+export interface ParentSuggestion {
+  hop: string;
+  host?: string;
+  depth: number;
+  target?: string;
+  children: string[];
+}
+
+export interface MaintenanceWindow {
+  id?: number;
+  name: string;
+  kind: 'maintenance' | 'silence';
+  enabled: boolean;
+  targets: string;
+  tags: string;
+  starts_at?: string | null;
+  ends_at?: string | null;
+  cron: string;
+  duration_min: number;
+  comment: string;
+  active?: boolean;
+  active_until?: string;
+}
+
+export interface RoutePath {
+  id: number;
+  target: string;
+  started_at: string;
+  ended_at?: string;
+  last_seen_at: string;
+  samples: number;
+  fingerprint: string;
+  as_fingerprint: string;
+  hops: string[];
+  as_path: string[];
+  current: boolean;
+  active_sec: number;
+  avg_latency_ms: number;
+  latency_samples: number;
+}
+
+export interface RouteChange {
+  id: number;
+  created_at: string;
+  target: string;
+  from_path_id: number;
+  to_path_id: number;
+  level: 'ip' | 'asn';
+  from_hops: string[];
+  to_hops: string[];
+  from_as_path: string[];
+  to_as_path: string[];
+  diff: { op: '=' | '-' | '+'; ip: string }[];
+}
+
+export interface HopRecord {
+  id: number;
+  created_at: string;
+  target: string;
+  hop: number;
+  ip: string;
+  host?: string;
+  asn?: string;
+  record_id: number;
+  loss: number;
+  latency_last_ms: number;
+  latency_avg_ms: number;
+  latency_best_ms: number;
+  latency_worst_ms: number;
+}
+
+export interface RouterTarget {
+  target: string;
+  hops: number[];
+  samples: number;
+  avg_loss: number;
+  avg_latency_ms: number;
+  first_seen: string;
+  last_seen: string;
+}
+
+export interface NotificationChannel {
+  id?: number;
+  name: string;
+  type: 'webhook' | 'smtp' | 'telegram' | 'ntfy' | 'gotify';
+  enabled: boolean;
+  rate_per_hour: number;
+  // Secret fields (passwords, tokens, webhook headers) are write-only: send
+  // a value to set one, omit or send '' to keep it, null to clear it
+  config: Record<string, any>;
+  secrets_set?: string[];
+  last_sent_at?: string;
+  last_error?: string;
+  last_error_at?: string;
 }
 
 export interface LogEntry {
@@ -35,11 +227,90 @@ export const saveTarget = (target: Target) => request.post<Target>('/api/v1/targ
 
 export const deleteTarget = (id: number) => request.delete(`/api/v1/targets/${id}`);
 
+export const acceptHostKey = (id: number) => request.post(`/api/v1/targets/${id}/hostkey/accept`);
+
+export const forgetHostKey = (id: number) => request.delete(`/api/v1/targets/${id}/hostkey`);
+
 export const getHistory = (params: { target: string; start?: string; end?: string }) => request.get('/api/v1/history', { params });
 
+export const getAvailability = (params: { target: string; start?: string; end?: string }) =>
+  request.get<Availability>('/api/v1/availability', { params });
+
+export const getIncidents = (params: { target?: string; start?: string; end?: string }) =>
+  request.get<{ incidents: Incident[]; summary?: IncidentSummary }>('/api/v1/incidents', { params });
+
+export const getAlertRules = () => request.get<AlertRule[]>('/api/v1/alerts/rules');
+
+export const saveAlertRule = (rule: AlertRule) => request.post<AlertRule>('/api/v1/alerts/rules', rule);
+
+export const deleteAlertRule = (id: number) => request.delete(`/api/v1/alerts/rules/${id}`);
+
+export const getAlerts = (params?: { target?: string; start?: string; end?: string }) =>
+  request.get<{ active: AlertState[]; events: AlertEvent[] }>('/api/v1/alerts', { params });
+
+export const getChannels = () => request.get<NotificationChannel[]>('/api/v1/notifications/channels');
+
+export const saveChannel = (channel: NotificationChannel) => request.post<NotificationChannel>('/api/v1/notifications/channels', channel);
+
+export const deleteChannel = (id: number) => request.delete(`/api/v1/notifications/channels/${id}`);
+
+// Waits for the channel's own answer, which can take longer than the default timeout
+export const testChannel = (id: number) => request.post(`/api/v1/notifications/channels/${id}/test`, undefined, { timeout: 45000 });
+
+export const getParentSuggestions = () => request.get<ParentSuggestion[]>('/api/v1/dependencies/suggestions');
+
+export const getMaintenanceWindows = () => request.get<MaintenanceWindow[]>('/api/v1/maintenance');
+
+export const saveMaintenanceWindow = (window: MaintenanceWindow) => request.post<MaintenanceWindow>('/api/v1/maintenance', window);
+
+export const deleteMaintenanceWindow = (id: number) => request.delete(`/api/v1/maintenance/${id}`);
+
+export const getRoutePaths = (params: { target: string; start?: string; end?: string }) =>
+  request.get<RoutePath[]>('/api/v1/routes/paths', { params });
+
+export const getRouteChanges = (params: { target?: string; start?: string; end?: string }) =>
+  request.get<RouteChange[]>('/api/v1/routes/changes', { params });
+
+export const getHopSeries = (params: { target: string; hop?: number; ip?: string; start?: string; end?: string }) =>
+  request.get<HopRecord[]>('/api/v1/hops', { params });
+
+export const getRouterSeries = (params: { ip: string; start?: string; end?: string }) =>
+  request.get<{ ip: string; targets: RouterTarget[]; records: HopRecord[] }>('/api/v1/hops/router', { params });
+
 export const getLatestTrace = (target: string, lang?: string) =>
   request.get('/api/v1/trace', { params: { target, lang } });
 
+export const getReverseTrace = (target: string, lang?: string) =>
+  request.get('/api/v1/trace/reverse', { params: { target, lang } });
+
+export const getReverseHistory = (params: { target: string; start?: string; end?: string }) =>
+  request.get('/api/v1/history/reverse', { params });
+
+export const getMetrics = (params: { target: string; probe?: string; name?: string; start?: string; end?: string }) =>
+  request.get<MetricRecord[]>('/api/v1/metrics', { params });
+
+export interface InterfaceRecord {
+  id: number;
+  created_at: string;
+  target: string;
+  interface: string;
+  if_index: number;
+  in_bps: number;
+  out_bps: number;
+  in_util: number;
+  out_util: number;
+  in_errors: number;
+  out_errors: number;
+  in_discards: number;
+  out_discards: number;
+}
+
+export const getInterfaceHistory = (params: { target: string; interface?: string; start?: string; end?: string }) =>
+  request.get<InterfaceRecord[]>('/api/v1/history/interfaces', { params });
+
+export const getHostClock = (params?: { name?: string; start?: string; end?: string }) =>
+  request.get<{ reference: string; records: MetricRecord[] }>('/api/v1/clock', { params });
+
 export const triggerProbe = (payload?: { target?: string }) => request.post('/api/v1/probe', payload || {});
 
 export const getLogs = (params?: { lines?: number; level?: string }) =>
@@ -11,6 +11,7 @@ import {
   FileTextOutlined,
   GlobalOutlined,
   CloudDownloadOutlined,
+  AlertOutlined,
 } from '@ant-design/icons';
 import { useLocation, useNavigate } from 'react-router-dom';
 import { useTranslation } from 'react-i18next';
@@ -102,6 +103,7 @@ const AppLayout: React.FC<AppLayoutProps> = ({ children, isDark, onToggleTheme }
   const menuItems = [
     { key: '/dashboard', icon: <DashboardOutlined />, label: t('nav.dashboard') },
     { key: '/targets', icon: <DeploymentUnitOutlined />, label: t('nav.targets') },
+    { key: '/alerts', icon: <AlertOutlined />, label: t('nav.alerts') },
     { key: '/logs', icon: <FileTextOutlined />, label: t('nav.logs') },
     { key: '/settings', icon: <SettingOutlined />, label: t('nav.settings') },
     { key: '/about', icon: <InfoCircleOutlined />, label: t('nav.about') },
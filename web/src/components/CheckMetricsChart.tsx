@@ -0,0 +1,66 @@
+import React from 'react';
+import ReactECharts from 'echarts-for-react';
+import type { MetricRecord } from '../api';
+
+interface CheckMetricsChartProps {
+  records: MetricRecord[];
+  isDark: boolean;
+  names?: string[]; // Series to show, default all with a "ms" unit
+}
+
+const palette = ['#1677ff', '#ff7a45', '#52c41a', '#722ed1', '#faad14', '#13c2c2', '#eb2f96'];
+
+// CheckMetricsChart plots check probe series (SSH session phases, plugin
+// perfdata, NTP offset, ...) on a shared time axis
+const CheckMetricsChart: React.FC<CheckMetricsChartProps> = ({ records, isDark, names }) => {
+  const series = new Map<string, { unit: string; data: [number, number][] }>();
+  records.forEach((r) => {
+    if (names ? !names.includes(r.name) : r.unit !== 'ms') return;
+    if (!series.has(r.name)) series.set(r.name, { unit: r.unit || '', data: [] });
+    series.get(r.name)!.data.push([new Date(r.created_at).getTime(), r.value]);
+  });
+
+  const option = {
+    backgroundColor: 'transparent',
+    tooltip: {
+      trigger: 'axis',
+      formatter: (params: any) => {
+        if (!params.length) return '';
+        const time = new Date(params[0].value[0]).toLocaleString([], {
+          month: 'short',
+          day: 'numeric',
+          hour: '2-digit',
+          minute: '2-digit',
+        });
+        let result = `<div style="font-weight:500">${time}</div>`;
+        params.forEach((p: any) => {
+          const unit = series.get(p.seriesName)?.unit || '';
+          result += `<div>${p.marker} ${p.seriesName}: ${p.value[1].toFixed(3)}${unit}</div>`;
+        });
+        return result;
+      },
+    },
+    legend: { top: 0, textStyle: { color: isDark ? '#d9d9d9' : '#595959' } },
+    grid: { top: 40, bottom: 30, left: 50, right: 20 },
+    xAxis: {
+      type: 'time',
+      axisLine: { lineStyle: { color: isDark ? '#303030' : '#d9d9d9' } },
+    },
+    yAxis: {
+      type: 'value',
+      splitLine: { lineStyle: { color: isDark ? '#2f2f2f' : '#f0f0f0' } },
+    },
+    series: Array.from(series.entries()).map(([name, s], i) => ({
+      name,
+      type: 'line',
+      smooth: true,
+      data: s.data,
+      itemStyle: { color: palette[i % palette.length] },
+      showSymbol: s.data.length < 50,
+    })),
+  };
+
+  return <ReactECharts option={option} style={{ height: 280 }} notMerge={true} theme={isDark ? 'dark' : 'light'} />;
+};
+
+export default CheckMetricsChart;
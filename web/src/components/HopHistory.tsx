@@ -0,0 +1,111 @@
+import React, { useMemo, useState } from 'react';
+import { Empty, Modal, Radio, Space, Spin, Table, Typography } from 'antd';
+import { useRequest } from 'ahooks';
+import { useTranslation } from 'react-i18next';
+import type { HopRecord, MetricRecord, RouterTarget, Target } from '../api';
+import { getHopSeries, getRouterSeries } from '../api';
+import CheckMetricsChart from './CheckMetricsChart';
+
+interface HopHistoryProps {
+  target: string;
+  hop?: { hop: number; ip: string; host?: string };
+  hours: number; // Range to show, ending now
+  targets: Target[];
+  isDark: boolean;
+  onClose: () => void;
+}
+
+// toSeries turns hop samples into named chart series, prefixed when
+// several targets share the chart
+const toSeries = (records: HopRecord[], label: (r: HopRecord) => string = () => ''): MetricRecord[] =>
+  records.flatMap((r) => {
+    const prefix = label(r);
+    const points: MetricRecord[] = [
+      { id: r.id, created_at: r.created_at, target: r.target, probe: 'hop', name: `${prefix}Loss`, value: r.loss, unit: '%' },
+    ];
+    if (r.loss < 100 && r.latency_avg_ms > 0) {
+      points.push({ id: r.id, created_at: r.created_at, target: r.target, probe: 'hop', name: `${prefix}Latency`, value: r.latency_avg_ms, unit: 'ms' });
+    }
+    return points;
+  });
+
+// HopHistory charts one hop of a target's path over time, or the router
+// at that hop across every target whose path crosses it
+const HopHistory: React.FC<HopHistoryProps> = ({ target, hop, hours, targets, isDark, onClose }) => {
+  const { t } = useTranslation();
+  const [view, setView] = useState<'hop' | 'router'>('hop');
+  const responded = !!hop && !!hop.ip && hop.ip !== '*';
+  const mode = responded ? view : 'hop';
+
+  const targetNames = useMemo(() => Object.fromEntries(targets.map((tg) => [tg.address, tg.name])), [targets]);
+
+  const { data, loading } = useRequest(
+    async () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - hours * 60 * 60 * 1000);
+      const range = { start: start.toISOString(), end: end.toISOString() };
+      if (mode === 'router') {
+        const res = await getRouterSeries({ ip: hop!.ip, ...range });
+        return { records: res.records, targets: res.targets };
+      }
+      const records = await getHopSeries({ target, hop: hop!.hop, ...range });
+      return { records, targets: [] as RouterTarget[] };
+    },
+    { refreshDeps: [target, hop?.hop, hop?.ip, hours, mode], ready: !!hop },
+  );
+
+  const records = data?.records || [];
+  const series =
+    mode === 'router'
+      ? toSeries(records, (r) => `${targetNames[r.target] || r.target} `)
+      : toSeries(records);
+  // At one position the router can change with the path
+  const seenIPs = mode === 'hop' ? Array.from(new Set(records.map((r) => r.ip || '*'))) : [];
+
+  const targetColumns = [
+    { title: t('hopHistory.target'), dataIndex: 'target', render: (v: string) => targetNames[v] || v },
+    { title: t('hopHistory.position'), dataIndex: 'hops', render: (v: number[]) => v.map((h) => `#${h}`).join(', ') },
+    { title: t('hopHistory.samples'), dataIndex: 'samples' },
+    { title: t('hopTable.loss'), dataIndex: 'avg_loss', render: (v: number) => `${v.toFixed(1)}%` },
+    { title: t('hopTable.latency'), dataIndex: 'avg_latency_ms', render: (v: number) => (v > 0 ? `${v.toFixed(1)}ms` : '-') },
+    { title: t('hopHistory.lastSeen'), dataIndex: 'last_seen', render: (v: string) => new Date(v).toLocaleString() },
+  ];
+
+  return (
+    <Modal
+      title={hop ? t('hopHistory.title', { hop: hop.hop, host: hop.host || hop.ip || '*' }) : ''}
+      open={!!hop}
+      onCancel={onClose}
+      footer={null}
+      width={900}
+      destroyOnClose
+    >
+      <Space direction="vertical" style={{ width: '100%' }}>
+        <Radio.Group
+          value={mode}
+          onChange={(e) => setView(e.target.value)}
+          optionType="button"
+          options={[
+            { label: t('hopHistory.thisHop'), value: 'hop' },
+            { label: t('hopHistory.thisRouter'), value: 'router', disabled: !responded },
+          ]}
+        />
+        {seenIPs.length > 1 && (
+          <Typography.Text type="secondary">{t('hopHistory.routersSeen', { ips: seenIPs.join(', ') })}</Typography.Text>
+        )}
+        <Spin spinning={loading}>
+          {records.length > 0 ? (
+            <CheckMetricsChart records={series} isDark={isDark} names={Array.from(new Set(series.map((r) => r.name)))} />
+          ) : (
+            <Empty description={t('hopHistory.none')} />
+          )}
+        </Spin>
+        {mode === 'router' && data && data.targets.length > 0 && (
+          <Table rowKey="target" size="small" columns={targetColumns} dataSource={data.targets} pagination={false} />
+        )}
+      </Space>
+    </Modal>
+  );
+};
+
+export default HopHistory;
@@ -0,0 +1,98 @@
+import React from 'react';
+import { Space, Statistic, Table, Tag, Tooltip, Typography } from 'antd';
+import { useTranslation } from 'react-i18next';
+import type { Incident, IncidentSummary } from '../api';
+
+interface IncidentListProps {
+  incidents: Incident[];
+  summary?: IncidentSummary;
+}
+
+const categoryColors: Record<string, string> = {
+  timeout: 'red',
+  'resolve-failure': 'volcano',
+  'permission-error': 'purple',
+  'config-error': 'purple',
+  'packet-loss': 'orange',
+  'high-latency': 'gold',
+};
+
+// formatSeconds renders a duration as e.g. "2h 5m" or "45s"
+export const formatSeconds = (sec: number) => {
+  if (!sec || sec < 1) return '0s';
+  const d = Math.floor(sec / 86400);
+  const h = Math.floor((sec % 86400) / 3600);
+  const m = Math.floor((sec % 3600) / 60);
+  const s = Math.floor(sec % 60);
+  if (d > 0) return `${d}d ${h}h`;
+  if (h > 0) return `${h}h ${m}m`;
+  if (m > 0) return `${m}m ${s}s`;
+  return `${s}s`;
+};
+
+// IncidentList shows a target's incidents with MTTR/MTBF over the range
+const IncidentList: React.FC<IncidentListProps> = ({ incidents, summary }) => {
+  const { t } = useTranslation();
+
+  const columns = [
+    {
+      title: t('incidents.started'),
+      dataIndex: 'started_at',
+      render: (v: string) => new Date(v).toLocaleString(),
+    },
+    {
+      title: t('incidents.duration'),
+      key: 'duration',
+      render: (_: any, r: Incident) =>
+        r.ended_at ? (
+          formatSeconds(r.duration_sec)
+        ) : (
+          <Tag color="red">
+            {t('incidents.ongoing')} {formatSeconds((Date.now() - new Date(r.started_at).getTime()) / 1000)}
+          </Tag>
+        ),
+    },
+    {
+      title: t('incidents.category'),
+      dataIndex: 'category',
+      render: (v: string, r: Incident) => (
+        <Space size={4}>
+          <Tooltip title={r.last_error}>
+            <Tag color={categoryColors[v] || 'default'}>{v}</Tag>
+          </Tooltip>
+          {r.caused_by && <Tag>{t('incidents.causedBy', { parent: r.caused_by })}</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: t('incidents.peakLoss'),
+      dataIndex: 'peak_loss',
+      render: (v: number) => `${v.toFixed(1)}%`,
+    },
+    {
+      title: t('incidents.peakLatency'),
+      dataIndex: 'peak_latency_ms',
+      render: (v: number) => (v > 0 ? `${v.toFixed(1)}ms` : '-'),
+    },
+  ];
+
+  return (
+    <>
+      {summary && (
+        <Space size="large" style={{ marginBottom: 12 }}>
+          <Statistic title={t('incidents.count')} value={summary.count} valueStyle={{ fontSize: 18 }} />
+          <Statistic title={t('incidents.downtime')} value={formatSeconds(summary.downtime_sec)} valueStyle={{ fontSize: 18 }} />
+          <Statistic title="MTTR" value={summary.mttr_sec ? formatSeconds(summary.mttr_sec) : '-'} valueStyle={{ fontSize: 18 }} />
+          <Statistic title="MTBF" value={summary.mtbf_sec ? formatSeconds(summary.mtbf_sec) : '-'} valueStyle={{ fontSize: 18 }} />
+        </Space>
+      )}
+      {incidents.length > 0 ? (
+        <Table rowKey="id" size="small" columns={columns} dataSource={incidents} pagination={{ pageSize: 5 }} />
+      ) : (
+        <Typography.Text type="secondary">{t('incidents.none')}</Typography.Text>
+      )}
+    </>
+  );
+};
+
+export default IncidentList;
@@ -0,0 +1,251 @@
+import React, { useMemo, useState } from 'react';
+import { Button, Card, Form, Input, InputNumber, Modal, Radio, Select, Space, Switch, Table, Tag } from 'antd';
+import { PlusOutlined } from '@ant-design/icons';
+import { useRequest } from 'ahooks';
+import { useTranslation } from 'react-i18next';
+import type { MaintenanceWindow } from '../api';
+import { deleteMaintenanceWindow, getMaintenanceWindows, getTargets, saveMaintenanceWindow } from '../api';
+
+const splitList = (s?: string) => (s || '').split(',').map((v) => v.trim()).filter(Boolean);
+
+// <input type="datetime-local"> works in local time without a zone
+const toLocalInput = (iso?: string | null) => {
+  if (!iso) return undefined;
+  const d = new Date(iso);
+  return new Date(d.getTime() - d.getTimezoneOffset() * 60000).toISOString().slice(0, 16);
+};
+const fromLocalInput = (value?: string) => (value ? new Date(value).toISOString() : null);
+
+const MaintenanceWindows: React.FC = () => {
+  const { t } = useTranslation();
+  const [form] = Form.useForm();
+  const [open, setOpen] = useState(false);
+  const [editing, setEditing] = useState<MaintenanceWindow | null>(null);
+
+  const { data = [], refresh, loading } = useRequest(getMaintenanceWindows, { pollingInterval: 60000 });
+  const { data: targets = [] } = useRequest(getTargets);
+
+  const targetNames = useMemo(() => Object.fromEntries(targets.map((tg) => [tg.address, tg.name])), [targets]);
+  const tagOptions = useMemo(
+    () =>
+      Array.from(new Set(targets.flatMap((tg) => [tg.group || '', ...splitList(tg.tags)]).filter(Boolean))).map((v) => ({
+        label: v,
+        value: v,
+      })),
+    [targets],
+  );
+
+  const onCreate = (kind: MaintenanceWindow['kind']) => {
+    setEditing(null);
+    form.resetFields();
+    const now = new Date();
+    form.setFieldsValue({
+      kind,
+      enabled: true,
+      recurring: false,
+      duration_min: 60,
+      starts_at: toLocalInput(now.toISOString()),
+      ends_at: toLocalInput(new Date(now.getTime() + 3600 * 1000).toISOString()),
+    });
+    setOpen(true);
+  };
+
+  const onEdit = (record: MaintenanceWindow) => {
+    setEditing(record);
+    form.setFieldsValue({
+      ...record,
+      recurring: !!record.cron,
+      targets: splitList(record.targets),
+      tags: splitList(record.tags),
+      starts_at: toLocalInput(record.starts_at),
+      ends_at: toLocalInput(record.ends_at),
+    });
+    setOpen(true);
+  };
+
+  const onDelete = async (id?: number) => {
+    if (!id) return;
+    await deleteMaintenanceWindow(id);
+    refresh();
+  };
+
+  const onToggle = async (record: MaintenanceWindow, checked: boolean) => {
+    await saveMaintenanceWindow({ ...record, enabled: checked });
+    refresh();
+  };
+
+  const onSubmit = async () => {
+    const values = await form.validateFields();
+    try {
+      await saveMaintenanceWindow({
+        id: editing?.id,
+        name: values.name,
+        kind: values.kind,
+        enabled: values.enabled ?? true,
+        targets: (values.targets || []).join(','),
+        tags: (values.tags || []).join(','),
+        cron: values.recurring ? values.cron || '' : '',
+        duration_min: values.recurring ? values.duration_min || 0 : 0,
+        starts_at: fromLocalInput(values.starts_at),
+        ends_at: fromLocalInput(values.ends_at),
+        comment: values.comment || '',
+      });
+      setOpen(false);
+      refresh();
+    } catch {
+      // The request interceptor already shows the server's error
+    }
+  };
+
+  const describeSchedule = (w: MaintenanceWindow) => {
+    if (w.cron) {
+      return `${w.cron} · ${t('maintenance.forMinutes', { count: w.duration_min })}`;
+    }
+    const start = w.starts_at ? new Date(w.starts_at).toLocaleString() : '-';
+    const end = w.ends_at ? new Date(w.ends_at).toLocaleString() : '-';
+    return `${start} → ${end}`;
+  };
+
+  const columns = [
+    { title: t('maintenance.name'), dataIndex: 'name' },
+    {
+      title: t('maintenance.kind'),
+      dataIndex: 'kind',
+      render: (v: string) => <Tag color={v === 'silence' ? 'purple' : 'gold'}>{t(`maintenance.kinds.${v}`)}</Tag>,
+    },
+    {
+      title: t('maintenance.scope'),
+      key: 'scope',
+      render: (_: any, w: MaintenanceWindow) => {
+        const scoped = splitList(w.targets);
+        const tags = splitList(w.tags);
+        if (scoped.length === 0 && tags.length === 0) return <Tag>{t('alerts.allTargets')}</Tag>;
+        return (
+          <Space size={4} wrap>
+            {scoped.map((a) => <Tag key={a}>{targetNames[a] || a}</Tag>)}
+            {tags.map((tag) => <Tag key={tag} color="geekblue">{tag}</Tag>)}
+          </Space>
+        );
+      },
+    },
+    { title: t('maintenance.schedule'), key: 'schedule', render: (_: any, w: MaintenanceWindow) => describeSchedule(w) },
+    {
+      title: t('maintenance.now'),
+      key: 'active',
+      render: (_: any, w: MaintenanceWindow) =>
+        w.active ? (
+          <Tag color="orange">{t('maintenance.activeUntil', { time: new Date(w.active_until!).toLocaleString() })}</Tag>
+        ) : (
+          '-'
+        ),
+    },
+    {
+      title: t('common.status'),
+      dataIndex: 'enabled',
+      render: (val: boolean, w: MaintenanceWindow) => (
+        <Switch
+          checked={val}
+          onChange={(checked) => onToggle(w, checked)}
+          checkedChildren={t('common.enabled')}
+          unCheckedChildren={t('common.disabled')}
+        />
+      ),
+    },
+    {
+      title: t('common.actions'),
+      render: (_: any, w: MaintenanceWindow) => (
+        <Space>
+          <Button type="link" onClick={() => onEdit(w)}>{t('common.edit')}</Button>
+          <Button type="link" danger onClick={() => onDelete(w.id)}>{t('common.delete')}</Button>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <Card
+      className="page-card"
+      title={t('maintenance.title')}
+      extra={
+        <Space>
+          <Button onClick={() => onCreate('silence')}>{t('maintenance.newSilence')}</Button>
+          <Button icon={<PlusOutlined />} onClick={() => onCreate('maintenance')}>{t('maintenance.newWindow')}</Button>
+        </Space>
+      }
+    >
+      <Table rowKey="id" loading={loading} dataSource={data} columns={columns} pagination={false} />
+
+      <Modal
+        title={editing ? t('maintenance.editWindow') : t('maintenance.newWindow')}
+        open={open}
+        onOk={onSubmit}
+        onCancel={() => setOpen(false)}
+        destroyOnClose
+      >
+        <Form layout="vertical" form={form} preserve={false}>
+          <Form.Item name="name" label={t('maintenance.name')} rules={[{ required: true }]}>
+            <Input placeholder="Router firmware upgrade" maxLength={64} />
+          </Form.Item>
+          <Form.Item name="kind" label={t('maintenance.kind')} extra={t('maintenance.kindHint')}>
+            <Radio.Group
+              options={[
+                { label: t('maintenance.kinds.maintenance'), value: 'maintenance' },
+                { label: t('maintenance.kinds.silence'), value: 'silence' },
+              ]}
+            />
+          </Form.Item>
+          <Form.Item name="targets" label={t('maintenance.targets')} extra={t('maintenance.scopeHint')}>
+            <Select mode="multiple" allowClear options={targets.map((tg) => ({ label: tg.name, value: tg.address }))} />
+          </Form.Item>
+          <Form.Item name="tags" label={t('maintenance.tags')}>
+            <Select mode="tags" allowClear tokenSeparators={[',']} options={tagOptions} />
+          </Form.Item>
+          <Form.Item name="recurring" label={t('maintenance.recurring')} valuePropName="checked">
+            <Switch />
+          </Form.Item>
+          <Form.Item shouldUpdate={(prev, cur) => prev.recurring !== cur.recurring}>
+            {({ getFieldValue }) =>
+              getFieldValue('recurring') ? (
+                <>
+                  <Space align="start">
+                    <Form.Item name="cron" label={t('maintenance.cron')} extra={t('maintenance.cronHint')} rules={[{ required: true }]}>
+                      <Input placeholder="0 2 * * sun" style={{ width: 200 }} />
+                    </Form.Item>
+                    <Form.Item name="duration_min" label={t('maintenance.durationMin')} rules={[{ required: true }]}>
+                      <InputNumber min={1} max={10080} />
+                    </Form.Item>
+                  </Space>
+                  <Space align="start">
+                    <Form.Item name="starts_at" label={t('maintenance.from')}>
+                      <Input type="datetime-local" />
+                    </Form.Item>
+                    <Form.Item name="ends_at" label={t('maintenance.until')}>
+                      <Input type="datetime-local" />
+                    </Form.Item>
+                  </Space>
+                </>
+              ) : (
+                <Space align="start">
+                  <Form.Item name="starts_at" label={t('maintenance.startsAt')} rules={[{ required: true }]}>
+                    <Input type="datetime-local" />
+                  </Form.Item>
+                  <Form.Item name="ends_at" label={t('maintenance.endsAt')} rules={[{ required: true }]}>
+                    <Input type="datetime-local" />
+                  </Form.Item>
+                </Space>
+              )
+            }
+          </Form.Item>
+          <Form.Item name="comment" label={t('maintenance.comment')}>
+            <Input.TextArea rows={2} />
+          </Form.Item>
+          <Form.Item name="enabled" label={t('common.status')} valuePropName="checked">
+            <Switch checkedChildren={t('common.enabled')} unCheckedChildren={t('common.disabled')} />
+          </Form.Item>
+        </Form>
+      </Modal>
+    </Card>
+  );
+};
+
+export default MaintenanceWindows;
@@ -1,12 +1,14 @@
 import React from 'react';
 import ReactECharts from 'echarts-for-react';
+import type { RouteChange } from '../api';
 
 interface MetricsChartProps {
   history: any[];
   isDark: boolean;
+  routeChanges?: RouteChange[];
 }
 
-const MetricsChart: React.FC<MetricsChartProps> = ({ history, isDark }) => {
+const MetricsChart: React.FC<MetricsChartProps> = ({ history, isDark, routeChanges = [] }) => {
   // Format time based on data range
   const formatTime = (dateStr: string) => {
     const date = new Date(dateStr);
@@ -26,8 +28,42 @@ const MetricsChart: React.FC<MetricsChartProps> = ({ history, isDark }) => {
 
   const times = history.map((h) => formatTime(h.created_at || h.CreatedAt));
   const fullTimes = history.map((h) => formatFullTime(h.created_at || h.CreatedAt));
-  const latency = history.map((h) => h.latency_ms || h.LatencyMs || 0);
+  // Failed cycles (status other than ok) have no latency: leave a gap and shade it
+  const failed = (h: any) => h.status && h.status !== 'ok';
+  const latency = history.map((h) => (failed(h) ? null : h.latency_ms || h.LatencyMs || 0));
   const loss = history.map((h) => h.packet_loss || h.PacketLoss || 0);
+  // Runs of consecutive failed cycles, as category indexes
+  const outages: [{ xAxis: number }, { xAxis: number }][] = [];
+  history.forEach((h, i) => {
+    if (!failed(h)) return;
+    const last = outages[outages.length - 1];
+    if (last && last[1].xAxis === i - 1) {
+      last[1] = { xAxis: i };
+    } else {
+      outages.push([{ xAxis: i }, { xAxis: i }]);
+    }
+  });
+  // Runs of samples taken in maintenance windows, shaded grey
+  const maintenance: [{ xAxis: number }, { xAxis: number }][] = [];
+  history.forEach((h, i) => {
+    if (!h.maintenance) return;
+    const last = maintenance[maintenance.length - 1];
+    if (last && last[1].xAxis === i - 1) {
+      last[1] = { xAxis: i };
+    } else {
+      maintenance.push([{ xAxis: i }, { xAxis: i }]);
+    }
+  });
+
+  // Route changes, drawn at the first sample taken after each one
+  const stamps = history.map((h) => new Date(h.created_at || h.CreatedAt).getTime());
+  const reroutes = routeChanges
+    .map((c) => {
+      const at = new Date(c.created_at).getTime();
+      const idx = stamps.findIndex((ts) => ts >= at);
+      return idx < 0 ? null : { xAxis: idx, name: c.level === 'asn' ? 'AS path' : 'Route' };
+    })
+    .filter(Boolean);
 
   const option = {
     backgroundColor: 'transparent',
@@ -38,9 +74,20 @@ const MetricsChart: React.FC<MetricsChartProps> = ({ history, isDark }) => {
         if (idx === undefined) return '';
         let result = `<div style="font-weight:500">${fullTimes[idx]}</div>`;
         params.forEach((p: any) => {
+          if (p.value == null) return;
           const unit = p.seriesName === 'Latency' ? 'ms' : '%';
           result += `<div>${p.marker} ${p.seriesName}: ${p.value.toFixed(1)}${unit}</div>`;
         });
+        const h = history[idx];
+        if (failed(h)) {
+          result += `<div style="color:#ff4d4f">${h.status}: ${h.error || ''}</div>`;
+        }
+        if (h.maintenance) {
+          result += `<div style="color:#8c8c8c">Maintenance</div>`;
+        }
+        if (h.adaptive) {
+          result += `<div style="color:#fa8c16">Adaptive sample</div>`;
+        }
         return result;
       }
     },
@@ -66,6 +113,19 @@ const MetricsChart: React.FC<MetricsChartProps> = ({ history, isDark }) => {
         data: latency,
         itemStyle: { color: '#1677ff' },
         showSymbol: history.length < 50,
+        markArea: {
+          silent: true,
+          itemStyle: { color: 'rgba(255, 77, 79, 0.15)' },
+          label: { show: false },
+          data: outages,
+        },
+        markLine: {
+          silent: true,
+          symbol: 'none',
+          lineStyle: { color: '#722ed1', type: 'dashed' },
+          label: { formatter: '{b}', fontSize: 10, color: '#722ed1' },
+          data: reroutes,
+        },
       },
       {
         name: 'Packet Loss',
@@ -74,6 +134,12 @@ const MetricsChart: React.FC<MetricsChartProps> = ({ history, isDark }) => {
         data: loss,
         itemStyle: { color: '#ff7a45' },
         showSymbol: history.length < 50,
+        markArea: {
+          silent: true,
+          itemStyle: { color: 'rgba(140, 140, 140, 0.15)' },
+          label: { show: false },
+          data: maintenance,
+        },
       },
     ],
   };
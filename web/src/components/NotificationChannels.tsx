@@ -0,0 +1,323 @@
+import React, { useState } from 'react';
+import { Button, Card, Form, Input, InputNumber, Modal, Select, Space, Switch, Table, Tag, Tooltip, message } from 'antd';
+import { PlusOutlined } from '@ant-design/icons';
+import { useRequest } from 'ahooks';
+import { useTranslation } from 'react-i18next';
+import type { NotificationChannel } from '../api';
+import { deleteChannel, getChannels, saveChannel, testChannel } from '../api';
+
+const typeOptions = [
+  { label: 'Webhook', value: 'webhook' },
+  { label: 'SMTP Email', value: 'smtp' },
+  { label: 'Telegram Bot', value: 'telegram' },
+  { label: 'ntfy', value: 'ntfy' },
+  { label: 'Gotify', value: 'gotify' },
+];
+
+// Write-only config keys per type, mirrored from the server
+const secretFields: Record<string, string[]> = {
+  webhook: ['headers'],
+  smtp: ['password'],
+  telegram: ['token'],
+  ntfy: ['token', 'password'],
+  gotify: ['token'],
+};
+
+// Webhook headers are edited as "Name: value" lines
+const parseHeaders = (text?: string) => {
+  const headers: Record<string, string> = {};
+  (text || '').split('\n').forEach((line) => {
+    const idx = line.indexOf(':');
+    if (idx > 0) headers[line.slice(0, idx).trim()] = line.slice(idx + 1).trim();
+  });
+  return headers;
+};
+
+const NotificationChannels: React.FC = () => {
+  const { t } = useTranslation();
+  const [form] = Form.useForm();
+  const [open, setOpen] = useState(false);
+  const [editing, setEditing] = useState<NotificationChannel | null>(null);
+  const [testing, setTesting] = useState<number | null>(null);
+
+  const { data = [], refresh, loading } = useRequest(getChannels);
+
+  const isSet = (key: string) => editing?.secrets_set?.includes(key);
+  const secretPlaceholder = (key: string) => (isSet(key) ? t('channels.unchanged') : undefined);
+
+  const onCreate = () => {
+    setEditing(null);
+    form.resetFields();
+    form.setFieldsValue({ enabled: true, type: 'webhook', rate_per_hour: 30, config: { security: 'starttls' } });
+    setOpen(true);
+  };
+
+  const onEdit = (record: NotificationChannel) => {
+    setEditing(record);
+    form.setFieldsValue({ ...record, config: { ...record.config } });
+    setOpen(true);
+  };
+
+  const onDelete = async (id?: number) => {
+    if (!id) return;
+    await deleteChannel(id);
+    refresh();
+  };
+
+  const onTest = async (id?: number) => {
+    if (!id) return;
+    setTesting(id);
+    try {
+      await testChannel(id);
+      message.success(t('channels.testSent'));
+    } catch {
+      // The request interceptor already shows the channel's error
+    } finally {
+      setTesting(null);
+      refresh();
+    }
+  };
+
+  const onToggle = async (record: NotificationChannel, checked: boolean) => {
+    // Secrets are omitted from the config, so the stored ones are kept
+    await saveChannel({ ...record, enabled: checked });
+    refresh();
+  };
+
+  const onSubmit = async () => {
+    const values = await form.validateFields();
+    const config: Record<string, any> = { ...(values.config || {}) };
+    for (const key of secretFields[values.type] || []) {
+      if (key === 'headers') {
+        const headers = parseHeaders(config.headers);
+        if (Object.keys(headers).length > 0) config.headers = headers;
+        else delete config.headers;
+      } else if (!config[key]) {
+        delete config[key];
+      }
+    }
+    try {
+      await saveChannel({
+        id: editing?.id,
+        name: values.name,
+        type: values.type,
+        enabled: values.enabled ?? true,
+        rate_per_hour: values.rate_per_hour || 0,
+        config,
+      });
+      setOpen(false);
+      refresh();
+    } catch {
+      // The request interceptor already shows the server's error
+    }
+  };
+
+  const columns = [
+    { title: t('channels.name'), dataIndex: 'name' },
+    { title: t('channels.type'), dataIndex: 'type', render: (v: string) => <Tag color="blue">{v}</Tag> },
+    {
+      title: t('channels.rateLimit'),
+      dataIndex: 'rate_per_hour',
+      render: (v: number) => (v > 0 ? t('channels.perHour', { count: v }) : t('channels.unlimited')),
+    },
+    {
+      title: t('channels.lastDelivery'),
+      key: 'last',
+      render: (_: any, r: NotificationChannel) => {
+        const failed = r.last_error && (!r.last_sent_at || (r.last_error_at && r.last_error_at > r.last_sent_at));
+        if (failed) {
+          return (
+            <Tooltip title={r.last_error}>
+              <Tag color="red">{t('channels.failed')}</Tag>
+            </Tooltip>
+          );
+        }
+        return r.last_sent_at ? new Date(r.last_sent_at).toLocaleString() : '-';
+      },
+    },
+    {
+      title: t('common.status'),
+      dataIndex: 'enabled',
+      render: (val: boolean, r: NotificationChannel) => (
+        <Switch
+          checked={val}
+          onChange={(checked) => onToggle(r, checked)}
+          checkedChildren={t('common.enabled')}
+          unCheckedChildren={t('common.disabled')}
+        />
+      ),
+    },
+    {
+      title: t('common.actions'),
+      render: (_: any, r: NotificationChannel) => (
+        <Space>
+          <Button type="link" loading={testing === r.id} onClick={() => onTest(r.id)}>{t('channels.test')}</Button>
+          <Button type="link" onClick={() => onEdit(r)}>{t('common.edit')}</Button>
+          <Button type="link" danger onClick={() => onDelete(r.id)}>{t('common.delete')}</Button>
+        </Space>
+      ),
+    },
+  ];
+
+  return (
+    <Card className="page-card" title={t('channels.title')} extra={<Button icon={<PlusOutlined />} onClick={onCreate}>{t('channels.newChannel')}</Button>}>
+      <Table rowKey="id" loading={loading} dataSource={data} columns={columns} pagination={false} />
+
+      <Modal
+        title={editing ? t('channels.editChannel') : t('channels.newChannel')}
+        open={open}
+        onOk={onSubmit}
+        onCancel={() => setOpen(false)}
+        destroyOnClose
+      >
+        <Form layout="vertical" form={form} preserve={false}>
+          <Form.Item name="name" label={t('channels.name')} rules={[{ required: true }]}>
+            <Input placeholder="Ops team" />
+          </Form.Item>
+          <Form.Item name="type" label={t('channels.type')} rules={[{ required: true }]}>
+            <Select options={typeOptions} disabled={!!editing} />
+          </Form.Item>
+          <Form.Item shouldUpdate={(prev, cur) => prev.type !== cur.type}>
+            {({ getFieldValue }) => {
+              const type = getFieldValue('type');
+              if (type === 'webhook') {
+                return (
+                  <>
+                    <Form.Item name={['config', 'url']} label="URL" rules={[{ required: true }]}>
+                      <Input placeholder="https://hooks.example.com/routelens" />
+                    </Form.Item>
+                    <Form.Item name={['config', 'body_template']} label={t('channels.bodyTemplate')} extra={t('channels.webhookTemplateHint')}>
+                      <Input.TextArea rows={4} placeholder={'{"text": {{json .Title}}, "detail": {{json .Body}}}'} />
+                    </Form.Item>
+                    <Form.Item name={['config', 'headers']} label={t('channels.headers')} extra={t('channels.headersHint')}>
+                      <Input.TextArea rows={2} placeholder={isSet('headers') ? t('channels.unchanged') : 'Authorization: Bearer ...'} />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (type === 'smtp') {
+                return (
+                  <>
+                    <Space align="start">
+                      <Form.Item name={['config', 'host']} label={t('channels.smtpHost')} rules={[{ required: true }]}>
+                        <Input placeholder="smtp.example.com" />
+                      </Form.Item>
+                      <Form.Item name={['config', 'port']} label={t('channels.port')}>
+                        <InputNumber min={1} max={65535} placeholder="587" />
+                      </Form.Item>
+                      <Form.Item name={['config', 'security']} label={t('channels.security')}>
+                        <Select
+                          style={{ width: 120 }}
+                          options={[
+                            { label: 'STARTTLS', value: 'starttls' },
+                            { label: 'TLS', value: 'tls' },
+                            { label: t('channels.none'), value: 'none' },
+                          ]}
+                        />
+                      </Form.Item>
+                    </Space>
+                    <Space align="start">
+                      <Form.Item name={['config', 'username']} label={t('channels.username')}>
+                        <Input />
+                      </Form.Item>
+                      <Form.Item name={['config', 'password']} label={t('channels.password')}>
+                        <Input.Password placeholder={secretPlaceholder('password')} />
+                      </Form.Item>
+                    </Space>
+                    <Form.Item name={['config', 'from']} label={t('channels.from')} rules={[{ required: true }]}>
+                      <Input placeholder="RouteLens <alerts@example.com>" />
+                    </Form.Item>
+                    <Form.Item name={['config', 'to']} label={t('channels.to')} rules={[{ required: true }]}>
+                      <Select mode="tags" tokenSeparators={[',', ' ']} />
+                    </Form.Item>
+                    <Form.Item name={['config', 'subject_template']} label={t('channels.subjectTemplate')}>
+                      <Input placeholder="{{.Title}}" />
+                    </Form.Item>
+                    <Form.Item name={['config', 'body_template']} label={t('channels.bodyTemplate')}>
+                      <Input.TextArea rows={3} placeholder="{{.Body}}" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (type === 'telegram') {
+                return (
+                  <>
+                    <Form.Item name={['config', 'token']} label={t('channels.botToken')} rules={[{ required: !isSet('token') }]}>
+                      <Input.Password placeholder={secretPlaceholder('token') || '123456:ABC-DEF...'} />
+                    </Form.Item>
+                    <Form.Item name={['config', 'chat_id']} label={t('channels.chatId')} rules={[{ required: true }]}>
+                      <Input placeholder="-1001234567890" />
+                    </Form.Item>
+                    <Form.Item name={['config', 'api_url']} label={t('channels.apiUrl')} extra={t('channels.apiUrlHint')}>
+                      <Input placeholder="https://api.telegram.org" />
+                    </Form.Item>
+                    <Form.Item name={['config', 'template']} label={t('channels.template')}>
+                      <Input.TextArea rows={3} placeholder={'{{.Title}}\n{{.Body}}'} />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (type === 'ntfy') {
+                return (
+                  <>
+                    <Form.Item name={['config', 'url']} label={t('channels.topicUrl')} rules={[{ required: true }]}>
+                      <Input placeholder="https://ntfy.sh/routelens-alerts" />
+                    </Form.Item>
+                    <Form.Item name={['config', 'token']} label={t('channels.accessToken')}>
+                      <Input.Password placeholder={secretPlaceholder('token')} />
+                    </Form.Item>
+                    <Space align="start">
+                      <Form.Item name={['config', 'username']} label={t('channels.username')}>
+                        <Input />
+                      </Form.Item>
+                      <Form.Item name={['config', 'password']} label={t('channels.password')}>
+                        <Input.Password placeholder={secretPlaceholder('password')} />
+                      </Form.Item>
+                      <Form.Item name={['config', 'priority']} label={t('channels.priority')}>
+                        <InputNumber min={1} max={5} />
+                      </Form.Item>
+                    </Space>
+                    <Form.Item name={['config', 'template']} label={t('channels.template')}>
+                      <Input.TextArea rows={2} placeholder="{{.Body}}" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (type === 'gotify') {
+                return (
+                  <>
+                    <Form.Item name={['config', 'url']} label={t('channels.serverUrl')} rules={[{ required: true }]}>
+                      <Input placeholder="https://gotify.example.com" />
+                    </Form.Item>
+                    <Space align="start">
+                      <Form.Item name={['config', 'token']} label={t('channels.appToken')} rules={[{ required: !isSet('token') }]}>
+                        <Input.Password placeholder={secretPlaceholder('token')} />
+                      </Form.Item>
+                      <Form.Item name={['config', 'priority']} label={t('channels.priority')}>
+                        <InputNumber min={0} max={10} />
+                      </Form.Item>
+                    </Space>
+                    <Form.Item name={['config', 'template']} label={t('channels.template')}>
+                      <Input.TextArea rows={2} placeholder="{{.Body}}" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              return null;
+            }}
+          </Form.Item>
+          <Space align="start">
+            <Form.Item name="rate_per_hour" label={t('channels.rateLimit')} extra={t('channels.rateLimitHint')}>
+              <InputNumber min={0} max={3600} />
+            </Form.Item>
+            <Form.Item name="enabled" label={t('common.status')} valuePropName="checked">
+              <Switch checkedChildren={t('common.enabled')} unCheckedChildren={t('common.disabled')} />
+            </Form.Item>
+          </Space>
+        </Form>
+      </Modal>
+    </Card>
+  );
+};
+
+export default NotificationChannels;
@@ -0,0 +1,80 @@
+import React from 'react';
+import { Button, Modal, Space, Table, Tag, Typography } from 'antd';
+import { useRequest } from 'ahooks';
+import { useTranslation } from 'react-i18next';
+import type { ParentSuggestion, Target } from '../api';
+import { getParentSuggestions, saveTarget } from '../api';
+
+interface ParentSuggestionsProps {
+  open: boolean;
+  targets: Target[];
+  onClose: () => void;
+  onApplied: () => void;
+}
+
+// ParentSuggestions lists hops the targets' latest paths share, deepest
+// first, and sets one as the parent of the targets behind it
+const ParentSuggestions: React.FC<ParentSuggestionsProps> = ({ open, targets, onClose, onApplied }) => {
+  const { t } = useTranslation();
+  const { data = [], loading } = useRequest(getParentSuggestions, { ready: open, refreshDeps: [open] });
+
+  const byAddress = Object.fromEntries(targets.map((tg) => [tg.address, tg]));
+
+  const onApply = async (s: ParentSuggestion) => {
+    try {
+      for (const addr of s.children) {
+        const child = byAddress[addr];
+        if (child && child.parent !== s.target) {
+          await saveTarget({ ...child, parent: s.target });
+        }
+      }
+      onApplied();
+    } catch {
+      // The request interceptor already shows the server's error
+    }
+  };
+
+  const columns = [
+    {
+      title: t('dependencies.hop'),
+      key: 'hop',
+      render: (_: any, s: ParentSuggestion) => (
+        <Space direction="vertical" size={0}>
+          <Typography.Text>
+            #{s.depth} {s.hop}
+          </Typography.Text>
+          {s.host && s.host !== s.hop && <Typography.Text type="secondary">{s.host}</Typography.Text>}
+          {s.target && <Tag color="blue">{byAddress[s.target]?.name || s.target}</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: t('dependencies.children'),
+      dataIndex: 'children',
+      render: (children: string[]) => (
+        <Space size={4} wrap>
+          {children.map((addr) => <Tag key={addr}>{byAddress[addr]?.name || addr}</Tag>)}
+        </Space>
+      ),
+    },
+    {
+      title: t('common.actions'),
+      key: 'actions',
+      render: (_: any, s: ParentSuggestion) =>
+        s.target ? (
+          <Button type="link" onClick={() => onApply(s)}>{t('dependencies.apply')}</Button>
+        ) : (
+          <Typography.Text type="secondary">{t('dependencies.notATarget')}</Typography.Text>
+        ),
+    },
+  ];
+
+  return (
+    <Modal title={t('dependencies.title')} open={open} onCancel={onClose} footer={null} width={760} destroyOnClose>
+      <Typography.Paragraph type="secondary">{t('dependencies.hint')}</Typography.Paragraph>
+      <Table rowKey={(s) => `${s.depth}-${s.hop}`} size="small" loading={loading} dataSource={data} columns={columns} pagination={false} />
+    </Modal>
+  );
+};
+
+export default ParentSuggestions;
@@ -0,0 +1,113 @@
+import React from 'react';
+import { Space, Table, Tag, Tooltip, Typography } from 'antd';
+import { useTranslation } from 'react-i18next';
+import type { RouteChange, RoutePath } from '../api';
+import { formatSeconds } from './IncidentList';
+
+interface RouteHistoryProps {
+  paths: RoutePath[];
+  changes: RouteChange[];
+}
+
+const diffColors: Record<string, string> = { '-': 'red', '+': 'green' };
+
+// RouteDiff shows a change hop by hop: removed hops red, added hops green
+const RouteDiff: React.FC<{ change: RouteChange }> = ({ change }) => (
+  <Space size={2} wrap>
+    {change.diff.map((d, i) => (
+      <Tag key={i} color={diffColors[d.op]} style={d.op === '-' ? { textDecoration: 'line-through' } : undefined}>
+        {d.op === '=' ? d.ip : `${d.op} ${d.ip}`}
+      </Tag>
+    ))}
+  </Space>
+);
+
+// RouteHistory lists a target's path versions over the range, with the
+// latency seen on each, and the changes between them
+const RouteHistory: React.FC<RouteHistoryProps> = ({ paths, changes }) => {
+  const { t } = useTranslation();
+
+  const pathColumns = [
+    {
+      title: t('routes.since'),
+      dataIndex: 'started_at',
+      render: (v: string, r: RoutePath) => (
+        <Space size={4}>
+          {new Date(v).toLocaleString()}
+          {r.current && <Tag color="blue">{t('routes.current')}</Tag>}
+        </Space>
+      ),
+    },
+    {
+      title: t('routes.active'),
+      dataIndex: 'active_sec',
+      render: (v: number) => formatSeconds(v),
+    },
+    {
+      title: t('routes.hops'),
+      dataIndex: 'hops',
+      render: (v: string[]) => (
+        <Tooltip title={v.join(' → ')}>
+          <span>{v.length}</span>
+        </Tooltip>
+      ),
+    },
+    {
+      title: t('routes.asPath'),
+      dataIndex: 'as_path',
+      render: (v: string[]) => (v.length > 0 ? v.join(' → ') : '-'),
+    },
+    {
+      title: t('routes.avgLatency'),
+      dataIndex: 'avg_latency_ms',
+      render: (v: number, r: RoutePath) => (r.latency_samples > 0 ? `${v.toFixed(1)}ms` : '-'),
+    },
+  ];
+
+  const changeColumns = [
+    {
+      title: t('routes.changedAt'),
+      dataIndex: 'created_at',
+      render: (v: string) => new Date(v).toLocaleString(),
+    },
+    {
+      title: t('routes.level'),
+      dataIndex: 'level',
+      render: (v: string) => <Tag color={v === 'asn' ? 'purple' : 'default'}>{t(`routes.levels.${v}`)}</Tag>,
+    },
+    {
+      title: t('routes.diff'),
+      key: 'diff',
+      render: (_: any, r: RouteChange) => (
+        <Space direction="vertical" size={4}>
+          <RouteDiff change={r} />
+          {r.level === 'asn' && (
+            <Typography.Text type="secondary" style={{ fontSize: 12 }}>
+              {r.from_as_path.join(' → ') || '-'} ⇒ {r.to_as_path.join(' → ') || '-'}
+            </Typography.Text>
+          )}
+        </Space>
+      ),
+    },
+  ];
+
+  if (paths.length === 0) {
+    return <Typography.Text type="secondary">{t('routes.none')}</Typography.Text>;
+  }
+
+  return (
+    <>
+      <Table rowKey="id" size="small" columns={pathColumns} dataSource={[...paths].reverse()} pagination={{ pageSize: 5 }} />
+      {changes.length > 0 && (
+        <>
+          <Typography.Title level={5} style={{ marginTop: 8 }}>
+            {t('routes.changes')}
+          </Typography.Title>
+          <Table rowKey="id" size="small" columns={changeColumns} dataSource={changes} pagination={{ pageSize: 5 }} />
+        </>
+      )}
+    </>
+  );
+};
+
+export default RouteHistory;
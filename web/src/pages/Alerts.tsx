@@ -0,0 +1,276 @@
+import React, { useMemo, useState } from 'react';
+import { Button, Card, Form, Input, InputNumber, Modal, Select, Space, Switch, Table, Tag, Tooltip, message } from 'antd';
+import { PlusOutlined } from '@ant-design/icons';
+import { useRequest } from 'ahooks';
+import { useTranslation } from 'react-i18next';
+import type { AlertEvent, AlertRule, AlertState } from '../api';
+import { deleteAlertRule, getAlertRules, getAlerts, getTargets, saveAlertRule } from '../api';
+import MaintenanceWindows from '../components/MaintenanceWindows';
+import NotificationChannels from '../components/NotificationChannels';
+
+const metricOptions = [
+  { label: 'Latency (ms)', value: 'latency_ms' },
+  { label: 'Packet Loss (%)', value: 'packet_loss' },
+  { label: 'Download (Mbps)', value: 'speed_down' },
+  { label: 'Upload (Mbps)', value: 'speed_up' },
+];
+
+const operatorOptions = ['>', '>=', '<', '<='].map((op) => ({ label: op, value: op }));
+
+const stateColors: Record<string, string> = {
+  firing: 'red',
+  pending: 'orange',
+  resolved: 'green',
+  ok: 'green',
+};
+
+const Alerts: React.FC = () => {
+  const { t } = useTranslation();
+  const [form] = Form.useForm();
+  const [open, setOpen] = useState(false);
+  const [editing, setEditing] = useState<AlertRule | null>(null);
+
+  const { data: rules = [], refresh: refreshRules, loading } = useRequest(getAlertRules);
+  const { data: targets = [] } = useRequest(getTargets);
+  const { data: alerts, refresh: refreshAlerts } = useRequest(
+    () => getAlerts({ start: new Date(Date.now() - 7 * 24 * 3600 * 1000).toISOString() }),
+    { pollingInterval: 30000 },
+  );
+
+  const ruleNames = useMemo(() => Object.fromEntries(rules.map((r) => [r.id, r.name])), [rules]);
+  const targetNames = useMemo(() => Object.fromEntries(targets.map((tg) => [tg.address, tg.name])), [targets]);
+  const groupOptions = useMemo(
+    () => Array.from(new Set(targets.map((tg) => tg.group).filter(Boolean))).map((g) => ({ label: g, value: g as string })),
+    [targets],
+  );
+
+  const describeRule = (r: AlertRule) => {
+    const unit = r.baseline_days > 0 ? `× ${t('alerts.baselineOf', { days: r.baseline_days })}` : '';
+    const parts = [`${r.metric} ${r.operator} ${r.threshold} ${unit}`.trim()];
+    if (r.cycles > 1) parts.push(t('alerts.forCycles', { count: r.cycles }));
+    if (r.for_sec > 0) parts.push(t('alerts.forDuration', { sec: r.for_sec }));
+    return parts.join(', ');
+  };
+
+  const onToggle = async (record: AlertRule, checked: boolean) => {
+    try {
+      await saveAlertRule({ ...record, enabled: checked });
+      refreshRules();
+    } catch (e) {
+      message.error(t('common.error'));
+    }
+  };
+
+  const onEdit = (record: AlertRule) => {
+    setEditing(record);
+    form.setFieldsValue({ ...record, resolve_threshold: record.resolve_threshold ?? undefined });
+    setOpen(true);
+  };
+
+  const onCreate = () => {
+    setEditing(null);
+    form.resetFields();
+    form.setFieldsValue({ enabled: true, metric: 'packet_loss', operator: '>', threshold: 5, cycles: 3, recover_cycles: 2, baseline_days: 0, for_sec: 0 });
+    setOpen(true);
+  };
+
+  const onDelete = async (id?: number) => {
+    if (!id) return;
+    await deleteAlertRule(id);
+    refreshRules();
+    refreshAlerts();
+  };
+
+  const onSubmit = async () => {
+    const values = await form.validateFields();
+    const payload: AlertRule = {
+      id: editing?.id,
+      name: values.name,
+      enabled: values.enabled ?? true,
+      target: values.target || '',
+      group: values.group || '',
+      metric: values.metric,
+      operator: values.operator,
+      threshold: values.threshold,
+      baseline_days: values.baseline_days || 0,
+      cycles: values.cycles || 1,
+      for_sec: values.for_sec || 0,
+      recover_cycles: values.recover_cycles || 1,
+      resolve_threshold: values.resolve_threshold ?? null,
+    };
+    try {
+      await saveAlertRule(payload);
+      setOpen(false);
+      refreshRules();
+      refreshAlerts();
+    } catch {
+      // The request interceptor already shows the server's error
+    }
+  };
+
+  const ruleColumns = [
+    { title: t('alerts.name'), dataIndex: 'name' },
+    {
+      title: t('alerts.scope'),
+      key: 'scope',
+      render: (_: any, r: AlertRule) => (
+        <Space size={4}>
+          {r.target && <Tag>{targetNames[r.target] || r.target}</Tag>}
+          {r.group && <Tag color="geekblue">{r.group}</Tag>}
+          {!r.target && !r.group && <Tag>{t('alerts.allTargets')}</Tag>}
+        </Space>
+      ),
+    },
+    { title: t('alerts.condition'), key: 'condition', render: (_: any, r: AlertRule) => describeRule(r) },
+    {
+      title: t('common.status'),
+      dataIndex: 'enabled',
+      render: (val: boolean, r: AlertRule) => (
+        <Switch
+          checked={val}
+          onChange={(checked) => onToggle(r, checked)}
+          checkedChildren={t('common.enabled')}
+          unCheckedChildren={t('common.disabled')}
+        />
+      ),
+    },
+    {
+      title: t('common.actions'),
+      render: (_: any, r: AlertRule) => (
+        <Space>
+          <Button type="link" onClick={() => onEdit(r)}>{t('common.edit')}</Button>
+          <Button type="link" danger onClick={() => onDelete(r.id)}>{t('common.delete')}</Button>
+        </Space>
+      ),
+    },
+  ];
+
+  const activeColumns = [
+    { title: t('alerts.rule'), dataIndex: 'rule_id', render: (id: number) => ruleNames[id] || `#${id}` },
+    { title: t('alerts.target'), dataIndex: 'target', render: (v: string) => targetNames[v] || v },
+    { title: t('common.status'), dataIndex: 'state', render: (v: string) => <Tag color={stateColors[v]}>{t(`alerts.states.${v}`)}</Tag> },
+    {
+      title: t('alerts.value'),
+      key: 'value',
+      render: (_: any, r: AlertState) => `${r.last_value.toFixed(2)} / ${r.threshold.toFixed(2)}`,
+    },
+    {
+      title: t('alerts.since'),
+      key: 'since',
+      render: (_: any, r: AlertState) => {
+        const since = r.state === 'firing' ? r.fired_at : r.breach_since;
+        return since ? new Date(since).toLocaleString() : '-';
+      },
+    },
+  ];
+
+  const eventColumns = [
+    { title: t('alerts.time'), dataIndex: 'created_at', render: (v: string) => new Date(v).toLocaleString() },
+    {
+      title: t('common.status'),
+      dataIndex: 'state',
+      render: (v: string, r: AlertEvent) => (
+        <Space size={4}>
+          <Tag color={stateColors[v]}>{t(`alerts.states.${v}`)}</Tag>
+          {r.caused_by ? (
+            <Tag>{t('alerts.causedBy', { parent: targetNames[r.caused_by] || r.caused_by })}</Tag>
+          ) : (
+            r.silenced && <Tag>{t('alerts.silenced')}</Tag>
+          )}
+        </Space>
+      ),
+    },
+    {
+      title: t('alerts.rule'),
+      dataIndex: 'rule_name',
+      render: (v: string, r: AlertEvent) => <Tooltip title={r.message}>{v}</Tooltip>,
+    },
+    { title: t('alerts.target'), dataIndex: 'target', render: (v: string) => targetNames[v] || v },
+    {
+      title: t('alerts.value'),
+      key: 'value',
+      render: (_: any, r: AlertEvent) => `${r.value.toFixed(2)} / ${r.threshold.toFixed(2)}`,
+    },
+  ];
+
+  return (
+    <Space direction="vertical" size="large" style={{ width: '100%' }}>
+      <Card className="page-card" title={t('alerts.active')}>
+        <Table rowKey="id" size="small" dataSource={alerts?.active || []} columns={activeColumns} pagination={false} locale={{ emptyText: t('alerts.noneActive') }} />
+      </Card>
+
+      <Card className="page-card" title={t('alerts.rules')} extra={<Button icon={<PlusOutlined />} onClick={onCreate}>{t('alerts.newRule')}</Button>}>
+        <Table rowKey="id" loading={loading} dataSource={rules} columns={ruleColumns} />
+      </Card>
+
+      <MaintenanceWindows />
+
+      <NotificationChannels />
+
+      <Card className="page-card" title={t('alerts.history')}>
+        <Table rowKey="id" size="small" dataSource={alerts?.events || []} columns={eventColumns} pagination={{ pageSize: 20 }} />
+      </Card>
+
+      <Modal
+        title={editing ? t('alerts.editRule') : t('alerts.newRule')}
+        open={open}
+        onOk={onSubmit}
+        onCancel={() => setOpen(false)}
+        destroyOnClose
+      >
+        <Form layout="vertical" form={form} preserve={false}>
+          <Form.Item name="name" label={t('alerts.name')} rules={[{ required: true }]}>
+            <Input placeholder="Packet loss" />
+          </Form.Item>
+          <Space style={{ width: '100%' }} align="start">
+            <Form.Item name="target" label={t('alerts.target')} extra={t('alerts.scopeHint')}>
+              <Select
+                allowClear
+                style={{ width: 200 }}
+                options={targets.map((tg) => ({ label: tg.name, value: tg.address }))}
+              />
+            </Form.Item>
+            <Form.Item name="group" label={t('alerts.group')}>
+              <Select allowClear style={{ width: 200 }} options={groupOptions} />
+            </Form.Item>
+          </Space>
+          <Space style={{ width: '100%' }} align="start">
+            <Form.Item name="metric" label={t('alerts.metric')} rules={[{ required: true }]}>
+              <Select style={{ width: 180 }} options={metricOptions} />
+            </Form.Item>
+            <Form.Item name="operator" label={t('alerts.operator')} rules={[{ required: true }]}>
+              <Select style={{ width: 80 }} options={operatorOptions} />
+            </Form.Item>
+            <Form.Item name="threshold" label={t('alerts.threshold')} rules={[{ required: true }]}>
+              <InputNumber style={{ width: 120 }} />
+            </Form.Item>
+          </Space>
+          <Form.Item name="baseline_days" label={t('alerts.baselineDays')} extra={t('alerts.baselineHint')}>
+            <InputNumber min={0} max={90} />
+          </Form.Item>
+          <Space style={{ width: '100%' }} align="start">
+            <Form.Item name="cycles" label={t('alerts.cycles')}>
+              <InputNumber min={1} max={1000} />
+            </Form.Item>
+            <Form.Item name="for_sec" label={t('alerts.forSec')}>
+              <InputNumber min={0} max={604800} />
+            </Form.Item>
+          </Space>
+          <Space style={{ width: '100%' }} align="start">
+            <Form.Item name="recover_cycles" label={t('alerts.recoverCycles')}>
+              <InputNumber min={1} max={1000} />
+            </Form.Item>
+            <Form.Item name="resolve_threshold" label={t('alerts.resolveThreshold')} extra={t('alerts.resolveHint')}>
+              <InputNumber style={{ width: 160 }} />
+            </Form.Item>
+          </Space>
+          <Form.Item name="enabled" label={t('common.status')} valuePropName="checked">
+            <Switch checkedChildren={t('common.enabled')} unCheckedChildren={t('common.disabled')} />
+          </Form.Item>
+        </Form>
+      </Modal>
+    </Space>
+  );
+};
+
+export default Alerts;
@@ -4,10 +4,14 @@ import { CheckCircleOutlined, CloseCircleOutlined, MinusCircleOutlined } from '@
 import type { ColumnsType } from 'antd/es/table';
 import { useRequest } from 'ahooks';
 import { useTranslation } from 'react-i18next';
-import { getHistory, getLatestTrace, getTargets } from '../api';
-import type { Target } from '../api';
+import { getAvailability, getHistory, getHostClock, getIncidents, getInterfaceHistory, getLatestTrace, getMetrics, getRouteChanges, getRoutePaths, getTargets } from '../api';
+import type { MetricRecord, Target } from '../api';
 import MapChart from '../components/MapChart';
+import CheckMetricsChart from '../components/CheckMetricsChart';
+import HopHistory from '../components/HopHistory';
+import IncidentList from '../components/IncidentList';
 import MetricsChart from '../components/MetricsChart';
+import RouteHistory from '../components/RouteHistory';
 import { useTheme } from '../context/ThemeContext';
 
 interface HopRow {
@@ -28,6 +32,18 @@ interface HopRow {
   geoPrecision: string;
 }
 
+// Probe types that record check metrics rather than speed tests
+const bloatGradeColors: Record<string, string> = {
+  'A+': 'green',
+  A: 'green',
+  B: 'lime',
+  C: 'gold',
+  D: 'orange',
+  F: 'red',
+};
+
+const checkProbeTypes = ['MODE_SSH_SESSION', 'MODE_PLUGIN', 'MODE_GRPC', 'MODE_NTP', 'MODE_TWAMP', 'MODE_UDP_ECHO'];
+
 const Dashboard: React.FC = () => {
   const { isDark } = useTheme();
   const { t, i18n } = useTranslation();
@@ -35,6 +51,7 @@ const Dashboard: React.FC = () => {
   const [trace, setTrace] = useState<any>(null);
   const [timeRange, setTimeRange] = useState<number>(1); // hours (default 1h)
   const [countdown, setCountdown] = useState<number>(0);
+  const [historyHop, setHistoryHop] = useState<HopRow>();
 
   // Calculate polling interval based on time range (shorter range = faster refresh)
   const pollingInterval = useMemo(() => {
@@ -97,6 +114,108 @@ const Dashboard: React.FC = () => {
     }
   );
 
+  const { data: availability } = useRequest(
+    () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - timeRange * 60 * 60 * 1000);
+      return getAvailability({ target: selectedTarget, start: start.toISOString(), end: end.toISOString() });
+    },
+    {
+      refreshDeps: [selectedTarget, timeRange],
+      ready: !!selectedTarget,
+      pollingInterval,
+    }
+  );
+
+  const { data: incidentData } = useRequest(
+    () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - timeRange * 60 * 60 * 1000);
+      return getIncidents({ target: selectedTarget, start: start.toISOString(), end: end.toISOString() });
+    },
+    {
+      refreshDeps: [selectedTarget, timeRange],
+      ready: !!selectedTarget,
+      pollingInterval,
+    }
+  );
+
+  const { data: routeData } = useRequest(
+    async () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - timeRange * 60 * 60 * 1000);
+      const params = { target: selectedTarget, start: start.toISOString(), end: end.toISOString() };
+      const [paths, changes] = await Promise.all([getRoutePaths(params), getRouteChanges(params)]);
+      return { paths, changes };
+    },
+    {
+      refreshDeps: [selectedTarget, timeRange],
+      ready: !!selectedTarget,
+      pollingInterval,
+    }
+  );
+
+  // Check probes (SSH session, plugin, gRPC, NTP, TWAMP, UDP) record named series instead of speed results
+  const { data: checkMetrics = [] } = useRequest(
+    () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - timeRange * 60 * 60 * 1000);
+      return getMetrics({ target: selectedTarget, start: start.toISOString(), end: end.toISOString() });
+    },
+    {
+      refreshDeps: [selectedTarget, timeRange],
+      ready: !!selectedTarget,
+      pollingInterval,
+    }
+  );
+
+  const { data: interfaceHistory = [] } = useRequest(
+    () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - timeRange * 60 * 60 * 1000);
+      return getInterfaceHistory({ target: selectedTarget, start: start.toISOString(), end: end.toISOString() });
+    },
+    {
+      refreshDeps: [selectedTarget, timeRange],
+      ready: !!selectedMeta?.snmp_config,
+      pollingInterval,
+    }
+  );
+
+  // One in/out series per interface, in Mbps
+  const interfaceSeries = useMemo(() => {
+    const records: MetricRecord[] = [];
+    interfaceHistory.forEach((r) => {
+      records.push({ ...r, probe: 'snmp', name: `${r.interface} in`, value: r.in_bps / 1e6, unit: ' Mbps' });
+      records.push({ ...r, probe: 'snmp', name: `${r.interface} out`, value: r.out_bps / 1e6, unit: ' Mbps' });
+    });
+    return records;
+  }, [interfaceHistory]);
+
+  // Percent series (e.g. TWAMP/UDP loss) get their own chart next to the ms one
+  // NTP reference IDs in the range, one entry per change of upstream
+  const refIDChanges = useMemo(
+    () =>
+      checkMetrics
+        .filter((r) => r.name === 'refid' && r.label)
+        .filter((r, i, all) => i === 0 || all[i - 1].label !== r.label),
+    [checkMetrics]
+  );
+
+  const checkPercentNames = useMemo(
+    () => Array.from(new Set(checkMetrics.filter((r) => r.unit === '%').map((r) => r.name))),
+    [checkMetrics]
+  );
+
+  const { data: hostClock } = useRequest(
+    () => {
+      const end = new Date();
+      const start = new Date(end.getTime() - timeRange * 60 * 60 * 1000);
+      return getHostClock({ start: start.toISOString(), end: end.toISOString() });
+    },
+    { refreshDeps: [timeRange], pollingInterval }
+  );
+
   // Re-fetch trace when language changes for localized location names
   useRequest(
     () => getLatestTrace(selectedTarget, i18n.language),
@@ -108,12 +227,20 @@ const Dashboard: React.FC = () => {
     }
   );
 
-  const avgLatency = history.length
-    ? history.reduce((sum: number, h: any) => sum + (h.latency_ms || h.LatencyMs || 0), 0) / history.length
+  // Adaptive samples only add resolution to bad periods; counting them
+  // would weigh those periods more than the rest
+  const regular = history.filter((h: any) => !h.adaptive);
+  // Failed cycles have no latency; averaging their zeros would flatter the result
+  const measured = regular.filter((h: any) => !h.status || h.status === 'ok');
+  const avgLatency = measured.length
+    ? measured.reduce((sum: number, h: any) => sum + (h.latency_ms || h.LatencyMs || 0), 0) / measured.length
     : 0;
-  const avgLoss = history.length
-    ? history.reduce((sum: number, h: any) => sum + (h.packet_loss || h.PacketLoss || 0), 0) / history.length
+  const avgLoss = regular.length
+    ? regular.reduce((sum: number, h: any) => sum + (h.packet_loss || h.PacketLoss || 0), 0) / regular.length
     : 0;
+  // The target is being probed faster while its newest sample is adaptive
+  const lastSample = history.length ? history[history.length - 1] : null;
+  const fastProbing = !!lastSample?.adaptive && Date.now() - new Date(lastSample.created_at).getTime() < 60000;
   
   // Find the most recent record with speed data (speed tests run less frequently than pings)
   const latestSpeedRecord = useMemo(() => {
@@ -122,7 +249,7 @@ const Dashboard: React.FC = () => {
       const down = h.speed_down || h.SpeedDown || 0;
       const up = h.speed_up || h.SpeedUp || 0;
       if (down > 0 || up > 0) {
-        return { down, up, time: h.created_at || h.CreatedAt };
+        return { down, up, time: h.created_at || h.CreatedAt, bloatGrade: h.bloat_grade as string | undefined };
       }
     }
     return null;
@@ -132,7 +259,8 @@ const Dashboard: React.FC = () => {
   const lastSpeedUp = latestSpeedRecord?.up || 0;
 
   // Speed test status indicator
-  const isSpeedEnabled = selectedMeta && selectedMeta.probe_type !== 'MODE_ICMP' && selectedMeta.probe_type !== '';
+  const isSpeedEnabled = selectedMeta && selectedMeta.probe_type !== 'MODE_ICMP' && selectedMeta.probe_type !== ''
+    && !checkProbeTypes.includes(selectedMeta.probe_type);
   const hasSpeedError = isSpeedEnabled && selectedMeta?.last_error;
   const speedStatusIcon = useMemo(() => {
     if (!isSpeedEnabled) return null;
@@ -213,7 +341,17 @@ const Dashboard: React.FC = () => {
   });
 
   const hopColumns: ColumnsType<HopRow> = [
-    { title: t('hopTable.hop'), dataIndex: 'hop', width: 50, align: 'center' },
+    {
+      title: t('hopTable.hop'),
+      dataIndex: 'hop',
+      width: 50,
+      align: 'center',
+      render: (val: number, row: HopRow) => (
+        <Tooltip title={t('hopHistory.open')}>
+          <Typography.Link onClick={() => setHistoryHop(row)}>{val}</Typography.Link>
+        </Tooltip>
+      ),
+    },
     {
       title: t('hopTable.ipHost'),
       dataIndex: 'host',
@@ -284,6 +422,18 @@ const Dashboard: React.FC = () => {
         <Col span={8}>
           <Card className="page-card" style={{ height: 120 }}>
             <Statistic title={t('dashboard.packetLoss')} value={avgLoss} suffix="%" precision={2} />
+            {availability && availability.cycles > 0 && (
+              <Tooltip
+                title={Object.entries(availability.by_status)
+                  .map(([status, count]) => `${status}: ${count}`)
+                  .concat(availability.maintenance > 0 ? [`${t('dashboard.inMaintenance')}: ${availability.maintenance}`] : [])
+                  .join(', ')}
+              >
+                <Typography.Text type="secondary" style={{ fontSize: 12, cursor: 'help' }}>
+                  {t('dashboard.availability')}: {availability.availability.toFixed(2)}%
+                </Typography.Text>
+              </Tooltip>
+            )}
           </Card>
         </Col>
         <Col span={8}>
@@ -292,6 +442,13 @@ const Dashboard: React.FC = () => {
               <Typography.Text type="secondary" style={{ fontSize: 14 }}>
                 {t('dashboard.bandwidth') || 'Bandwidth'} {speedStatusIcon}
               </Typography.Text>
+              {isSpeedEnabled && latestSpeedRecord?.bloatGrade && (
+                <Tooltip title={t('dashboard.bloatGradeHint')}>
+                  <Tag color={bloatGradeColors[latestSpeedRecord.bloatGrade]} style={{ float: 'right', cursor: 'help' }}>
+                    {t('dashboard.bloatGrade')} {latestSpeedRecord.bloatGrade}
+                  </Tag>
+                </Tooltip>
+              )}
             </div>
             <Row>
               <Col span={12}>
@@ -364,6 +521,14 @@ const Dashboard: React.FC = () => {
               ]}
             />
           </Card>
+          <HopHistory
+            target={selectedTarget}
+            hop={historyHop}
+            hours={timeRange}
+            targets={targets}
+            isDark={isDark}
+            onClose={() => setHistoryHop(undefined)}
+          />
         </Col>
         <Col span={8}>
           <Card 
@@ -371,6 +536,11 @@ const Dashboard: React.FC = () => {
             title={t('dashboard.historicalMetrics')}
             extra={
               <Space>
+                {fastProbing && (
+                  <Tooltip title={t('dashboard.fastProbingHint')}>
+                    <Tag color="orange">{t('dashboard.fastProbing')}</Tag>
+                  </Tooltip>
+                )}
                 <Tooltip title={t('dashboard.autoRefresh') || 'Auto-refresh'}>
                   <span style={{
                     display: 'inline-flex',
@@ -398,8 +568,75 @@ const Dashboard: React.FC = () => {
               </Space>
             }
           >
-            <MetricsChart history={history} isDark={isDark} />
+            <MetricsChart history={history} isDark={isDark} routeChanges={routeData?.changes} />
           </Card>
+          {checkMetrics.length > 0 && (
+            <Card
+              className="chart-card"
+              title={t('dashboard.checkMetrics')}
+              extra={
+                refIDChanges.length > 0 && (
+                  <Tooltip
+                    title={refIDChanges.map((r) => (
+                      <div key={r.id}>
+                        {new Date(r.created_at).toLocaleString()} → {r.label}
+                      </div>
+                    ))}
+                  >
+                    <Tag>{t('dashboard.ntpReference', { refid: refIDChanges[refIDChanges.length - 1].label })}</Tag>
+                  </Tooltip>
+                )
+              }
+              style={{ marginTop: 16 }}
+            >
+              <CheckMetricsChart records={checkMetrics} isDark={isDark} />
+            </Card>
+          )}
+          {checkPercentNames.length > 0 && (
+            <Card className="chart-card" title={t('dashboard.checkLoss')} style={{ marginTop: 16 }}>
+              <CheckMetricsChart records={checkMetrics} isDark={isDark} names={checkPercentNames} />
+            </Card>
+          )}
+          {(interfaceSeries.length > 0 || selectedMeta?.snmp_error) && (
+            <Card
+              className="chart-card"
+              title={t('dashboard.interfaceTraffic')}
+              extra={
+                selectedMeta?.snmp_error && (
+                  <Tooltip title={selectedMeta.snmp_error} color="red">
+                    <Tag color="red">{t('dashboard.snmpFailing')}</Tag>
+                  </Tooltip>
+                )
+              }
+              style={{ marginTop: 16 }}
+            >
+              <CheckMetricsChart
+                records={interfaceSeries}
+                isDark={isDark}
+                names={Array.from(new Set(interfaceSeries.map((r) => r.name)))}
+              />
+            </Card>
+          )}
+          {hostClock?.reference && (
+            <Card
+              className="chart-card"
+              title={t('dashboard.hostClock')}
+              extra={<Typography.Text type="secondary">{hostClock.reference}</Typography.Text>}
+              style={{ marginTop: 16 }}
+            >
+              <CheckMetricsChart records={hostClock.records} isDark={isDark} names={['offset_ms', 'delay_ms']} />
+            </Card>
+          )}
+          {incidentData && (
+            <Card className="page-card" title={t('incidents.title')} style={{ marginTop: 16 }}>
+              <IncidentList incidents={incidentData.incidents} summary={incidentData.summary} />
+            </Card>
+          )}
+          {routeData && (
+            <Card className="page-card" title={t('routes.title')} style={{ marginTop: 16 }}>
+              <RouteHistory paths={routeData.paths} changes={routeData.changes} />
+            </Card>
+          )}
         </Col>
       </Row>
     </div>
@@ -1,23 +1,48 @@
 import React, { useMemo, useState } from 'react';
-import { Button, Card, Form, Input, Modal, Select, Space, Switch, Table, Tag, Upload, message } from 'antd';
-import { PlusOutlined, UploadOutlined } from '@ant-design/icons';
+import { Button, Card, Form, Input, Modal, Select, Space, Switch, Table, Tag, Tooltip, Upload, message } from 'antd';
+import { ApartmentOutlined, MinusCircleOutlined, PlusOutlined, UploadOutlined } from '@ant-design/icons';
 import { useRequest } from 'ahooks';
 import { useTranslation } from 'react-i18next';
 import type { Target } from '../api';
-import { deleteTarget, getTargets, saveTarget } from '../api';
+import { acceptHostKey, deleteTarget, forgetHostKey, getTargets, saveTarget } from '../api';
+import ParentSuggestions from '../components/ParentSuggestions';
 
 const probeOptions = [
   { label: 'ICMP', value: 'MODE_ICMP' },
   { label: 'HTTP', value: 'MODE_HTTP' },
   { label: 'SSH', value: 'MODE_SSH' },
+  { label: 'SSH Session', value: 'MODE_SSH_SESSION' },
   { label: 'IPERF', value: 'MODE_IPERF' },
+  { label: 'RouteLens Peer', value: 'MODE_ROUTELENS' },
+  { label: 'LibreSpeed', value: 'MODE_LIBRESPEED' },
+  { label: 'Plugin (Nagios)', value: 'MODE_PLUGIN' },
+  { label: 'gRPC Health', value: 'MODE_GRPC' },
+  { label: 'NTP', value: 'MODE_NTP' },
+  { label: 'TWAMP-Light', value: 'MODE_TWAMP' },
+  { label: 'UDP Stream (RouteLens)', value: 'MODE_UDP_ECHO' },
 ];
 
+// Bandwidth probes, which can sample latency under load while they run
+const speedProbeTypes = ['MODE_HTTP', 'MODE_SSH', 'MODE_IPERF', 'MODE_ROUTELENS', 'MODE_LIBRESPEED'];
+
+const pluginStatusColors: Record<string, string> = {
+  OK: 'green',
+  WARNING: 'orange',
+  CRITICAL: 'red',
+  UNKNOWN: 'purple',
+  SERVING: 'green',
+  NOT_SERVING: 'red',
+  SERVICE_UNKNOWN: 'orange',
+  SYNCED: 'green',
+  UNSYNCED: 'red',
+};
+
 const Targets: React.FC = () => {
   const { t } = useTranslation();
   const [form] = Form.useForm();
   const [open, setOpen] = useState(false);
   const [editing, setEditing] = useState<Target | null>(null);
+  const [suggestOpen, setSuggestOpen] = useState(false);
 
   const { data = [], refresh, loading } = useRequest(getTargets);
 
@@ -39,7 +64,21 @@ const Targets: React.FC = () => {
   const columns = useMemo(() => [
     { title: t('targets.name'), dataIndex: 'name' },
     { title: t('targets.hostIp'), dataIndex: 'address' },
+    {
+      title: t('targets.parent'),
+      dataIndex: 'parent',
+      render: (val: string) => (val ? <Tag>{data.find((tg) => tg.address === val)?.name || val}</Tag> : null),
+    },
     { title: t('targets.probeType'), dataIndex: 'probe_type', render: (val: string) => <Tag color="blue">{val}</Tag> },
+    {
+      title: t('targets.checkStatus'),
+      dataIndex: 'last_status',
+      render: (val: string, record: Target) => val ? (
+        <Tooltip title={<span style={{ whiteSpace: 'pre-wrap' }}>{record.last_message}</span>}>
+          <Tag color={pluginStatusColors[val] || 'default'}>{val}</Tag>
+        </Tooltip>
+      ) : null,
+    },
     { 
       title: t('common.status'), 
       dataIndex: 'enabled', 
@@ -57,12 +96,15 @@ const Targets: React.FC = () => {
       render: (_: any, record: Target) => (
         <Space>
           <Button type="link" onClick={() => onEdit(record)}>{t('common.edit')}</Button>
+          {record.ssh_host_key_pending && (
+            <Button type="link" danger onClick={() => onAcceptHostKey(record)}>{t('targets.acceptHostKey')}</Button>
+          )}
           <Button type="link" danger onClick={() => onDelete(record.id)}>{t('common.delete')}</Button>
         </Space>
       ),
     },
   // eslint-disable-next-line react-hooks/exhaustive-deps
-  ], [t]);
+  ], [t, data]);
 
   const onEdit = (record: Target) => {
     setEditing(record);
@@ -77,10 +119,22 @@ const Targets: React.FC = () => {
       }
     }
     
+    let snmpConfig: Record<string, any> = {};
+    if (record.snmp_config) {
+      try {
+        snmpConfig = JSON.parse(record.snmp_config);
+      } catch {
+        // ignore parse error
+      }
+    }
+
     form.setFieldsValue({
       name: record.name,
       address: record.address,
       desc: record.desc,
+      group: record.group || '',
+      tags: (record.tags || '').split(',').filter(Boolean),
+      parent: record.parent || undefined,
       enabled: record.enabled,
       probe_type: record.probe_type,
       // HTTP fields
@@ -90,12 +144,100 @@ const Targets: React.FC = () => {
       ssh_port: parsedConfig.port || 22,
       ssh_key_path: parsedConfig.key_path || '',
       ssh_key_text: parsedConfig.key_text || '',
+      ssh_method: parsedConfig.method || 'exec',
+      ssh_host_key: record.ssh_host_key || '',
+      ssh_remote_dir: parsedConfig.remote_dir || '',
+      ssh_command: parsedConfig.command || '',
+      ssh_streams: parsedConfig.streams || 1,
+      ssh_connections: parsedConfig.connections || 1,
+      ssh_duration: parsedConfig.duration || '',
+      ssh_jump_hosts: parsedConfig.jump_hosts || [],
+      ssh_reverse_enabled: (parsedConfig.reverse as any)?.enabled || false,
+      ssh_reverse_destination: (parsedConfig.reverse as any)?.destination || '',
       // iPerf fields
       iperf_port: parsedConfig.port || 5201,
+      iperf_udp: parsedConfig.udp || false,
+      iperf_bitrate: parsedConfig.bitrate || '',
+      iperf_reverse: parsedConfig.reverse || false,
+      iperf_parallel: parsedConfig.parallel || 1,
+      iperf_duration: parsedConfig.duration || 5,
+      iperf_congestion: parsedConfig.congestion || '',
+      // RouteLens peer fields
+      peer_url: parsedConfig.url || '',
+      peer_token: parsedConfig.token || '',
+      // LibreSpeed fields
+      librespeed_url: parsedConfig.url || '',
+      // Plugin fields
+      plugin_command: parsedConfig.command || '',
+      plugin_args: parsedConfig.args || [],
+      plugin_timeout: parsedConfig.timeout || '',
+      // gRPC fields
+      grpc_port: parsedConfig.port || '',
+      grpc_service: parsedConfig.service || '',
+      grpc_tls: parsedConfig.tls || false,
+      grpc_insecure: parsedConfig.insecure || false,
+      grpc_server_name: parsedConfig.server_name || '',
+      grpc_ca_file: parsedConfig.ca_file || '',
+      grpc_timeout: parsedConfig.timeout || '',
+      // NTP fields
+      ntp_port: parsedConfig.port || '',
+      ntp_timeout: parsedConfig.timeout || '',
+      // TWAMP fields
+      twamp_port: parsedConfig.port || '',
+      twamp_count: parsedConfig.count || '',
+      twamp_interval: parsedConfig.interval_ms || '',
+      twamp_packet_size: parsedConfig.packet_size || '',
+      twamp_clock_synced: parsedConfig.clock_synced || false,
+      // UDP echo stream fields
+      udp_port: parsedConfig.port || '',
+      udp_pps: parsedConfig.pps || '',
+      udp_packet_size: parsedConfig.packet_size || '',
+      udp_duration: parsedConfig.duration || '',
+      // Bufferbloat sampling (speed probes)
+      bloat_enabled: parsedConfig.bufferbloat?.enabled || false,
+      bloat_method: parsedConfig.bufferbloat?.method || 'icmp',
+      bloat_port: parsedConfig.bufferbloat?.port || '',
+      // SNMP interface polling (independent of probe type)
+      snmp_enabled: !!record.snmp_config,
+      snmp_host: snmpConfig.host || '',
+      snmp_port: snmpConfig.port || '',
+      snmp_version: snmpConfig.version || '2c',
+      snmp_community: snmpConfig.community || '',
+      snmp_interfaces: snmpConfig.interfaces || [],
+      snmp_user: snmpConfig.user || '',
+      snmp_auth_protocol: snmpConfig.auth_protocol || 'SHA',
+      snmp_auth_password: snmpConfig.auth_password || '',
+      snmp_priv_protocol: snmpConfig.priv_protocol || 'AES',
+      snmp_priv_password: snmpConfig.priv_password || '',
     });
     setOpen(true);
   };
 
+  const onAcceptHostKey = (record: Target) => {
+    if (!record.id) return;
+    Modal.confirm({
+      title: t('targets.acceptHostKey'),
+      content: (
+        <>
+          <p>{t('targets.acceptHostKeyWarning')}</p>
+          <Input.TextArea readOnly rows={3} value={record.ssh_host_key_pending} />
+        </>
+      ),
+      okButtonProps: { danger: true },
+      onOk: async () => {
+        await acceptHostKey(record.id!);
+        refresh();
+      },
+    });
+  };
+
+  const onForgetHostKey = async () => {
+    if (!editing?.id) return;
+    await forgetHostKey(editing.id);
+    form.setFieldValue('ssh_host_key', '');
+    refresh();
+  };
+
   const onDelete = async (id?: number) => {
     if (!id) return;
     await deleteTarget(id);
@@ -105,7 +247,7 @@ const Targets: React.FC = () => {
   const onCreate = () => {
     setEditing(null);
     form.resetFields();
-    form.setFieldsValue({ enabled: true, probe_type: 'MODE_ICMP' });
+    form.setFieldsValue({ enabled: true, probe_type: 'MODE_ICMP', ssh_method: 'exec', snmp_version: '2c', snmp_auth_protocol: 'SHA', snmp_priv_protocol: 'AES' });
     setOpen(true);
   };
 
@@ -129,14 +271,113 @@ const Targets: React.FC = () => {
           key_path: values.ssh_key_path || '',
           key_text: values.ssh_key_text || '',
           port: Number(values.ssh_port || 22),
+          method: values.ssh_method || 'exec',
+          remote_dir: values.ssh_remote_dir || '',
+          streams: Number(values.ssh_streams || 1),
+          connections: Number(values.ssh_connections || 1),
+          duration: Number(values.ssh_duration || 0),
+          jump_hosts: (values.ssh_jump_hosts || [])
+            .filter((j: any) => j && j.host)
+            .map((j: any) => ({ ...j, port: Number(j.port || 22) })),
+          reverse: {
+            enabled: values.ssh_reverse_enabled || false,
+            destination: values.ssh_reverse_destination || '',
+          },
+        });
+      case 'MODE_SSH_SESSION':
+        return JSON.stringify({
+          user: values.ssh_user || 'root',
+          key_path: values.ssh_key_path || '',
+          key_text: values.ssh_key_text || '',
+          port: Number(values.ssh_port || 22),
+          command: values.ssh_command || '',
+          jump_hosts: (values.ssh_jump_hosts || [])
+            .filter((j: any) => j && j.host)
+            .map((j: any) => ({ ...j, port: Number(j.port || 22) })),
         });
       case 'MODE_IPERF':
-        return JSON.stringify({ port: Number(values.iperf_port || 5201) });
+        return JSON.stringify({
+          port: Number(values.iperf_port || 5201),
+          udp: values.iperf_udp || false,
+          bitrate: values.iperf_bitrate || '',
+          reverse: values.iperf_reverse || false,
+          parallel: Number(values.iperf_parallel || 1),
+          duration: Number(values.iperf_duration || 5),
+          congestion: values.iperf_congestion || '',
+        });
+      case 'MODE_ROUTELENS':
+        return JSON.stringify({ url: values.peer_url || '', token: values.peer_token || '' });
+      case 'MODE_LIBRESPEED':
+        return JSON.stringify({ url: values.librespeed_url || '' });
+      case 'MODE_PLUGIN':
+        return JSON.stringify({
+          command: values.plugin_command || '',
+          args: values.plugin_args || [],
+          timeout: Number(values.plugin_timeout || 0),
+        });
+      case 'MODE_GRPC':
+        return JSON.stringify({
+          port: Number(values.grpc_port || 0),
+          service: values.grpc_service || '',
+          tls: values.grpc_tls || false,
+          insecure: values.grpc_insecure || false,
+          server_name: values.grpc_server_name || '',
+          ca_file: values.grpc_ca_file || '',
+          timeout: Number(values.grpc_timeout || 0),
+        });
+      case 'MODE_NTP':
+        return JSON.stringify({
+          port: Number(values.ntp_port || 0),
+          timeout: Number(values.ntp_timeout || 0),
+        });
+      case 'MODE_TWAMP':
+        return JSON.stringify({
+          port: Number(values.twamp_port || 0),
+          count: Number(values.twamp_count || 0),
+          interval_ms: Number(values.twamp_interval || 0),
+          packet_size: Number(values.twamp_packet_size || 0),
+          clock_synced: values.twamp_clock_synced || false,
+        });
+      case 'MODE_UDP_ECHO':
+        return JSON.stringify({
+          port: Number(values.udp_port || 0),
+          pps: Number(values.udp_pps || 0),
+          packet_size: Number(values.udp_packet_size || 0),
+          duration: Number(values.udp_duration || 0),
+        });
       default:
         return '';
     }
   };
 
+  const withBufferbloat = (values: any, config: string) => {
+    if (!speedProbeTypes.includes(values.probe_type) || !values.bloat_enabled) return config;
+    return JSON.stringify({
+      ...(config ? JSON.parse(config) : {}),
+      bufferbloat: {
+        enabled: true,
+        method: values.bloat_method || 'icmp',
+        port: Number(values.bloat_port || 0),
+      },
+    });
+  };
+
+  const buildSNMPConfig = (values: any) => {
+    if (!values.snmp_enabled) return '';
+    return JSON.stringify({
+      host: values.snmp_host || '',
+      port: Number(values.snmp_port || 0),
+      version: values.snmp_version || '2c',
+      community: values.snmp_community || '',
+      interfaces: values.snmp_interfaces || [],
+      user: values.snmp_user || '',
+      auth_protocol: values.snmp_auth_protocol || '',
+      auth_password: values.snmp_auth_password || '',
+      priv_protocol: values.snmp_priv_protocol || '',
+      priv_password: values.snmp_priv_password || '',
+    });
+  };
+
   const onSubmit = async () => {
     const values = await form.validateFields();
     const payload: Target = {
@@ -144,9 +385,14 @@ const Targets: React.FC = () => {
       name: values.name,
       address: values.address,
       desc: values.desc || '',
+      group: values.group || '',
+      tags: (values.tags || []).join(','),
+      parent: values.parent || '',
       enabled: values.enabled ?? true,
       probe_type: values.probe_type,
-      probe_config: buildProbeConfig(values),
+      probe_config: withBufferbloat(values, buildProbeConfig(values)),
+      snmp_config: buildSNMPConfig(values),
+      ssh_host_key: values.probe_type === 'MODE_SSH' || values.probe_type === 'MODE_SSH_SESSION' ? values.ssh_host_key || '' : undefined,
     };
     await saveTarget(payload);
     setOpen(false);
@@ -154,7 +400,16 @@ const Targets: React.FC = () => {
   };
 
   return (
-    <Card className="page-card" title={t('targets.title')} extra={<Button icon={<PlusOutlined />} onClick={onCreate}>{t('targets.newTarget')}</Button>}>
+    <Card
+      className="page-card"
+      title={t('targets.title')}
+      extra={
+        <Space>
+          <Button icon={<ApartmentOutlined />} onClick={() => setSuggestOpen(true)}>{t('dependencies.suggest')}</Button>
+          <Button icon={<PlusOutlined />} onClick={onCreate}>{t('targets.newTarget')}</Button>
+        </Space>
+      }
+    >
       <Table rowKey="id" loading={loading} dataSource={data} columns={columns} />
 
       <Modal
@@ -171,6 +426,22 @@ const Targets: React.FC = () => {
           <Form.Item name="address" label={t('targets.hostIp')} rules={[{ required: true }]}>
             <Input placeholder="1.2.3.4" />
           </Form.Item>
+          <Form.Item name="group" label={t('targets.group')} extra={t('targets.groupHint')}>
+            <Input placeholder="edge" maxLength={64} />
+          </Form.Item>
+          <Form.Item name="tags" label={t('targets.tags')} extra={t('targets.tagsHint')}>
+            <Select mode="tags" tokenSeparators={[',']} />
+          </Form.Item>
+          <Form.Item name="parent" label={t('targets.parent')} extra={t('targets.parentHint')}>
+            <Select
+              allowClear
+              showSearch
+              optionFilterProp="label"
+              options={data
+                .filter((tg) => tg.address !== editing?.address)
+                .map((tg) => ({ label: `${tg.name} (${tg.address})`, value: tg.address }))}
+            />
+          </Form.Item>
           <Form.Item name="probe_type" label={t('targets.probeType')} rules={[{ required: true }]}>
             <Select options={probeOptions} />
           </Form.Item>
@@ -184,7 +455,8 @@ const Targets: React.FC = () => {
                   </Form.Item>
                 );
               }
-              if (mode === 'MODE_SSH') {
+              if (mode === 'MODE_SSH' || mode === 'MODE_SSH_SESSION') {
+                const session = mode === 'MODE_SSH_SESSION';
                 return (
                   <>
                     <Form.Item name="ssh_user" label={t('targets.sshUser')} rules={[{ required: true }]}>
@@ -202,24 +474,321 @@ const Targets: React.FC = () => {
                     <Upload beforeUpload={handleUpload} showUploadList={false}>
                       <Button icon={<UploadOutlined />}>{t('targets.uploadKey')}</Button>
                     </Upload>
+                    {session ? (
+                      <Form.Item name="ssh_command" label={t('targets.sshCommand')} extra={t('targets.sshCommandHint')} style={{ marginTop: 16 }}>
+                        <Input placeholder="echo routelens" />
+                      </Form.Item>
+                    ) : (
+                      <>
+                        <Form.Item name="ssh_method" label={t('targets.sshMethod')} style={{ marginTop: 16 }}>
+                          <Select
+                            options={[
+                              { label: t('targets.sshMethods.exec'), value: 'exec' },
+                              { label: t('targets.sshMethods.sftp'), value: 'sftp' },
+                            ]}
+                          />
+                        </Form.Item>
+                        <Form.Item name="ssh_remote_dir" label={t('targets.sshRemoteDir')}>
+                          <Input placeholder="/upload" />
+                        </Form.Item>
+                      </>
+                    )}
+                    <Form.Item name="ssh_host_key" label={t('targets.sshHostKey')} extra={t('targets.sshHostKeyHint')}>
+                      <Input.TextArea rows={2} placeholder="ssh-ed25519 AAAA..." />
+                    </Form.Item>
+                    {editing?.ssh_host_key && (
+                      <Button danger onClick={onForgetHostKey} style={{ marginBottom: 16 }}>{t('targets.forgetHostKey')}</Button>
+                    )}
+                    <Form.List name="ssh_jump_hosts">
+                      {(fields, { add, remove }) => (
+                        <Form.Item label={t('targets.sshJumpHosts')} extra={t('targets.sshJumpHostsHint')}>
+                          {fields.map((field) => (
+                            <Space key={field.key} align="baseline" wrap style={{ display: 'flex', marginBottom: 8 }}>
+                              <Form.Item name={[field.name, 'host']} noStyle rules={[{ required: true }]}>
+                                <Input placeholder="bastion.example.com" style={{ width: 170 }} />
+                              </Form.Item>
+                              <Form.Item name={[field.name, 'port']} noStyle>
+                                <Input placeholder="22" style={{ width: 64 }} />
+                              </Form.Item>
+                              <Form.Item name={[field.name, 'user']} noStyle>
+                                <Input placeholder={t('targets.sshUser')} style={{ width: 100 }} />
+                              </Form.Item>
+                              <Form.Item name={[field.name, 'password']} noStyle>
+                                <Input.Password placeholder={t('targets.sshPassword')} style={{ width: 130 }} />
+                              </Form.Item>
+                              <Form.Item name={[field.name, 'key_path']} noStyle>
+                                <Input placeholder={t('targets.sshKeyPath')} style={{ width: 150 }} />
+                              </Form.Item>
+                              <Form.Item name={[field.name, 'key_text']} hidden noStyle>
+                                <Input />
+                              </Form.Item>
+                              <Form.Item name={[field.name, 'host_key']} hidden noStyle>
+                                <Input />
+                              </Form.Item>
+                              <MinusCircleOutlined onClick={() => remove(field.name)} />
+                            </Space>
+                          ))}
+                          <Button type="dashed" onClick={() => add()} icon={<PlusOutlined />}>
+                            {t('targets.addJumpHost')}
+                          </Button>
+                        </Form.Item>
+                      )}
+                    </Form.List>
+                    {!session && (
+                      <>
+                        <Form.Item name="ssh_reverse_enabled" label={t('targets.sshReverse')} valuePropName="checked" extra={t('targets.sshReverseHint')}>
+                          <Switch />
+                        </Form.Item>
+                        <Form.Item name="ssh_reverse_destination" label={t('targets.sshReverseDestination')}>
+                          <Input placeholder={t('targets.sshReverseDestinationPlaceholder')} />
+                        </Form.Item>
+                        <Form.Item name="ssh_streams" label={t('targets.sshStreams')}>
+                          <Input placeholder="1" />
+                        </Form.Item>
+                        <Form.Item name="ssh_connections" label={t('targets.sshConnections')}>
+                          <Input placeholder="1" />
+                        </Form.Item>
+                        <Form.Item name="ssh_duration" label={t('targets.sshDuration')}>
+                          <Input placeholder="10" />
+                        </Form.Item>
+                      </>
+                    )}
                   </>
                 );
               }
               if (mode === 'MODE_IPERF') {
                 return (
-                  <Form.Item name="iperf_port" label={t('targets.iperfPort')} rules={[{ required: true }]}>
-                    <Input placeholder="5201" />
+                  <>
+                    <Form.Item name="iperf_port" label={t('targets.iperfPort')} rules={[{ required: true }]}>
+                      <Input placeholder="5201" />
+                    </Form.Item>
+                    <Form.Item name="iperf_udp" label={t('targets.iperfUdp')} valuePropName="checked">
+                      <Switch />
+                    </Form.Item>
+                    <Form.Item name="iperf_bitrate" label={t('targets.iperfBitrate')}>
+                      <Input placeholder="100M" />
+                    </Form.Item>
+                    <Form.Item name="iperf_reverse" label={t('targets.iperfReverse')} valuePropName="checked">
+                      <Switch />
+                    </Form.Item>
+                    <Form.Item name="iperf_parallel" label={t('targets.iperfParallel')}>
+                      <Input placeholder="1" />
+                    </Form.Item>
+                    <Form.Item name="iperf_duration" label={t('targets.iperfDuration')}>
+                      <Input placeholder="5" />
+                    </Form.Item>
+                    <Form.Item name="iperf_congestion" label={t('targets.iperfCongestion')}>
+                      <Input placeholder="bbr" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (mode === 'MODE_ROUTELENS') {
+                return (
+                  <>
+                    <Form.Item name="peer_url" label={t('targets.peerUrl')}>
+                      <Input placeholder="http://peer.example.com:8080" />
+                    </Form.Item>
+                    <Form.Item name="peer_token" label={t('targets.peerToken')} rules={[{ required: true }]}>
+                      <Input.Password placeholder="RS_SPEEDTEST_TOKEN" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (mode === 'MODE_LIBRESPEED') {
+                return (
+                  <Form.Item name="librespeed_url" label={t('targets.librespeedUrl')}>
+                    <Input placeholder="https://speed.example.com/" />
                   </Form.Item>
                 );
               }
+              if (mode === 'MODE_PLUGIN') {
+                return (
+                  <>
+                    <Form.Item name="plugin_command" label={t('targets.pluginCommand')} extra={t('targets.pluginCommandHint')} rules={[{ required: true }]}>
+                      <Input placeholder="/usr/lib/nagios/plugins/check_ping" />
+                    </Form.Item>
+                    <Form.Item name="plugin_args" label={t('targets.pluginArgs')} extra={t('targets.pluginArgsHint')}>
+                      <Select mode="tags" open={false} tokenSeparators={[]} placeholder="-H $HOSTADDRESS$" />
+                    </Form.Item>
+                    <Form.Item name="plugin_timeout" label={t('targets.pluginTimeout')}>
+                      <Input placeholder="10" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (mode === 'MODE_GRPC') {
+                return (
+                  <>
+                    <Form.Item name="grpc_port" label={t('targets.grpcPort')} rules={[{ required: true }]}>
+                      <Input placeholder="50051" />
+                    </Form.Item>
+                    <Form.Item name="grpc_service" label={t('targets.grpcService')} extra={t('targets.grpcServiceHint')}>
+                      <Input placeholder="my.package.MyService" />
+                    </Form.Item>
+                    <Form.Item name="grpc_tls" label={t('targets.grpcTls')} valuePropName="checked">
+                      <Switch />
+                    </Form.Item>
+                    <Form.Item noStyle shouldUpdate={(prev, cur) => prev.grpc_tls !== cur.grpc_tls}>
+                      {({ getFieldValue: get }) => get('grpc_tls') && (
+                        <>
+                          <Form.Item name="grpc_server_name" label={t('targets.grpcServerName')}>
+                            <Input placeholder="api.internal.example.com" />
+                          </Form.Item>
+                          <Form.Item name="grpc_ca_file" label={t('targets.grpcCaFile')}>
+                            <Input placeholder="/etc/ssl/internal-ca.pem" />
+                          </Form.Item>
+                          <Form.Item name="grpc_insecure" label={t('targets.grpcInsecure')} valuePropName="checked">
+                            <Switch />
+                          </Form.Item>
+                        </>
+                      )}
+                    </Form.Item>
+                    <Form.Item name="grpc_timeout" label={t('targets.grpcTimeout')}>
+                      <Input placeholder="5" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (mode === 'MODE_NTP') {
+                return (
+                  <>
+                    <Form.Item name="ntp_port" label={t('targets.ntpPort')}>
+                      <Input placeholder="123" />
+                    </Form.Item>
+                    <Form.Item name="ntp_timeout" label={t('targets.ntpTimeout')}>
+                      <Input placeholder="5" />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (mode === 'MODE_TWAMP') {
+                return (
+                  <>
+                    <Form.Item name="twamp_port" label={t('targets.twampPort')} extra={t('targets.twampPortHint')}>
+                      <Input placeholder="862" />
+                    </Form.Item>
+                    <Form.Item name="twamp_count" label={t('targets.twampCount')}>
+                      <Input placeholder="100" />
+                    </Form.Item>
+                    <Form.Item name="twamp_interval" label={t('targets.twampInterval')}>
+                      <Input placeholder="20" />
+                    </Form.Item>
+                    <Form.Item name="twamp_packet_size" label={t('targets.twampPacketSize')}>
+                      <Input placeholder="41" />
+                    </Form.Item>
+                    <Form.Item name="twamp_clock_synced" label={t('targets.twampClockSynced')} valuePropName="checked" extra={t('targets.twampClockSyncedHint')}>
+                      <Switch />
+                    </Form.Item>
+                  </>
+                );
+              }
+              if (mode === 'MODE_UDP_ECHO') {
+                return (
+                  <>
+                    <Form.Item name="udp_port" label={t('targets.udpPort')} extra={t('targets.udpPortHint')} rules={[{ required: true }]}>
+                      <Input placeholder="7862" />
+                    </Form.Item>
+                    <Form.Item name="udp_pps" label={t('targets.udpPps')}>
+                      <Input placeholder="50" />
+                    </Form.Item>
+                    <Form.Item name="udp_packet_size" label={t('targets.udpPacketSize')}>
+                      <Input placeholder="200" />
+                    </Form.Item>
+                    <Form.Item name="udp_duration" label={t('targets.udpDuration')}>
+                      <Input placeholder="10" />
+                    </Form.Item>
+                  </>
+                );
+              }
               return null;
             }}
           </Form.Item>
+          <Form.Item noStyle shouldUpdate={(prev, cur) => prev.probe_type !== cur.probe_type || prev.bloat_enabled !== cur.bloat_enabled || prev.bloat_method !== cur.bloat_method}>
+            {({ getFieldValue: get }) => speedProbeTypes.includes(get('probe_type')) && (
+              <>
+                <Form.Item name="bloat_enabled" label={t('targets.bloatEnabled')} valuePropName="checked" extra={t('targets.bloatEnabledHint')}>
+                  <Switch />
+                </Form.Item>
+                {get('bloat_enabled') && (
+                  <Form.Item name="bloat_method" label={t('targets.bloatMethod')}>
+                    <Select options={[{ label: 'ICMP', value: 'icmp' }, { label: 'TCP connect', value: 'tcp' }]} />
+                  </Form.Item>
+                )}
+                {get('bloat_enabled') && get('bloat_method') === 'tcp' && (
+                  <Form.Item name="bloat_port" label={t('targets.bloatPort')} extra={t('targets.bloatPortHint')} rules={[{ required: true }]}>
+                    <Input placeholder="443" />
+                  </Form.Item>
+                )}
+              </>
+            )}
+          </Form.Item>
+          <Form.Item name="snmp_enabled" label={t('targets.snmpEnabled')} valuePropName="checked" extra={t('targets.snmpEnabledHint')}>
+            <Switch />
+          </Form.Item>
+          <Form.Item noStyle shouldUpdate={(prev, cur) => prev.snmp_enabled !== cur.snmp_enabled || prev.snmp_version !== cur.snmp_version}>
+            {({ getFieldValue: get }) => get('snmp_enabled') && (
+              <>
+                <Form.Item name="snmp_interfaces" label={t('targets.snmpInterfaces')} extra={t('targets.snmpInterfacesHint')} rules={[{ required: true }]}>
+                  <Select mode="tags" open={false} placeholder="GigabitEthernet0/1" />
+                </Form.Item>
+                <Space wrap>
+                  <Form.Item name="snmp_version" label={t('targets.snmpVersion')}>
+                    <Select style={{ width: 90 }} options={[{ label: 'v2c', value: '2c' }, { label: 'v3', value: '3' }]} />
+                  </Form.Item>
+                  <Form.Item name="snmp_host" label={t('targets.snmpHost')}>
+                    <Input placeholder={t('targets.snmpHostPlaceholder')} />
+                  </Form.Item>
+                  <Form.Item name="snmp_port" label={t('targets.snmpPort')}>
+                    <Input placeholder="161" style={{ width: 80 }} />
+                  </Form.Item>
+                </Space>
+                {get('snmp_version') === '3' ? (
+                  <>
+                    <Form.Item name="snmp_user" label={t('targets.snmpUser')} rules={[{ required: true }]}>
+                      <Input />
+                    </Form.Item>
+                    <Space wrap>
+                      <Form.Item name="snmp_auth_protocol" label={t('targets.snmpAuthProtocol')}>
+                        <Select style={{ width: 110 }} options={['MD5', 'SHA', 'SHA224', 'SHA256', 'SHA384', 'SHA512'].map((v) => ({ label: v, value: v }))} />
+                      </Form.Item>
+                      <Form.Item name="snmp_auth_password" label={t('targets.snmpAuthPassword')}>
+                        <Input.Password />
+                      </Form.Item>
+                    </Space>
+                    <Space wrap>
+                      <Form.Item name="snmp_priv_protocol" label={t('targets.snmpPrivProtocol')}>
+                        <Select style={{ width: 110 }} options={['DES', 'AES', 'AES192', 'AES256', 'AES192C', 'AES256C'].map((v) => ({ label: v, value: v }))} />
+                      </Form.Item>
+                      <Form.Item name="snmp_priv_password" label={t('targets.snmpPrivPassword')}>
+                        <Input.Password />
+                      </Form.Item>
+                    </Space>
+                  </>
+                ) : (
+                  <Form.Item name="snmp_community" label={t('targets.snmpCommunity')}>
+                    <Input.Password placeholder="public" />
+                  </Form.Item>
+                )}
+              </>
+            )}
+          </Form.Item>
           <Form.Item name="desc" label={t('targets.description')}>
             <Input.TextArea rows={3} />
           </Form.Item>
         </Form>
       </Modal>
+
+      <ParentSuggestions
+        open={suggestOpen}
+        targets={data}
+        onClose={() => setSuggestOpen(false)}
+        onApplied={() => {
+          message.success(t('dependencies.applied'));
+          setSuggestOpen(false);
+          refresh();
+        }}
+      />
     </Card>
   );
 };